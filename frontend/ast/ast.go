@@ -1,5 +1,12 @@
 package ast
 
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
 type NodeType string
 
 const (
@@ -10,6 +17,14 @@ const (
 	ArrayDeclerationNode    NodeType = "ArrayDeclerationNode"
 	MapDeclerationNode      NodeType = "MapDeclerationNode"
 	ShorthandOperatorNode   NodeType = "ShorthandOperatorNode" // e.g. ++, --, +=, -=, /=, *=
+	DeferStatementNode      NodeType = "DeferStatementNode"
+	SpawnStatementNode      NodeType = "SpawnStatementNode"
+	ExportStatementNode     NodeType = "ExportStatementNode"
+	ReturnStatementNode     NodeType = "ReturnStatementNode"
+	YieldStatementNode      NodeType = "YieldStatementNode"
+	DestructuringNode       NodeType = "DestructuringNode" // e.g. let [a, b] = arr;
+	ArrayPatternNode        NodeType = "ArrayPatternNode"
+	MapPatternNode          NodeType = "MapPatternNode"
 
 	// Namespace and Environment.
 	NamespaceDeclerationNode NodeType = "NamespaceDeclerationNode"
@@ -21,13 +36,18 @@ const (
 	UnaryExprNode        NodeType = "UnaryExprNode"
 	FuncDeclerationNode  NodeType = "FuncDeclerationNode"
 	MemberExpressionNode NodeType = "MemberExpressionNode"
+	SpreadExprNode       NodeType = "SpreadExprNode"
+	SliceExprNode        NodeType = "SliceExprNode" // e.g. arr[1:3], str[:-1]
 
 	// Literals.
 	NumericLiteralNode  NodeType = "NumericLiteralNode"
+	BigIntLiteralNode   NodeType = "BigIntLiteralNode"
 	StringLiteralNode   NodeType = "StringLiteralNode"
+	BytesLiteralNode    NodeType = "BytesLiteralNode"
 	BooleanLiteralNode  NodeType = "BooleanLiteralNode"
 	IdentifierNode      NodeType = "IdentifierNode"
 	ArrayIdentifierNode NodeType = "ArrayIdentifierNode"
+	ArrayLiteralNode    NodeType = "ArrayLiteralNode"
 	PropertyNode        NodeType = "PropertyNode"
 	ObjectLiteralNode   NodeType = "ObjectLiteralNode"
 
@@ -36,6 +56,7 @@ const (
 	TernaryNode NodeType = "TernaryNode"
 	WhileNode   NodeType = "WhileNode"
 	ForNode     NodeType = "ForNode"
+	ForInNode   NodeType = "ForInNode"
 
 	// Misc.
 	UnknownNode NodeType = "UnknownNode"
@@ -43,66 +64,247 @@ const (
 
 type Expression interface {
 	expr()
+	Location() Span
+}
+
+// A point in the source, counted the same way as the lexer counts token positions.
+type Position struct {
+	Line int
+	Col  int
+}
+
+// The part of the source a node was parsed from, End is just past its last token. Nodes
+// made up by the parser, rather than read from the source, have a zero span.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// Returns where the node was written.
+func (s Span) Location() Span {
+	return s
+}
+
+// True when the node has no position, i.e. it was made up by the parser.
+func (s Span) IsZero() bool {
+	return s.Start.Line == 0
 }
 
 type Expr struct {
 	Kind NodeType
+	Span
 }
 
 func (e Expr) expr() {}
 
 type Program struct {
 	Kind NodeType
+	Span
 	Body []Expression
 }
 
 func (p Program) expr() {}
 
 type VariableDecleration struct {
-	Kind       NodeType
+	Kind NodeType
+	Span
 	Value      Expression
 	Constant   bool
 	Identifier string
+	Type       *TypeAnnotation // nil when not annotated.
 }
 
 func (v VariableDecleration) expr() {}
 
 type ArrayDecleration struct {
-	Kind       NodeType
+	Kind NodeType
+	Span
 	Value      []Expression
 	Constant   bool
 	Identifier string
+	Type       *TypeAnnotation // nil when not annotated.
 }
 
 func (a ArrayDecleration) expr() {}
 
 type MapDecleration struct {
-	Kind       NodeType
+	Kind NodeType
+	Span
 	Identifier string
-	Value      map[Expression]Expression
+	Value      []MapEntry // In the order they were written.
 	Constant   bool
+	Type       *TypeAnnotation // nil when not annotated.
 }
 
 func (m MapDecleration) expr() {}
 
+// A single key/value pair of a map decleration.
+type MapEntry struct {
+	Key   Expression
+	Value Expression
+}
+
 type FunctionDecleration struct {
-	Kind   NodeType
-	Params []string
-	Name   string
-	Body   []Expression
+	Kind NodeType
+	Span
+	Params     []Parameter
+	Name       string
+	Body       []Expression
+	ReturnType *TypeAnnotation // nil when not annotated.
+	Generator  bool            // Set when the body contains a 'yield'.
 }
 
 func (f FunctionDecleration) expr() {}
 
+// A single function parameter, e.g. 'name', 'greeting = "hi"' or '...rest'.
+type Parameter struct {
+	Name    string
+	Default Expression      // nil when no default value is given.
+	Rest    bool            // Collects any remaining args into an array.
+	Type    *TypeAnnotation // nil when not annotated, the element type for rest params.
+	Pattern *Pattern        // Set when the arg is destructured, i.e. fn f([a, b]).
+}
+
+// An optional type annotation, e.g. 'int', 'array<string>' or 'map<string, int>'.
+type TypeAnnotation struct {
+	Name   string
+	Params []TypeAnnotation
+	Line   int
+	Col    int
+}
+
+// Renders the annotation as it would be written in source.
+func (t TypeAnnotation) String() string {
+
+	if len(t.Params) == 0 {
+		return t.Name
+	}
+
+	params := make([]string, 0)
+	for _, p := range t.Params {
+		params = append(params, p.String())
+	}
+
+	return t.Name + "<" + strings.Join(params, ", ") + ">"
+}
+
+type DeferStatement struct {
+	Kind NodeType
+	Span
+	Call CallExpr
+}
+
+func (d DeferStatement) expr() {}
+
+// Runs a call as a concurrent task, i.e. spawn worker(jobs);
+type SpawnStatement struct {
+	Kind NodeType
+	Span
+	Call CallExpr
+}
+
+func (s SpawnStatement) expr() {}
+
+// Makes a top level decleration available to programs using this one as a module,
+// i.e. export fn add(a, b) { ... }
+type ExportStatement struct {
+	Kind NodeType
+	Span
+	Decleration Expression
+	Name        string // The name the decleration introduces.
+}
+
+func (e ExportStatement) expr() {}
+
+type ReturnStatement struct {
+	Kind NodeType
+	Span
+	Value Expression
+}
+
+func (r ReturnStatement) expr() {}
+
+// Hands a value to whoever is iterating over a generator, i.e. yield x;
+type YieldStatement struct {
+	Kind NodeType
+	Span
+	Value Expression
+}
+
+func (y YieldStatement) expr() {}
+
+type DestructuringDecleration struct {
+	Kind NodeType
+	Span
+	Pattern  Pattern
+	Value    Expression
+	Constant bool
+}
+
+func (d DestructuringDecleration) expr() {}
+
+// A destructuring pattern, either an array '[a, b = 0, ...rest]' or a map '{host, port}'.
+type Pattern struct {
+	Kind     NodeType
+	Elements []PatternElement
+}
+
+// A single name bound by a pattern. Array patterns may nest, in which case Pattern is set
+// instead of Name.
+type PatternElement struct {
+	Name    string
+	Pattern *Pattern
+	Default Expression // nil when no default value is given.
+	Rest    bool       // Collects the remaining elements or keys.
+}
+
+// Renders the pattern as it would be written in source, without any default values.
+func (p Pattern) String() string {
+
+	elements := make([]string, 0)
+	for _, e := range p.Elements {
+		elements = append(elements, e.String())
+	}
+
+	if p.Kind == MapPatternNode {
+		return "{" + strings.Join(elements, ", ") + "}"
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+func (e PatternElement) String() string {
+
+	name := e.Name
+	if e.Pattern != nil {
+		name = e.Pattern.String()
+	}
+
+	if e.Rest {
+		return "..." + name
+	}
+
+	return name
+}
+
 type NamespaceDecleration struct {
 	Kind NodeType
-	Name string
+	Span
+	Imports []Import
+}
+
+// A single import of a using directive.
+type Import struct {
+	Name    string   // A stdlib namespace, or the path of a .gob module.
+	Alias   string   // The name it is used under, empty to use the default.
+	Members []string // Set when only these names are brought into scope, i.e. using { split } from "strings";
 }
 
 func (n NamespaceDecleration) expr() {}
 
 type BinaryExpr struct {
-	Kind     NodeType
+	Kind NodeType
+	Span
 	Left     Expression
 	Right    Expression
 	Operator string
@@ -111,15 +313,25 @@ type BinaryExpr struct {
 func (b BinaryExpr) expr() {}
 
 type CallExpr struct {
-	Kind   NodeType
+	Kind NodeType
+	Span
 	Args   []Expression
 	Caller Expression
 }
 
 func (c CallExpr) expr() {}
 
+type SpreadExpr struct {
+	Kind NodeType
+	Span
+	Argument Expression
+}
+
+func (s SpreadExpr) expr() {}
+
 type MemberExpr struct {
-	Kind     NodeType
+	Kind NodeType
+	Span
 	Object   Expression
 	Property Expression
 	Computed bool
@@ -128,7 +340,8 @@ type MemberExpr struct {
 func (m MemberExpr) expr() {}
 
 type AssignmentExpr struct {
-	Kind    NodeType
+	Kind NodeType
+	Span
 	Value   Expression
 	Assigne Expression
 }
@@ -136,22 +349,44 @@ type AssignmentExpr struct {
 func (a AssignmentExpr) expr() {}
 
 type Identifier struct {
-	Kind   NodeType
+	Kind NodeType
+	Span
 	Symbol string
 }
 
 func (i Identifier) expr() {}
 
 type ArrayOrMapIdentifier struct {
-	Kind   NodeType
+	Kind NodeType
+	Span
 	Symbol string
 	Index  Expression
 }
 
 func (aom ArrayOrMapIdentifier) expr() {}
 
+type SliceExpr struct {
+	Kind NodeType
+	Span
+	Symbol string
+	Start  Expression // nil when open-ended, i.e. arr[:2].
+	End    Expression // nil when open-ended, i.e. arr[1:].
+}
+
+func (s SliceExpr) expr() {}
+
+type UnaryExpr struct {
+	Kind NodeType
+	Span
+	Operator string
+	Argument Expression
+}
+
+func (u UnaryExpr) expr() {}
+
 type ShorthandOperator struct {
-	Kind     NodeType
+	Kind NodeType
+	Span
 	Left     string
 	Right    Expression
 	Operator string
@@ -160,28 +395,58 @@ type ShorthandOperator struct {
 func (sho ShorthandOperator) expr() {}
 
 type NumericLiteral struct {
-	Kind  NodeType
+	Kind NodeType
+	Span
 	Value int
 }
 
 func (n NumericLiteral) expr() {}
 
+// An int literal too large to fit in a NumericLiteral.
+type BigIntLiteral struct {
+	Kind NodeType
+	Span
+	Value *big.Int
+}
+
+func (b BigIntLiteral) expr() {}
+
 type BooleanLiteral struct {
-	Kind  NodeType
+	Kind NodeType
+	Span
 	Value bool
 }
 
 func (b BooleanLiteral) expr() {}
 
 type StringLiteral struct {
-	Kind  NodeType
+	Kind NodeType
+	Span
 	Value string
 }
 
 func (b StringLiteral) expr() {}
 
+// An array used as a value, i.e. 'arr[1:3] = [4, 5];'.
+type ArrayLiteral struct {
+	Kind NodeType
+	Span
+	Elements []Expression
+}
+
+func (a ArrayLiteral) expr() {}
+
+type BytesLiteral struct {
+	Kind NodeType
+	Span
+	Value []byte
+}
+
+func (b BytesLiteral) expr() {}
+
 type IfCondition struct {
-	Kind      NodeType
+	Kind NodeType
+	Span
 	Condition Expression
 	Body      []Expression
 	ElseCatch bool
@@ -191,7 +456,8 @@ type IfCondition struct {
 func (n IfCondition) expr() {}
 
 type TernaryCondition struct {
-	Kind      NodeType
+	Kind NodeType
+	Span
 	Condition Expression
 	Left      Expression
 	Right     Expression
@@ -200,7 +466,8 @@ type TernaryCondition struct {
 func (t TernaryCondition) expr() {}
 
 type WhileLoop struct {
-	Kind      NodeType
+	Kind NodeType
+	Span
 	Condition Expression
 	Body      []Expression
 }
@@ -208,7 +475,8 @@ type WhileLoop struct {
 func (w WhileLoop) expr() {}
 
 type ForLoop struct {
-	Kind       NodeType
+	Kind NodeType
+	Span
 	Assignment VariableDecleration
 	Condition  BinaryExpr
 	Iterator   ShorthandOperator
@@ -217,6 +485,16 @@ type ForLoop struct {
 
 func (f ForLoop) expr() {}
 
+type ForInLoop struct {
+	Kind NodeType
+	Span
+	Binding  PatternElement // The loop variable, or a pattern to destructure each item into.
+	Iterable Expression
+	Body     []Expression
+}
+
+func (f ForInLoop) expr() {}
+
 type Property struct {
 	Kind  string
 	Key   string
@@ -224,7 +502,8 @@ type Property struct {
 }
 
 type ObjectLiteral struct {
-	Kind       NodeType
+	Kind NodeType
+	Span
 	Properties []Property
 }
 
@@ -232,6 +511,28 @@ func (o ObjectLiteral) expr() {}
 
 type Unknown struct {
 	Kind NodeType
+	Span
 }
 
 func (u Unknown) expr() {}
+
+// Names the kind of node in words, for use in error messages, i.e. 'array literal' for an
+// ArrayLiteral.
+func Describe(node Expression) string {
+
+	if node == nil {
+		return "nothing"
+	}
+
+	var b strings.Builder
+	for i, r := range reflect.TypeOf(node).Name() {
+
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteRune(' ')
+		}
+
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
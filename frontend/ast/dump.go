@@ -0,0 +1,130 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Renders a node and everything under it as an indented tree, one node per line, i.e.
+//
+//	Program
+//	  Body:
+//	    VariableDecleration Identifier: "day"
+//	      Value: NumericLiteral Value: 86400
+//
+// Fields left empty, other than the value of a literal, and where each node was written
+// are omitted.
+func Dump(node Expression) string {
+
+	var b strings.Builder
+	dump(&b, reflect.ValueOf(node), 0, "")
+
+	return b.String()
+}
+
+func dump(b *strings.Builder, v reflect.Value, depth int, label string) {
+
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+
+		if v.IsNil() {
+			return
+		}
+
+		v = v.Elem()
+	}
+
+	indent := strings.Repeat("  ", depth)
+
+	if v.Kind() != reflect.Struct {
+		fmt.Fprintf(b, "%v%v%v\n", indent, label, scalar(v))
+		return
+	}
+
+	fields := make([]string, 0)
+	children := make([]reflect.StructField, 0)
+
+	for i := 0; i < v.NumField(); i++ {
+
+		field := v.Type().Field(i)
+		value := v.Field(i)
+
+		// Literals are always shown, even when zero, i.e. 'NumericLiteral Value: 0'.
+		if field.Name == "Kind" || field.Name == "Span" || (value.IsZero() && field.Name != "Value") {
+			continue
+		}
+
+		if leaf(value) {
+			fields = append(fields, fmt.Sprintf("%v: %v", field.Name, scalar(value)))
+		} else {
+			children = append(children, field)
+		}
+	}
+
+	fmt.Fprintf(b, "%v%v%v", indent, label, v.Type().Name())
+	if len(fields) > 0 {
+		fmt.Fprintf(b, " %v", strings.Join(fields, ", "))
+	}
+	b.WriteString("\n")
+
+	for _, field := range children {
+
+		value := v.FieldByIndex(field.Index)
+
+		if value.Kind() != reflect.Slice {
+			dump(b, value, depth+1, field.Name+": ")
+			continue
+		}
+
+		fmt.Fprintf(b, "%v  %v:\n", indent, field.Name)
+		for i := 0; i < value.Len(); i++ {
+			dump(b, value.Index(i), depth+2, "")
+		}
+	}
+}
+
+// Whether a field is shown on the same line as its node, rather than as a child of it.
+// Patterns are rendered in full, as their defaults are expressions too.
+func leaf(v reflect.Value) bool {
+
+	switch v.Interface().(type) {
+	case Pattern, *Pattern, PatternElement:
+		return false
+	case fmt.Stringer:
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		return v.Type().Elem().Kind() == reflect.String || v.Type().Elem().Kind() == reflect.Uint8
+	case reflect.Interface, reflect.Struct:
+		return false
+	case reflect.Pointer:
+		return leaf(v.Elem())
+	}
+
+	return true
+}
+
+func scalar(v reflect.Value) string {
+
+	if s, isStringer := v.Interface().(fmt.Stringer); isStringer && leaf(v) {
+		return s.String()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("%q", v.String())
+	case reflect.Slice:
+
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return fmt.Sprintf("b%q", v.Bytes())
+		}
+
+		return fmt.Sprintf("%q", v.Interface())
+	case reflect.Pointer:
+		return scalar(v.Elem())
+	}
+
+	return fmt.Sprintf("%v", v.Interface())
+}
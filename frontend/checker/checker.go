@@ -0,0 +1,524 @@
+package checker
+
+import (
+	"fmt"
+
+	"goblin.org/main/frontend/ast"
+	"goblin.org/main/utils"
+)
+
+/*
+Static type checker, run over the AST before it is evaluated.
+
+Only annotated declerations, params and return types are checked. Anything without an
+annotation is treated as 'any', so unannotated programs always pass.
+*/
+
+// Origin every type error is prefixed with, used to line up the error underline.
+const Origin = "type error: "
+
+type TypeEnv struct {
+	Parent     *TypeEnv
+	Variables  map[string]ast.TypeAnnotation
+	Functions  map[string]ast.FunctionDecleration
+	FnName     string              // Name of the enclosing function, if any.
+	ReturnType *ast.TypeAnnotation // Return type of the enclosing function, if annotated.
+	Audit      map[int]string
+	Errors     *[]error
+}
+
+// Type checks an entire program, returning every mismatch found.
+func Check(program ast.Program, audit map[int]string) []error {
+
+	errs := make([]error, 0)
+
+	env := TypeEnv{
+		Variables: map[string]ast.TypeAnnotation{},
+		Functions: map[string]ast.FunctionDecleration{},
+		Audit:     audit,
+		Errors:    &errs,
+	}
+
+	check_block(program.Body, env)
+
+	return errs
+}
+
+// Records a type error, formatted the same way as parser errors.
+func (e TypeEnv) Report(line int, col int, message string) {
+
+	m := utils.GenerateError(Origin, e.Audit[line], "", line, col, message)
+
+	*e.Errors = append(*e.Errors, fmt.Errorf("%v", m))
+}
+
+// Creates a new scope nested inside this one.
+func (e TypeEnv) Child() TypeEnv {
+
+	return TypeEnv{
+		Parent:     &e,
+		Variables:  map[string]ast.TypeAnnotation{},
+		Functions:  map[string]ast.FunctionDecleration{},
+		FnName:     e.FnName,
+		ReturnType: e.ReturnType,
+		Audit:      e.Audit,
+		Errors:     e.Errors,
+	}
+}
+
+// Returns the type a variable was declared with, 'any' if it is unknown.
+func (e TypeEnv) LookupVariable(name string) ast.TypeAnnotation {
+
+	if t, ok := e.Variables[name]; ok {
+		return t
+	}
+
+	if e.Parent == nil {
+		return named("any")
+	}
+
+	return e.Parent.LookupVariable(name)
+}
+
+// Returns the decleration of a user-defined function, if one is in scope.
+func (e TypeEnv) LookupFunction(name string) (ast.FunctionDecleration, bool) {
+
+	if fn, ok := e.Functions[name]; ok {
+		return fn, true
+	}
+
+	if e.Parent == nil {
+		return ast.FunctionDecleration{}, false
+	}
+
+	return e.Parent.LookupFunction(name)
+}
+
+// Builds a simple, non-generic type.
+func named(name string) ast.TypeAnnotation {
+	return ast.TypeAnnotation{Name: name}
+}
+
+// Can a value of type 'actual' be stored somewhere annotated with type 'target'?
+func Assignable(target ast.TypeAnnotation, actual ast.TypeAnnotation) bool {
+
+	if target.Name == "any" || actual.Name == "any" {
+		return true
+	}
+
+	// Ints widen to floats.
+	if target.Name == "float" && actual.Name == "int" {
+		return true
+	}
+
+	if target.Name != actual.Name {
+		return false
+	}
+
+	// Nothing is known about the contents, e.g. an empty array.
+	if len(actual.Params) == 0 || len(target.Params) != len(actual.Params) {
+		return true
+	}
+
+	for i := range target.Params {
+		if !Assignable(target.Params[i], actual.Params[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Checks each statement of a block in order.
+func check_block(body []ast.Expression, env TypeEnv) {
+
+	for _, stmt := range body {
+		check_statement(stmt, env)
+	}
+}
+
+// Checks a single statement.
+func check_statement(stmt ast.Expression, env TypeEnv) {
+
+	switch s := stmt.(type) {
+	case ast.VariableDecleration:
+		check_var_decleration(s, env)
+	case ast.DestructuringDecleration:
+		infer_type(s.Value, env)
+		declare_pattern(s.Pattern, env)
+	case ast.ArrayDecleration:
+		check_array_decleration(s, env)
+	case ast.MapDecleration:
+		check_map_decleration(s, env)
+	case ast.FunctionDecleration:
+		check_function_decleration(s, env)
+	case ast.ReturnStatement:
+		check_return_statement(s, env)
+	case ast.YieldStatement:
+		infer_type(s.Value, env)
+	case ast.DeferStatement:
+		infer_type(s.Call, env)
+	case ast.SpawnStatement:
+		infer_type(s.Call, env)
+	case ast.ExportStatement:
+		check_statement(s.Decleration, env)
+	case ast.IfCondition:
+		infer_type(s.Condition, env)
+		check_block(s.Body, env)
+		check_block(s.ElseBody, env)
+	case ast.WhileLoop:
+		infer_type(s.Condition, env)
+		check_block(s.Body, env)
+	case ast.ForLoop:
+		loopEnv := env.Child()
+		check_var_decleration(s.Assignment, loopEnv)
+		infer_type(s.Condition, loopEnv)
+		check_block(s.Body, loopEnv.Child())
+	case ast.ForInLoop:
+		infer_type(s.Iterable, env)
+		iterationEnv := env.Child()
+		declare_element(s.Binding, iterationEnv)
+		check_block(s.Body, iterationEnv)
+	default:
+		infer_type(stmt, env)
+	}
+}
+
+// Checks a 'let' or 'const' decleration against its annotation.
+func check_var_decleration(dec ast.VariableDecleration, env TypeEnv) {
+
+	declared := named("any")
+
+	if dec.Type != nil {
+
+		declared = *dec.Type
+
+		// 'let int x;' has nothing to check yet.
+		if _, isEmpty := dec.Value.(ast.Expr); !isEmpty && dec.Value != nil {
+
+			actual := infer_type(dec.Value, env)
+			if !Assignable(declared, actual) {
+				env.Report(dec.Type.Line, dec.Type.Col, fmt.Sprintf("cannot use %v as %v in decleration of '%v'", actual, declared, dec.Identifier))
+			}
+		}
+	} else if dec.Value != nil {
+		infer_type(dec.Value, env)
+	}
+
+	env.Variables[dec.Identifier] = declared
+}
+
+// Checks the elements of an array decleration against its annotation.
+func check_array_decleration(arr ast.ArrayDecleration, env TypeEnv) {
+
+	declared := named("any")
+	element := named("any")
+
+	if arr.Type != nil {
+
+		declared = *arr.Type
+
+		if !Assignable(declared, named("array")) {
+			env.Report(arr.Type.Line, arr.Type.Col, fmt.Sprintf("cannot use array as %v in decleration of '%v'", declared, arr.Identifier))
+		} else if declared.Name == "array" {
+			element = declared.Params[0]
+		}
+	}
+
+	for i, val := range arr.Value {
+
+		actual := infer_type(val, env)
+		if !Assignable(element, actual) {
+			env.Report(arr.Type.Line, arr.Type.Col, fmt.Sprintf("cannot use %v as %v in element %v of '%v'", actual, element, i, arr.Identifier))
+		}
+	}
+
+	env.Variables[arr.Identifier] = declared
+}
+
+// Checks the keys and values of a map decleration against its annotation.
+func check_map_decleration(m ast.MapDecleration, env TypeEnv) {
+
+	declared := named("any")
+	keyType := named("any")
+	valueType := named("any")
+
+	if m.Type != nil {
+
+		declared = *m.Type
+
+		if !Assignable(declared, named("map")) {
+			env.Report(m.Type.Line, m.Type.Col, fmt.Sprintf("cannot use map as %v in decleration of '%v'", declared, m.Identifier))
+		} else if declared.Name == "map" {
+			keyType = declared.Params[0]
+			valueType = declared.Params[1]
+		}
+	}
+
+	for _, entry := range m.Value {
+
+		actualKey := infer_type(entry.Key, env)
+		if !Assignable(keyType, actualKey) {
+			env.Report(m.Type.Line, m.Type.Col, fmt.Sprintf("cannot use %v as %v in key of '%v'", actualKey, keyType, m.Identifier))
+		}
+
+		actualValue := infer_type(entry.Value, env)
+		if !Assignable(valueType, actualValue) {
+			env.Report(m.Type.Line, m.Type.Col, fmt.Sprintf("cannot use %v as %v in value of '%v'", actualValue, valueType, m.Identifier))
+		}
+	}
+
+	env.Variables[m.Identifier] = declared
+}
+
+// Declares every name bound by a destructuring pattern, nothing is known about their types.
+func declare_pattern(pattern ast.Pattern, env TypeEnv) {
+
+	for _, element := range pattern.Elements {
+
+		if element.Default != nil {
+			infer_type(element.Default, env)
+		}
+
+		declare_element(element, env)
+	}
+}
+
+// Declares the name bound by a single pattern element.
+func declare_element(element ast.PatternElement, env TypeEnv) {
+
+	if element.Pattern != nil {
+		declare_pattern(*element.Pattern, env)
+		return
+	}
+
+	env.Variables[element.Name] = named("any")
+}
+
+// Checks a function's default param values and body.
+func check_function_decleration(f ast.FunctionDecleration, env TypeEnv) {
+
+	// Declared before the body is checked, so recursive calls are checked too.
+	env.Functions[f.Name] = f
+	env.Variables[f.Name] = named("any")
+
+	fnEnv := env.Child()
+	fnEnv.FnName = f.Name
+	fnEnv.ReturnType = f.ReturnType
+
+	// Generators hand back an iterator, whatever their body returns.
+	if f.Generator {
+		fnEnv.ReturnType = nil
+	}
+
+	for _, param := range f.Params {
+
+		declared := named("any")
+		if param.Type != nil {
+			declared = *param.Type
+		}
+
+		if param.Default != nil {
+
+			actual := infer_type(param.Default, fnEnv)
+			if param.Type != nil && !Assignable(declared, actual) {
+				env.Report(param.Type.Line, param.Type.Col, fmt.Sprintf("cannot use %v as %v for default of param '%v'", actual, declared, param.Name))
+			}
+		}
+
+		// Rest params are annotated with their element type.
+		if param.Rest {
+			declared = ast.TypeAnnotation{Name: "array", Params: []ast.TypeAnnotation{declared}}
+		}
+
+		if param.Pattern != nil {
+			declare_pattern(*param.Pattern, fnEnv)
+			continue
+		}
+
+		fnEnv.Variables[param.Name] = declared
+	}
+
+	check_block(f.Body, fnEnv)
+}
+
+// Checks a returned value against the enclosing function's return type.
+func check_return_statement(r ast.ReturnStatement, env TypeEnv) {
+
+	actual := named("null")
+	if r.Value != nil {
+		actual = infer_type(r.Value, env)
+	}
+
+	if env.ReturnType != nil && !Assignable(*env.ReturnType, actual) {
+		env.Report(r.Start.Line, r.Start.Col, fmt.Sprintf("fn %v returns %v, got %v", env.FnName, *env.ReturnType, actual))
+	}
+}
+
+// Checks a call to a user-defined function against its annotated params.
+func check_call(call ast.CallExpr, fn ast.FunctionDecleration, env TypeEnv) {
+
+	for i, arg := range call.Args {
+
+		// Can't tell which params spread args land in, stop checking here.
+		if spread, isSpread := arg.(ast.SpreadExpr); isSpread {
+			infer_type(spread.Argument, env)
+			return
+		}
+
+		actual := infer_type(arg, env)
+
+		var param ast.Parameter
+		if i < len(fn.Params) {
+			param = fn.Params[i]
+		} else if len(fn.Params) > 0 && fn.Params[len(fn.Params)-1].Rest {
+			param = fn.Params[len(fn.Params)-1]
+		} else {
+			// Too many args, left to the runtime to report.
+			continue
+		}
+
+		if param.Type != nil && !Assignable(*param.Type, actual) {
+			env.Report(call.Start.Line, call.Start.Col, fmt.Sprintf("param '%v' of fn %v expects %v, got %v", param.Name, fn.Name, *param.Type, actual))
+		}
+	}
+}
+
+// Works out the type an expression evaluates to, checking any calls made along the way.
+func infer_type(expr ast.Expression, env TypeEnv) ast.TypeAnnotation {
+
+	switch e := expr.(type) {
+	case ast.NumericLiteral, ast.BigIntLiteral:
+		return named("int")
+	case ast.StringLiteral:
+		return named("string")
+	case ast.BytesLiteral:
+		return named("bytes")
+	case ast.BooleanLiteral:
+		return named("bool")
+	case ast.ObjectLiteral:
+		return named("object")
+	case ast.Identifier:
+
+		if e.Symbol == "null" {
+			return named("null")
+		}
+
+		return env.LookupVariable(e.Symbol)
+	case ast.ArrayOrMapIdentifier:
+
+		infer_type(e.Index, env)
+
+		container := env.LookupVariable(e.Symbol)
+		if container.Name == "array" && len(container.Params) == 1 {
+			return container.Params[0]
+		} else if container.Name == "map" && len(container.Params) == 2 {
+			return container.Params[1]
+		} else if container.Name == "string" {
+			return container
+		} else if container.Name == "bytes" {
+			return named("int")
+		}
+
+		return named("any")
+	case ast.SliceExpr:
+
+		if e.Start != nil {
+			infer_type(e.Start, env)
+		}
+		if e.End != nil {
+			infer_type(e.End, env)
+		}
+
+		// Slicing an array or string gives back the same type.
+		container := env.LookupVariable(e.Symbol)
+		if container.Name == "array" || container.Name == "string" || container.Name == "bytes" {
+			return container
+		}
+
+		return named("any")
+	case ast.ArrayLiteral:
+
+		for _, el := range e.Elements {
+			infer_type(el, env)
+		}
+
+		return named("array")
+	case ast.UnaryExpr:
+
+		if infer_type(e.Argument, env).Name == "int" {
+			return named("int")
+		}
+
+		return named("any")
+	case ast.BinaryExpr:
+
+		left := infer_type(e.Left, env)
+		right := infer_type(e.Right, env)
+
+		switch e.Operator {
+		case "<", ">", "<=", ">=", "==", "!=":
+			return named("bool")
+		}
+
+		if left.Name == "int" && right.Name == "int" {
+			return named("int")
+		}
+
+		return named("any")
+	case ast.TernaryCondition:
+
+		infer_type(e.Condition, env)
+		left := infer_type(e.Left, env)
+		right := infer_type(e.Right, env)
+
+		if left.String() == right.String() {
+			return left
+		}
+
+		return named("any")
+	case ast.AssignmentExpr:
+
+		actual := infer_type(e.Value, env)
+
+		if iden, ok := e.Assigne.(ast.Identifier); ok {
+
+			declared := env.LookupVariable(iden.Symbol)
+			if !Assignable(declared, actual) {
+				env.Report(e.Start.Line, e.Start.Col, fmt.Sprintf("cannot assign %v to '%v' of type %v", actual, iden.Symbol, declared))
+			}
+		}
+
+		return actual
+	case ast.CallExpr:
+
+		if iden, ok := e.Caller.(ast.Identifier); ok {
+
+			if fn, found := env.LookupFunction(iden.Symbol); found {
+
+				check_call(e, fn, env)
+
+				if fn.Generator {
+					return named("iterator")
+				}
+
+				if fn.ReturnType != nil {
+					return *fn.ReturnType
+				}
+
+				return named("any")
+			}
+		}
+
+		for _, arg := range e.Args {
+			infer_type(arg, env)
+		}
+
+		return named("any")
+	case ast.SpreadExpr:
+		infer_type(e.Argument, env)
+		return named("any")
+	}
+
+	return named("any")
+}
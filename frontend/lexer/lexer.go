@@ -4,291 +4,356 @@ import (
 	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"goblin.org/main/utils"
 )
 
-func Tokenize(sourceCode string) ([]Token, map[int]string) {
+// Splits the source into tokens, along with an audit trail of each line, used to point at
+// errors later on. Returns a LexError for the first part of the source that isn't valid.
+func Tokenize(sourceCode string) ([]Token, map[int]string, error) {
 
-	tokens := make(Tokens, 0)
-	audit := make(map[int]string)
-	auditBuilder := ""
-
-	src := strings.Split(sourceCode, "")
-
-	line := 1
-	col := 0
-
-	for len(src) > 0 {
+	s := scanner{
+		src:    sourceCode,
+		line:   1,
+		tokens: make(Tokens, 0, len(sourceCode)/4),
+		audit:  make(map[int]string),
+	}
 
-		if src[0] == "\n" {
+	for s.pos < len(s.src) {
+
+		r := s.peek(0)
+
+		switch {
+		case r == '\n':
+
+			// Capture the line we just lexed, blank lines included so later lines keep their number.
+			s.audit[s.line] = s.auditBuilder.String()
+
+			// Increment the line count.
+			s.line++
+
+			// Reset the builder and the col counter.
+			s.auditBuilder.Reset()
+			s.col = 0
+			s.advance(1)
+		case r == ' ':
+			s.auditBuilder.WriteByte(' ')
+			s.col++
+			s.advance(1)
+		case r == '\t' || r == '\r':
+			// Skipped without being counted.
+			s.advance(1)
+		case r == '(':
+			s.symbol(OpenParen, 1)
+		case r == ')':
+			s.symbol(CloseParen, 1)
+		case r == '{':
+			s.symbol(OpenBrace, 1)
+		case r == '}':
+			s.symbol(CloseBrace, 1)
+		case r == '[':
+			s.symbol(OpenBracket, 1)
+		case r == ']':
+			s.symbol(CloseBracket, 1)
+		case r == ';':
+			s.symbol(EOL, 1)
+		case r == ':':
+			s.symbol(Colon, 1)
+		case r == ',':
+			s.symbol(Comma, 1)
+		case r == '?':
+			s.symbol(Ternary, 1)
+		case r == '.':
+
+			// Spread or rest operator '...'.
+			if strings.HasPrefix(s.rest(), "...") {
+				s.symbol(Ellipsis, 3)
+			} else {
+				s.symbol(Period, 1)
+			}
+		case r == '+' || r == '-':
 
-			// Only capture a line if there is anything there.
-			if auditBuilder != "" {
+			// Shorthand ++, --, += or -=, otherwise a standard BinOp.
+			if next := s.peek(1); next == r || next == '=' {
+				s.symbol(ShorthandOperator, 2)
+			} else {
+				s.symbol(BinaryOperator, 1)
+			}
+		case strings.ContainsRune("*/%&|^~<>", r):
 
-				// Capture the line we just lexed.
-				audit[line] = auditBuilder
+			op := operator(s.rest())
+			tokenType := BinaryOperator
 
-				// Increment the line count.
-				line++
+			if op == "<" || op == ">" || op == "<=" || op == ">=" {
+				// Comparison operators.
+				tokenType = ConditionalOperator
+			} else if strings.HasSuffix(op, "=") {
+				// Shorthand operators, i.e. '*=', '**=', '<<=' or '~/='.
+				tokenType = ShorthandOperator
 			}
 
-			// Reset the builder
-			auditBuilder = ""
-
-			// Reset the col counter.
-			col = 0
-		}
-		if src[0] == " " {
-			auditBuilder += src[0]
-			col++
-		}
+			s.symbol(tokenType, len(op))
+		case r == '=':
 
-		if src[0] == "(" {
-			auditBuilder += src[0]
-			tokens = append(tokens, token(OpenParen, utils.Shift[string](&src), line, col))
-			col++
-		} else if src[0] == ")" {
-			auditBuilder += src[0]
-			tokens = append(tokens, token(CloseParen, utils.Shift[string](&src), line, col))
-			col++
-		} else if src[0] == "{" {
-			auditBuilder += src[0]
-			tokens = append(tokens, token(OpenBrace, utils.Shift[string](&src), line, col))
-			col++
-		} else if src[0] == "}" {
-			auditBuilder += src[0]
-			tokens = append(tokens, token(CloseBrace, utils.Shift[string](&src), line, col))
-			col++
-		} else if src[0] == "[" {
-			auditBuilder += src[0]
-			tokens = append(tokens, token(OpenBracket, utils.Shift[string](&src), line, col))
-			col++
-		} else if src[0] == "]" {
-			auditBuilder += src[0]
-			tokens = append(tokens, token(CloseBracket, utils.Shift[string](&src), line, col))
-			col++
-
-		} else if src[0] == "+" {
-
-			if (src[1] == "+") || (src[1] == "=") {
-				// Shorthand ++ or +=
-				auditBuilder += src[0] + src[1]
-				op := fmt.Sprintf("%v%v", utils.Shift[string](&src), utils.Shift[string](&src))
-				tokens = append(tokens, token(ShorthandOperator, op, line, col))
-				col += 2
+			if s.peek(1) == '=' {
+				s.symbol(Equality, 2)
 			} else {
-				// Standard + BinOp.
-				auditBuilder += src[0]
-				tokens = append(tokens, token(BinaryOperator, utils.Shift[string](&src), line, col))
-				col++
+				s.symbol(Equals, 1)
 			}
-		} else if src[0] == "-" {
-
-			if (src[1] == "-") || (src[1] == "=") {
-				// Shorthand -- or -=
-				auditBuilder += src[0] + src[1]
-				op := fmt.Sprintf("%v%v", utils.Shift[string](&src), utils.Shift[string](&src))
-				tokens = append(tokens, token(ShorthandOperator, op, line, col))
-				col += 2
-			} else {
-				// Standard - BinOp.
-				auditBuilder += src[0]
-				tokens = append(tokens, token(BinaryOperator, utils.Shift[string](&src), line, col))
-				col++
-			}
-		} else if src[0] == "/" || src[0] == "*" || src[0] == "%" {
-
-			// Shorthand operator or standard BinaryOperator?
-			if src[1] == "=" {
-				// Shorthand operator.
-				auditBuilder += src[0] + src[1]
-				op := fmt.Sprintf("%v%v", utils.Shift[string](&src), utils.Shift[string](&src))
-				tokens = append(tokens, token(ShorthandOperator, op, line, col))
-				col += 2
-			} else {
-				// Standard BinOp.
-				auditBuilder += src[0]
-				tokens = append(tokens, token(BinaryOperator, utils.Shift[string](&src), line, col))
-				col++
+		case r == '!' && s.peek(1) == '=':
+			s.symbol(NotEquality, 2)
+		case isDigit(r):
+			s.number()
+		case r == '"':
+			if err := s.stringLiteral(); err != nil {
+				return nil, nil, err
 			}
-		} else if src[0] == ">" || src[0] == "<" {
-			auditBuilder += src[0]
-			tokens = append(tokens, token(ConditionalOperator, utils.Shift[string](&src), line, col))
-			col++
-		} else if src[0] == "=" && src[1] != "=" {
-			auditBuilder += src[0]
-			tokens = append(tokens, token(Equals, utils.Shift[string](&src), line, col))
-			col++
-		} else if src[0] == ";" {
-			auditBuilder += src[0]
-			tokens = append(tokens, token(EOL, utils.Shift[string](&src), line, col))
-			col++
-		} else if src[0] == ":" {
-			auditBuilder += src[0]
-			tokens = append(tokens, token(Colon, utils.Shift[string](&src), line, col))
-			col++
-		} else if src[0] == "," {
-			auditBuilder += src[0]
-			tokens = append(tokens, token(Comma, utils.Shift[string](&src), line, col))
-			col++
-		} else if src[0] == "." {
-			auditBuilder += src[0]
-			tokens = append(tokens, token(Period, utils.Shift[string](&src), line, col))
-			col++
-		} else if src[0] == "?" {
-			auditBuilder += src[0]
-			tokens = append(tokens, token(Ternary, utils.Shift[string](&src), line, col))
-			col++
-		} else {
-
-			// Multicharacter tokens (<=, >=, ==, !=, etc...)
-
-			if src[0] == "=" && src[1] == "=" {
-
-				auditBuilder += src[0] + src[1]
-
-				// This is an '==' operator.
-				symbol := utils.Shift[string](&src)
-				symbol += utils.Shift[string](&src)
-
-				tokens = append(tokens, token(Equality, symbol, line, col))
-				col += 2
-
-			} else if src[0] == "!" && src[1] == "=" {
-
-				auditBuilder += src[0] + src[1]
-
-				// This is an '!=' operator.
-				symbol := utils.Shift[string](&src)
-				symbol += utils.Shift[string](&src)
-
-				tokens = append(tokens, token(NotEquality, symbol, line, col))
-				col += 2
-
-			} else if isInt(src[0]) {
-				// Builds a number token.
-				num := ""
-
-				for len(src) > 0 && isInt(src[0]) {
-					num += utils.Shift[string](&src)
-				}
-
-				tokens = append(tokens, token(Number, num, line, col))
-				auditBuilder += num
-				col += len(num)
-
-			} else if isQuote(src[0]) {
-
-				// Start of a string literal.
-				str := ""
-
-				// Shift past '"'.
-				utils.Shift[string](&src)
-
-				for len(src) > 0 && !isQuote(src[0]) {
-					c := utils.Shift[string](&src)
-					str += c
-				}
-
-				// Shift past '"'.
-				utils.Shift[string](&src)
-
-				tokens = append(tokens, token(String, str, line, col))
-				auditBuilder += str
-				col += (len(str) + 2) // Length of string + 2 for the quotes either side.
-
-			} else if isAlpha(src[0]) {
-				// Builds an identifier token.
-				iden := ""
-
-				for len(src) > 0 && isAlpha(src[0]) {
-					iden += utils.Shift[string](&src)
-				}
-
-				// Check for reserved keyword.
-				t, ok := Keywords[iden]
-				if !ok {
-
-					// If not exist, check to see is this is a bool value.
-					bVal, err := truthValue(iden)
-					if err == nil {
-						bsv := utils.BtoS(bVal)
-						tokens = append(tokens, token(Boolean, bsv, line, col))
-						auditBuilder += bsv
-						col += len(bsv)
-					} else {
-
-						// Really is an identifier.
-						tokens = append(tokens, token(Identifier, iden, line, col))
-						auditBuilder += iden
-						col += len(iden)
-					}
-				} else {
-					tokens = append(tokens, token(t, iden, line, col))
-					auditBuilder += iden
-					col += len(iden)
-				}
-
-			} else if isSkippable(src[0]) {
-				// Skips to next character.
-				utils.Shift(&src)
-			} else {
-				fmt.Printf("Unrecognised token in source: %v \n", src[0])
-				panic("Program exit.")
+		case r == 'b' && s.peek(1) == '"':
+			if err := s.bytesLiteral(); err != nil {
+				return nil, nil, err
 			}
+		case isIdentifierStart(r):
+			s.identifier()
+		default:
+
+			_, size := utf8.DecodeRuneInString(s.rest())
+			text := s.src[s.pos : s.pos+size]
+
+			return nil, nil, lexError(s.auditBuilder.String()+text, s.line, s.col, text, fmt.Sprintf("unrecognised character '%v'", text))
 		}
 	}
 
 	// Add in the EOF token.
-	tokens = append(tokens, token(EOF, "EOF", line, col))
+	s.tokens = append(s.tokens, token(EOF, "EOF", s.line, s.col))
 
 	// Add the final of the lexer audit.
-	audit[line] = auditBuilder
+	s.audit[s.line] = s.auditBuilder.String()
+
+	return s.tokens, s.audit, nil
+}
+
+// Walks through the source a rune at a time. Columns count runes, so a line holding
+// multibyte characters still lines up with where the error is.
+type scanner struct {
+	src  string
+	pos  int // Byte offset of the current rune.
+	line int
+	col  int
+
+	tokens       Tokens
+	audit        map[int]string
+	auditBuilder strings.Builder // The line being lexed, as it is shown in errors.
+}
+
+// Returns the rune n runes ahead of the cursor, or 0 past the end of the source.
+func (s *scanner) peek(n int) rune {
+
+	pos := s.pos
+	for ; n > 0 && pos < len(s.src); n-- {
+		_, size := utf8.DecodeRuneInString(s.src[pos:])
+		pos += size
+	}
+
+	if pos >= len(s.src) {
+		return 0
+	}
+
+	r, _ := utf8.DecodeRuneInString(s.src[pos:])
+
+	return r
+}
+
+// The source from the cursor onwards.
+func (s *scanner) rest() string {
+	return s.src[s.pos:]
+}
+
+// Moves the cursor n runes along, returning the text moved past.
+func (s *scanner) advance(n int) string {
+
+	start := s.pos
+	for ; n > 0 && s.pos < len(s.src); n-- {
+		_, size := utf8.DecodeRuneInString(s.src[s.pos:])
+		s.pos += size
+	}
+
+	return s.src[start:s.pos]
+}
+
+// Moves the cursor past every rune matching, returning the text moved past.
+func (s *scanner) advanceWhile(match func(rune) bool) string {
+
+	start := s.pos
+	for s.pos < len(s.src) {
+
+		r, size := utf8.DecodeRuneInString(s.src[s.pos:])
+		if !match(r) {
+			break
+		}
+
+		s.pos += size
+	}
+
+	return s.src[start:s.pos]
+}
+
+// Adds a token to the list and the audit trail.
+func (s *scanner) emit(tknType TokenType, text string) {
+
+	s.tokens = append(s.tokens, token(tknType, text, s.line, s.col))
+	s.auditBuilder.WriteString(text)
+	s.col += utf8.RuneCountInString(text)
+}
+
+// Adds a token made up of the next n symbol characters.
+func (s *scanner) symbol(tknType TokenType, n int) {
 
-	return tokens, audit
+	text := s.advance(n)
+	s.emit(tknType, text)
 }
 
-// Checks to see if we are starting a new string.
-func isQuote(src string) bool {
+// Builds a number token.
+func (s *scanner) number() {
 
-	q := []rune(src)
-	return q[0] == '"'
+	num := s.advanceWhile(isDigit)
+	s.emit(Number, num)
 }
 
-// Checks to see if the src[0] contains alpha characters only.
-func isAlpha(src string) bool {
+// Builds a string literal token, the quotes aren't part of its value.
+func (s *scanner) stringLiteral() error {
+
+	// Move past '"'.
+	s.advance(1)
 
-	r := []rune(src)
+	str := s.advanceWhile(func(r rune) bool { return r != '"' })
 
-	return unicode.IsLetter(r[0])
+	if s.pos >= len(s.src) {
+		return lexError(s.auditBuilder.String()+"\""+str, s.line, s.col, "\""+str, "unterminated string")
+	}
+
+	// Move past '"'.
+	s.advance(1)
+
+	s.tokens = append(s.tokens, token(String, str, s.line, s.col))
+	s.auditBuilder.WriteString(str)
+	s.col += utf8.RuneCountInString(str) + 2 // Length of string + 2 for the quotes either side.
+
+	return nil
 }
 
-// Checks to see if the src[0] is numeric.
-func isInt(src string) bool {
+// Builds a bytes literal token, escapes are kept as written and decoded by the parser.
+func (s *scanner) bytesLiteral() error {
+
+	// Move past 'b"'.
+	s.advance(2)
 
-	r := []rune(src)
+	start := s.pos
+	for s.pos < len(s.src) && s.src[s.pos] != '"' {
 
-	return unicode.IsDigit(r[0])
+		// Keep escaped characters, so that '\"' doesn't end the literal.
+		if s.src[s.pos] == '\\' {
+			s.advance(1)
+		}
+
+		s.advance(1)
+	}
+
+	raw := s.src[start:s.pos]
+
+	if s.pos >= len(s.src) {
+		return lexError(s.auditBuilder.String()+"b\""+raw, s.line, s.col, "b\""+raw, "unterminated bytes literal")
+	}
+
+	// Move past '"'.
+	s.advance(1)
+
+	s.tokens = append(s.tokens, token(Bytes, raw, s.line, s.col))
+	s.auditBuilder.WriteString(raw)
+	s.col += utf8.RuneCountInString(raw) + 3 // Length of the literal + 3 for the prefix and quotes.
+
+	return nil
 }
 
-// Determined that this is a bool value, so getting its value.
-func truthValue(src string) (bool, error) {
+// Builds an identifier, keyword or boolean token.
+func (s *scanner) identifier() {
 
-	if src == "true" {
-		return true, nil
-	} else if src == "false" {
-		return false, nil
+	iden := s.advanceWhile(isIdentifierPart)
+
+	// Check for reserved keyword.
+	if t, ok := Keywords[iden]; ok {
+		s.emit(t, iden)
+		return
 	}
 
-	// Dont need an error message here, as not being a boolean value means
-	// this is actually an identifier
-	return false, fmt.Errorf("")
+	// If not, check to see is this is a bool value.
+	if iden == "true" || iden == "false" {
+		s.emit(Boolean, iden)
+		return
+	}
+
+	// Really is an identifier.
+	s.emit(Identifier, iden)
+}
+
+// Operators made up of more than one character, longest first so that '**=' isn't
+// read as '**' followed by '='.
+var compoundOperators = []string{
+	"**=", "<<=", ">>=", "~/=",
+	"**", "<<", ">>", "~/", "<=", ">=",
+	"*=", "/=", "%=", "&=", "|=", "^=",
+}
+
+// Returns the operator at the start of the source, which may span several characters.
+func operator(src string) string {
+
+	for _, op := range compoundOperators {
+		if strings.HasPrefix(src, op) {
+			return op
+		}
+	}
+
+	return src[:1]
+}
+
+// Only ascii digits make up numbers.
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
 }
 
-// Checks to see if this is a skippable token.
-func isSkippable(src string) bool {
-	return src == " " || src == "\n" || src == "\t" || src == "\r"
+// Identifiers start with a letter or '_'.
+func isIdentifierStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+// After the first character, identifiers can hold letters, digits and '_'.
+func isIdentifierPart(r rune) bool {
+	return isIdentifierStart(r) || unicode.IsDigit(r)
+}
+
+// An error found while lexing, pointing at the offending part of the source.
+type LexError struct {
+	Line    int
+	Col     int
+	Text    string // The offending text.
+	Source  string // The line it was found on, up to and including the offending text.
+	Message string
+}
+
+// Formats the error the same way the parser does, underlining where it went wrong.
+func (e LexError) Error() string {
+	return utils.GenerateParserError(e.Source, "", e.Line, e.Col, e.Message)
+}
+
+func lexError(source string, line int, col int, text string, message string) LexError {
+
+	return LexError{
+		Line:    line,
+		Col:     col,
+		Text:    text,
+		Source:  source,
+		Message: message,
+	}
 }
 
 // Builds and returns a new token.
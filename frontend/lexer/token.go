@@ -32,6 +32,7 @@ const (
 	Identifier TokenType = "Identifier"
 	Boolean    TokenType = "Boolean"
 	String     TokenType = "String"
+	Bytes      TokenType = "Bytes" // e.g. b"\x00data"
 
 	// Symbols.
 	Equals       TokenType = "="
@@ -44,6 +45,7 @@ const (
 	OpenBracket  TokenType = "["
 	CloseBracket TokenType = "]"
 	Period       TokenType = "."
+	Ellipsis     TokenType = "..."
 	Equality     TokenType = "=="
 	NotEquality  TokenType = "!="
 	Ternary      TokenType = "?"
@@ -54,14 +56,20 @@ const (
 	ShorthandOperator   TokenType = "ShorthandOperator"   // e.g. '++, --'
 
 	// Keywords.
-	Let   TokenType = "Let"   // declaring new variables
-	Const TokenType = "Const" // declaring new constants
-	Fn    TokenType = "Fn"    // declaring new functions
-	If    TokenType = "If"    // standard if condition
-	Else  TokenType = "Else"  // standard else condition
-	While TokenType = "While" // standard while loop
-	For   TokenType = "For"   // standard for loop
-	Using TokenType = "Using"
+	Let    TokenType = "Let"   // declaring new variables
+	Const  TokenType = "Const" // declaring new constants
+	Fn     TokenType = "Fn"    // declaring new functions
+	If     TokenType = "If"    // standard if condition
+	Else   TokenType = "Else"  // standard else condition
+	While  TokenType = "While" // standard while loop
+	For    TokenType = "For"   // standard for loop
+	In     TokenType = "In"    // for ... in loop
+	Using  TokenType = "Using"
+	Defer  TokenType = "Defer"  // deferring a call until the function returns
+	Return TokenType = "Return" // returning from a function
+	Yield  TokenType = "Yield"  // producing a value from a generator
+	Spawn  TokenType = "Spawn"  // running a call as a concurrent task
+	Export TokenType = "Export" // sharing a decleration with modules that use this one
 
 	// End of Line.
 	EOL TokenType = ";"
@@ -71,12 +79,18 @@ const (
 
 // Map of languages keywords.
 var Keywords = map[string]TokenType{
-	"let":   Let,
-	"const": Const,
-	"fn":    Fn,
-	"if":    If,
-	"else":  Else,
-	"while": While,
-	"for":   For,
-	"using": Using,
+	"let":    Let,
+	"const":  Const,
+	"fn":     Fn,
+	"if":     If,
+	"else":   Else,
+	"while":  While,
+	"for":    For,
+	"in":     In,
+	"using":  Using,
+	"defer":  Defer,
+	"return": Return,
+	"yield":  Yield,
+	"spawn":  Spawn,
+	"export": Export,
 }
@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"strings"
+
+	"goblin.org/main/frontend/lexer"
+	"goblin.org/main/utils"
+)
+
+// How serious a diagnostic is.
+type Severity string
+
+const SeverityError Severity = "error"
+
+// A problem found in the source, pointing at where it was found.
+type Diagnostic struct {
+	Severity Severity
+	Line     int
+	Col      int
+	Source   string // The line it was found on, as the lexer saw it.
+	Message  string
+}
+
+// Formats the diagnostic with the offending line underlined, i.e.
+//
+//	let x = 10
+//	~~~~~~~~~~^
+//	expecting token `;` on line 1 col 10
+func (d Diagnostic) Error() string {
+	return utils.GenerateParserError(d.Source, "", d.Line, d.Col, d.Message)
+}
+
+// Every problem found parsing a program, in the order they were found.
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) Error() string {
+
+	messages := make([]string, 0, len(d))
+	for _, diagnostic := range d {
+		messages = append(messages, diagnostic.Error())
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Tokens that can only start a statement, so a parse that went wrong can pick up again
+// from them.
+var statementStarts = map[lexer.TokenType]bool{
+	lexer.Let:    true,
+	lexer.Const:  true,
+	lexer.Fn:     true,
+	lexer.If:     true,
+	lexer.While:  true,
+	lexer.For:    true,
+	lexer.Using:  true,
+	lexer.Defer:  true,
+	lexer.Return: true,
+	lexer.Yield:  true,
+	lexer.Spawn:  true,
+	lexer.Export: true,
+}
+
+// Records err as a diagnostic. Errors that don't already know where they were found are
+// placed just after the last token eaten.
+func (p *Parser) report(err error) {
+
+	if d, isDiagnostic := err.(Diagnostic); isDiagnostic {
+		p.diagnostics = append(p.diagnostics, d)
+		return
+	}
+
+	p.diagnostics = append(p.diagnostics, p.diagnostic(err.Error()))
+}
+
+// Skips the rest of a statement that failed to parse, the one starting at token start.
+// Stops after its ';', or before the '}' closing the block it is in or the keyword starting
+// the next statement. Braces, brackets and parens the statement opened before it failed are
+// skipped up to their closer first, so an error inside a literal doesn't stop at the literal's
+// own '}'. A paren or bracket left open may never be closed though, so while inside one it
+// also stops before a keyword starting a statement on a line of its own, or after a ';' unless
+// the paren holds the header of a for loop. Always moves on at least one token, so the same
+// tokens are never reported twice.
+func (p *Parser) synchronise(start int) {
+
+	open := unclosed(p.tokens[start:min(p.tokenPointer, len(p.tokens))])
+
+	for moved := p.tokenPointer > start; p.notEof(); moved = true {
+
+		tk := p.at().Type
+
+		if len(open) == 0 {
+
+			if tk == lexer.EOL {
+				p.eat()
+				return
+			}
+
+			if moved && (tk == lexer.CloseBrace || statementStarts[tk]) {
+				return
+			}
+		} else if bracket, header := inside(open); bracket || header {
+
+			if tk == lexer.EOL && bracket {
+				p.eat()
+				return
+			}
+
+			if moved && statementStarts[tk] && p.startsLine() {
+				return
+			}
+		}
+
+		previous := lexer.Token{}
+		if p.tokenPointer > 0 {
+			previous = p.tokens[p.tokenPointer-1]
+		}
+
+		open = nest(open, previous, p.at())
+		p.eat()
+	}
+}
+
+// Returns the braces, brackets and parens left open by the tokens, innermost last. The paren
+// holding the header of a for loop is recorded as lexer.For.
+func unclosed(tokens []lexer.Token) []lexer.TokenType {
+
+	open := make([]lexer.TokenType, 0)
+
+	for i, tk := range tokens {
+
+		previous := lexer.Token{}
+		if i > 0 {
+			previous = tokens[i-1]
+		}
+
+		open = nest(open, previous, tk)
+	}
+
+	return open
+}
+
+// Opens or closes a brace, bracket or paren for the token, ignoring closers that have
+// nothing to close.
+func nest(open []lexer.TokenType, previous lexer.Token, tk lexer.Token) []lexer.TokenType {
+
+	switch tk.Type {
+	case lexer.OpenParen:
+		if previous.Type == lexer.For {
+			return append(open, lexer.For)
+		}
+		return append(open, tk.Type)
+	case lexer.OpenBrace, lexer.OpenBracket:
+		return append(open, tk.Type)
+	case lexer.CloseBrace, lexer.CloseParen, lexer.CloseBracket:
+		if len(open) > 0 {
+			return open[:len(open)-1]
+		}
+	}
+
+	return open
+}
+
+// Reports whether a paren or bracket is among those open, and whether the header of a for
+// loop is. Unlike a brace, neither holds statements, though a for header does hold ';'s.
+func inside(open []lexer.TokenType) (bracket bool, header bool) {
+
+	for _, tk := range open {
+		switch tk {
+		case lexer.OpenParen, lexer.OpenBracket:
+			bracket = true
+		case lexer.For:
+			header = true
+		}
+	}
+
+	return bracket, header
+}
+
+// Returns true if the current token is the first on its line.
+func (p *Parser) startsLine() bool {
+	return p.tokenPointer == 0 || p.tokens[p.tokenPointer-1].Line != p.at().Line
+}
@@ -2,6 +2,9 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
+	"strconv"
+	"unicode/utf8"
 
 	"goblin.org/main/frontend/ast"
 	"goblin.org/main/frontend/lexer"
@@ -13,37 +16,90 @@ Orders of prescidence
 
 // Assignment
 // Object
-// AdditiveExpr
-// MultiplicitaveExpr
+// BinaryExpr (see binaryPrecedence)
+// UnaryExpr
+// ExponentExpr
 // Call
 // Member
 // PrimaryExpr
 */
 
-var tokens []lexer.Token
-var tokenPointer int
-var audit map[int]string
+// Binary operators, from the loosest to the tightest binding. Operators on the same
+// level are left associative.
+var binaryPrecedence = [][]string{
+	{"==", "!=", "<", ">", "<=", ">="},
+	{"|"},
+	{"^"},
+	{"&"},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "~/", "%"},
+}
+
+// Turns the tokens of a single program into an AST. All the state of a parse lives here,
+// so any number of programs can be parsed at once, including from within another parse.
+type Parser struct {
+	tokens       []lexer.Token
+	tokenPointer int
+	audit        map[int]string
+
+	// How many function bodies deep the parser is, and whether the innermost one has yielded.
+	fnDepth int
+	yielded bool
+
+	// Every syntax error found so far, the parse carries on past each of them.
+	diagnostics Diagnostics
+}
+
+// Returns a parser for the given tokens, along with the audit trail of the source lines
+// they came from, used to point at errors.
+func NewParser(t []lexer.Token, a map[int]string) *Parser {
+
+	// Take a copy, the parser rewrites some tokens as it goes.
+	tokens := make([]lexer.Token, 0, len(t))
+	tokens = append(tokens, t...)
+
+	return &Parser{
+		tokens: tokens,
+		audit:  a,
+	}
+}
+
+// Simple returns the current token, the EOF token once there are none left.
+func (p *Parser) at() lexer.Token {
+
+	if p.tokenPointer >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+
+	return p.tokens[p.tokenPointer]
+}
 
-// Simple returns the current token.
-func at() lexer.Token {
-	return tokens[tokenPointer]
+// Returns the token after the current one, without moving the pointer.
+func (p *Parser) peek() lexer.Token {
+
+	if p.tokenPointer+1 >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+
+	return p.tokens[p.tokenPointer+1]
 }
 
 // Returns the current token and shifts the pointer along to
 // the next in the list.
-func eat() lexer.Token {
+func (p *Parser) eat() lexer.Token {
 
-	prev := at()
-	tokenPointer++
+	prev := p.at()
+	p.tokenPointer++
 	return prev
 }
 
 // Returns the current token and shifts the pointer along to
 // the next in the list. Used in error handling to check the expected
 // type of the token about to be returned.
-func expect(t lexer.TokenType) (lexer.Token, error) {
+func (p *Parser) expect(t lexer.TokenType) (lexer.Token, error) {
 
-	prev := at()
+	prev := p.at()
 
 	if &prev == nil || prev.Type != t {
 
@@ -51,56 +107,140 @@ func expect(t lexer.TokenType) (lexer.Token, error) {
 		return lexer.Token{}, fmt.Errorf("%v", message)
 	}
 
-	return eat(), nil
+	return p.eat(), nil
 }
 
-// Generates a formatted error message, complete with underline and error-point identification.
-func ErrorGenerator(message string) error {
+// Returns the span from the start of the given token to the end of the last token eaten.
+func (p *Parser) span(start lexer.Token) ast.Span {
+	return p.spanFrom(ast.Position{Line: start.Line, Col: start.Col})
+}
+
+// Returns the span from start to the end of the last token eaten.
+func (p *Parser) spanFrom(start ast.Position) ast.Span {
 
-	tmp := tokens[tokenPointer-1]
+	end := start
+	if p.tokenPointer > 0 {
 
-	m := utils.GenerateParserError(audit[tmp.Line], tmp.Value, tmp.Line, tmp.Col, message)
+		last := p.tokens[min(p.tokenPointer, len(p.tokens))-1]
+		end = ast.Position{Line: last.Line, Col: last.Col + tokenWidth(last)}
+	}
 
-	return fmt.Errorf("%v", m)
+	return ast.Span{Start: start, End: end}
 }
 
-func ProduceAST(t []lexer.Token, a map[int]string) (ast.Program, error) {
+// How many columns a token takes up in the source.
+func tokenWidth(t lexer.Token) int {
 
-	// Setup & store the list of tokans.
-	tokens = make([]lexer.Token, 0)
-	tokens = append(tokens, t...)
+	width := utf8.RuneCountInString(t.Value)
+
+	// The value of a literal doesn't include its quotes.
+	switch t.Type {
+	case lexer.String:
+		width += 2
+	case lexer.Bytes:
+		width += 3
+	}
+
+	return width
+}
+
+// Generates a formatted error message, complete with underline and error-point identification.
+func (p *Parser) ErrorGenerator(message string) error {
+	return p.diagnostic(message)
+}
+
+// Returns a diagnostic pointing just after the last token eaten.
+func (p *Parser) diagnostic(message string) Diagnostic {
+
+	tmp := p.tokens[0]
+	if p.tokenPointer > 0 {
+		tmp = p.tokens[min(p.tokenPointer, len(p.tokens))-1]
+	}
 
-	// Capture the audit trail of tokens.
-	audit = a
+	return Diagnostic{
+		Severity: SeverityError,
+		Line:     tmp.Line,
+		Col:      tmp.Col + utf8.RuneCountInString(tmp.Value),
+		Source:   p.audit[tmp.Line],
+		Message:  message,
+	}
+}
+
+// Parses a whole program with a parser of its own.
+func ProduceAST(t []lexer.Token, a map[int]string) (ast.Program, error) {
+	return NewParser(t, a).ProduceAST()
+}
 
-	// Set token stack pointer to zero.
-	tokenPointer = 0
+// Parses the tokens into a program. A parser can only be used once. Statements that fail to
+// parse are skipped, so every syntax error in the program is returned together as
+// Diagnostics.
+func (p *Parser) ProduceAST() (ast.Program, error) {
 
 	program := ast.Program{
 		Kind: "Program",
 		Body: []ast.Expression{},
 	}
 
-	for notEof() {
+	for p.notEof() {
+
+		start := p.tokenPointer
+
+		var parsed_statement ast.Expression
+		var err error
+
+		// Only top level declerations can be exported.
+		if p.at().Type == lexer.Export {
+			parsed_statement, err = p.parse_export_statement()
+		} else {
+			parsed_statement, err = p.parse_statement()
+		}
 
-		parsed_statement, err := parse_statement()
 		if err != nil {
-			return ast.Program{}, ErrorGenerator(err.Error())
+			p.report(err)
+			p.synchronise(start)
+			continue
 		}
 
 		program.Body = append(program.Body, parsed_statement)
 	}
 
+	if len(p.diagnostics) > 0 {
+		return ast.Program{}, p.diagnostics
+	}
+
 	return program, nil
 }
 
+// Parses the statements of a block, up to its closing brace. Statements that fail to parse
+// are reported and skipped, so the rest of the block is still checked.
+func (p *Parser) parse_block() []ast.Expression {
+
+	body := make([]ast.Expression, 0)
+
+	for p.at().Type != lexer.CloseBrace && p.at().Type != lexer.EOF {
+
+		start := p.tokenPointer
+
+		stmt, err := p.parse_statement()
+		if err != nil {
+			p.report(err)
+			p.synchronise(start)
+			continue
+		}
+
+		body = append(body, stmt)
+	}
+
+	return body
+}
+
 // Defines how the interpreter handles statements.
-func parse_statement() (ast.Expression, error) {
+func (p *Parser) parse_statement() (ast.Expression, error) {
 
-	switch at().Type {
+	switch p.at().Type {
 	case lexer.Let, lexer.Const:
 
-		pvd, err := parse_var_decleration()
+		pvd, err := p.parse_var_decleration()
 		if err != nil {
 			return ast.Expr{}, err
 		}
@@ -108,7 +248,7 @@ func parse_statement() (ast.Expression, error) {
 		return pvd, nil
 	case lexer.Boolean:
 		// Boolean literal coming in.
-		b, err := parse_primary_expression()
+		b, err := p.parse_primary_expression()
 		if err != nil {
 			return ast.Expr{}, err
 		}
@@ -117,7 +257,7 @@ func parse_statement() (ast.Expression, error) {
 
 	case lexer.Fn:
 
-		fn, err := parse_fn_decleration()
+		fn, err := p.parse_fn_decleration()
 
 		if err != nil {
 			return ast.Expr{}, err
@@ -126,7 +266,7 @@ func parse_statement() (ast.Expression, error) {
 		return fn, nil
 	case lexer.If:
 
-		iif, err := parse_if_condition()
+		iif, err := p.parse_if_condition()
 
 		if err != nil {
 			return ast.Expr{}, err
@@ -135,7 +275,7 @@ func parse_statement() (ast.Expression, error) {
 		return iif, nil
 	case lexer.While:
 
-		while, err := parse_while_loop()
+		while, err := p.parse_while_loop()
 		if err != nil {
 			return ast.Expr{}, err
 		}
@@ -143,7 +283,7 @@ func parse_statement() (ast.Expression, error) {
 		return while, nil
 	case lexer.For:
 
-		while, err := parse_for_loop()
+		while, err := p.parse_for_loop()
 		if err != nil {
 			return ast.Expr{}, err
 		}
@@ -151,25 +291,73 @@ func parse_statement() (ast.Expression, error) {
 		return while, nil
 	case lexer.Using:
 
-		using, err := parse_using_decleration()
+		using, err := p.parse_using_decleration()
 		if err != nil {
 			return ast.Expr{}, err
 		}
 
 		return using, nil
+	case lexer.Defer:
+
+		deferred, err := p.parse_defer_statement()
+		if err != nil {
+			return ast.Expr{}, err
+		}
+
+		return deferred, nil
+	case lexer.Return:
+
+		ret, err := p.parse_return_statement()
+		if err != nil {
+			return ast.Expr{}, err
+		}
+
+		return ret, nil
+	case lexer.Spawn:
+
+		spawn, err := p.parse_spawn_statement()
+		if err != nil {
+			return ast.Expr{}, err
+		}
+
+		return spawn, nil
+	case lexer.Yield:
+
+		yield, err := p.parse_yield_statement()
+		if err != nil {
+			return ast.Expr{}, err
+		}
+
+		return yield, nil
+	case lexer.Export:
+
+		p.eat()
+
+		return ast.Expr{}, fmt.Errorf("export can only be used at the top level of a module")
 	default:
-		expr, err := parse_expression()
+		expr, err := p.parse_expression()
 		if err != nil {
 			return ast.Expr{}, err
 		}
+
+		// Function calls used as statements, i.e. 'io.println(x);'
+		_, isCallExpr := expr.(ast.CallExpr)
+		if isCallExpr {
+
+			_, err = p.expect(lexer.EOL)
+			if err != nil {
+				return ast.Expr{}, err
+			}
+		}
+
 		return expr, nil
 	}
 }
 
 // Defines how the interpreter handles experssions.
-func parse_expression() (ast.Expression, error) {
+func (p *Parser) parse_expression() (ast.Expression, error) {
 
-	assign, err := parse_assignment_expression()
+	assign, err := p.parse_assignment_expression()
 	if err != nil {
 		return ast.Expr{}, err
 	}
@@ -183,19 +371,19 @@ func parse_expression() (ast.Expression, error) {
 // if (...) { ... } else { ... }							// if/else					DONE.
 // if (...) { ... } elseif (...) { ... } else { ... }		// if/elseif/else
 // let x = (...) ? { ... } : { ... }						// ternary operator			DONE.
-func parse_if_condition() (ast.Expression, error) {
+func (p *Parser) parse_if_condition() (ast.Expression, error) {
 
 	// Eat 'if' keyword
-	eat()
+	keyword := p.eat()
 
 	// Start of if condition, expect to see the open paren.
-	_, err := expect(lexer.OpenParen)
+	_, err := p.expect(lexer.OpenParen)
 	if err != nil {
 		return nil, err
 	}
 
 	// Capture expression inside the parens.
-	expr, err := parse_statement()
+	expr, err := p.parse_statement()
 	if err != nil {
 		return nil, err
 	}
@@ -219,32 +407,21 @@ func parse_if_condition() (ast.Expression, error) {
 	}
 
 	// End of if condition, expect to see the close paren.
-	_, err = expect(lexer.CloseParen)
+	_, err = p.expect(lexer.CloseParen)
 	if err != nil {
 		return nil, err
 	}
 
-	body := make([]ast.Expression, 0)
-
 	// Start of conditional body, expect to see the open brace.
-	_, err = expect(lexer.OpenBrace)
+	_, err = p.expect(lexer.OpenBrace)
 	if err != nil {
 		return nil, err
 	}
 
-	// Until we hit the end of the if body.
-	for at().Type != lexer.CloseBrace && at().Type != lexer.EOF {
-
-		stmt, err := parse_statement()
-		if err != nil {
-			return nil, err
-		}
-
-		body = append(body, stmt)
-	}
+	body := p.parse_block()
 
 	// End of conditional body, expect to see the closing brace.
-	_, err = expect(lexer.CloseBrace)
+	_, err = p.expect(lexer.CloseBrace)
 	if err != nil {
 		return nil, err
 	}
@@ -253,6 +430,7 @@ func parse_if_condition() (ast.Expression, error) {
 	// is a if/else.
 	var iif = ast.IfCondition{
 		Kind:      "IfNode",
+		Span:      p.span(keyword),
 		Condition: condition,
 		Body:      body,
 		ElseCatch: false,
@@ -260,38 +438,28 @@ func parse_if_condition() (ast.Expression, error) {
 	}
 
 	// Checking for an 'else' at the end of the 'if'.
-	if at().Type == lexer.Else {
+	if p.at().Type == lexer.Else {
 
 		// Eat past the 'else' keyword.
-		eat()
+		p.eat()
 
 		// Start of conditional body, expect to see the open brace.
-		_, err = expect(lexer.OpenBrace)
+		_, err = p.expect(lexer.OpenBrace)
 		if err != nil {
 			return nil, err
 		}
 
-		elseBody := make([]ast.Expression, 0)
-
-		// Until we hit the end of the if body.
-		for at().Type != lexer.CloseBrace && at().Type != lexer.EOF {
-
-			stmt, err := parse_statement()
-			if err != nil {
-				return nil, err
-			}
-
-			elseBody = append(elseBody, stmt)
-		}
+		elseBody := p.parse_block()
 
 		// End of conditional body, expect to see the closing brace.
-		_, err = expect(lexer.CloseBrace)
+		_, err = p.expect(lexer.CloseBrace)
 		if err != nil {
 			return nil, err
 		}
 
 		iif = ast.IfCondition{
 			Kind:      "IfNode",
+			Span:      p.span(keyword),
 			Condition: condition,
 			Body:      body,
 			ElseCatch: true,
@@ -303,18 +471,18 @@ func parse_if_condition() (ast.Expression, error) {
 }
 
 // Parses a standard while loop, i.e. while( ... ){ ... }
-func parse_while_loop() (ast.Expression, error) {
+func (p *Parser) parse_while_loop() (ast.Expression, error) {
 
-	eat() // Eat past the 'while' keyword.
+	keyword := p.eat() // Eat past the 'while' keyword.
 
 	// Start of while loop, expect to see the open paren.
-	_, err := expect(lexer.OpenParen)
+	_, err := p.expect(lexer.OpenParen)
 	if err != nil {
 		return nil, err
 	}
 
 	// Capture expression inside the parens.
-	expr, err := parse_statement()
+	expr, err := p.parse_statement()
 	if err != nil {
 		return nil, err
 	}
@@ -338,56 +506,51 @@ func parse_while_loop() (ast.Expression, error) {
 	}
 
 	// End of if condition, expect to see the close paren.
-	_, err = expect(lexer.CloseParen)
+	_, err = p.expect(lexer.CloseParen)
 	if err != nil {
 		return nil, err
 	}
 
-	body := make([]ast.Expression, 0)
-
 	// Start of conditional body, expect to see the open brace.
-	_, err = expect(lexer.OpenBrace)
+	_, err = p.expect(lexer.OpenBrace)
 	if err != nil {
 		return nil, err
 	}
 
-	// Until we hit the end of the if body.
-	for at().Type != lexer.CloseBrace && at().Type != lexer.EOF {
-
-		stmt, err := parse_statement()
-		if err != nil {
-			return nil, err
-		}
-
-		body = append(body, stmt)
-	}
+	body := p.parse_block()
 
 	// End of conditional body, expect to see the closing brace.
-	_, err = expect(lexer.CloseBrace)
+	_, err = p.expect(lexer.CloseBrace)
 	if err != nil {
 		return nil, err
 	}
 
 	return ast.WhileLoop{
 		Kind:      ast.WhileNode,
+		Span:      p.span(keyword),
 		Condition: condition,
 		Body:      body,
 	}, nil
 }
 
 // Parses a standard for loop, i.e. for( ... ) { ... }
-func parse_for_loop() (ast.Expression, error) {
+func (p *Parser) parse_for_loop() (ast.Expression, error) {
 
-	eat() // Eat past the 'for' keyword.
+	keyword := p.eat() // Eat past the 'for' keyword.
 
 	// Start of loop head, should be an open paren there.
-	_, err := expect(lexer.OpenParen)
+	_, err := p.expect(lexer.OpenParen)
 	if err != nil {
 		return nil, err
 	}
 
+	// No 'let', so this must be a 'for (x in arr)' loop.
+	if p.at().Type != lexer.Let && p.at().Type != lexer.Const {
+		return p.parse_for_in_loop(keyword)
+	}
+
 	// Next we should see an assignment expression, i.e. 'let i = 0;'
-	ass, err := parse_var_decleration()
+	ass, err := p.parse_var_decleration()
 	if err != nil {
 		return nil, err
 	}
@@ -398,7 +561,7 @@ func parse_for_loop() (ast.Expression, error) {
 	}
 
 	// Next we expect to see our binary expression, as this is how we determine if the loop should keep running.
-	expr, err := parse_statement()
+	expr, err := p.parse_statement()
 	if err != nil {
 		return nil, err
 	}
@@ -410,13 +573,13 @@ func parse_for_loop() (ast.Expression, error) {
 	}
 
 	// Next should be another ';'.
-	_, err = expect(lexer.EOL)
+	_, err = p.expect(lexer.EOL)
 	if err != nil {
 		return nil, err
 	}
 
 	// Finally, we expect to see a shorthand operator expression.
-	she, err := parse_identifier()
+	she, err := p.parse_identifier()
 	if err != nil {
 		return nil, err
 	}
@@ -427,94 +590,236 @@ func parse_for_loop() (ast.Expression, error) {
 	}
 
 	// End of loop header, should see ')'.
-	_, err = expect(lexer.CloseParen)
+	_, err = p.expect(lexer.CloseParen)
 	if err != nil {
 		return nil, err
 	}
 
 	// Start of loop body, expect to see '{'.
-	_, err = expect(lexer.OpenBrace)
+	_, err = p.expect(lexer.OpenBrace)
 	if err != nil {
 		return nil, err
 	}
 
-	body := make([]ast.Expression, 0)
+	body := p.parse_block()
+
+	// Start of loop body, expect to see '{'.
+	_, err = p.expect(lexer.CloseBrace)
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.ForLoop{
+		Kind:       "ForNode",
+		Span:       p.span(keyword),
+		Assignment: varDec,
+		Condition:  binop,
+		Iterator:   shorthandOp,
+		Body:       body,
+	}, nil
+}
+
+// Parses the rest of a for ... in loop, i.e. for (x in arr) { ... } or for ([k, v] in pairs) { ... }
+func (p *Parser) parse_for_in_loop(keyword lexer.Token) (ast.Expression, error) {
+
+	// The loop variable, or a pattern to destructure each item into.
+	var binding ast.PatternElement
 
-	// Until we hit the end of the if body.
-	for at().Type != lexer.CloseBrace && at().Type != lexer.EOF {
+	if p.at().Type == lexer.OpenBracket || p.at().Type == lexer.OpenBrace {
 
-		stmt, err := parse_statement()
+		pattern, err := p.parse_pattern()
 		if err != nil {
 			return nil, err
 		}
 
-		body = append(body, stmt)
+		binding.Pattern = &pattern
+	} else {
+
+		name, err := p.expect(lexer.Identifier)
+		if err != nil {
+			return nil, err
+		}
+
+		binding.Name = name.Value
+	}
+
+	_, err := p.expect(lexer.In)
+	if err != nil {
+		return nil, err
+	}
+
+	iterable, err := p.parse_expression()
+	if err != nil {
+		return nil, err
+	}
+
+	// End of loop header, should see ')'.
+	_, err = p.expect(lexer.CloseParen)
+	if err != nil {
+		return nil, err
 	}
 
 	// Start of loop body, expect to see '{'.
-	_, err = expect(lexer.CloseBrace)
+	_, err = p.expect(lexer.OpenBrace)
 	if err != nil {
 		return nil, err
 	}
 
-	return ast.ForLoop{
-		Kind:       "ForNode",
-		Assignment: varDec,
-		Condition:  binop,
-		Iterator:   shorthandOp,
-		Body:       body,
+	body := p.parse_block()
+
+	// End of loop body, expect to see '}'.
+	_, err = p.expect(lexer.CloseBrace)
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.ForInLoop{
+		Kind:     ast.ForInNode,
+		Span:     p.span(keyword),
+		Binding:  binding,
+		Iterable: iterable,
+		Body:     body,
 	}, nil
 }
 
-func parse_assignment_expression() (ast.Expression, error) {
+// Parses a destructuring pattern, either '[a, b = 0, ...rest]' or '{host, port}'.
+func (p *Parser) parse_pattern() (ast.Pattern, error) {
+
+	open := p.eat()
+
+	kind := ast.ArrayPatternNode
+	closing := lexer.CloseBracket
+	if open.Type == lexer.OpenBrace {
+		kind = ast.MapPatternNode
+		closing = lexer.CloseBrace
+	}
+
+	pattern := ast.Pattern{
+		Kind:     kind,
+		Elements: []ast.PatternElement{},
+	}
+
+	for p.at().Type != closing && p.at().Type != lexer.EOF {
+
+		element := ast.PatternElement{}
+
+		if p.at().Type == lexer.Ellipsis {
+			p.eat() // Move past the '...'.
+			element.Rest = true
+		}
+
+		// Array patterns can nest, i.e. let [[a, b], c] = arr;
+		if kind == ast.ArrayPatternNode && !element.Rest && (p.at().Type == lexer.OpenBracket || p.at().Type == lexer.OpenBrace) {
+
+			nested, err := p.parse_pattern()
+			if err != nil {
+				return ast.Pattern{}, err
+			}
+
+			element.Pattern = &nested
+		} else {
+
+			name, err := p.expect(lexer.Identifier)
+			if err != nil {
+				return ast.Pattern{}, err
+			}
+
+			element.Name = name.Value
+		}
+
+		if p.at().Type == lexer.Equals {
+
+			if element.Rest {
+				return ast.Pattern{}, fmt.Errorf("rest element '%v' cannot have a default value", element.Name)
+			}
+
+			p.eat() // Move past the '='.
+
+			def, err := p.parse_expression()
+			if err != nil {
+				return ast.Pattern{}, err
+			}
+
+			element.Default = def
+		}
+
+		pattern.Elements = append(pattern.Elements, element)
+
+		// The rest element collects everything left over, so it has to come last.
+		if element.Rest && p.at().Type != closing {
+			return ast.Pattern{}, fmt.Errorf("rest element '%v' must be the last element", element.Name)
+		}
+
+		if p.at().Type != closing {
+			_, err := p.expect(lexer.Comma)
+			if err != nil {
+				return ast.Pattern{}, err
+			}
+		}
+	}
+
+	_, err := p.expect(closing)
+	if err != nil {
+		return ast.Pattern{}, err
+	}
+
+	return pattern, nil
+}
+
+func (p *Parser) parse_assignment_expression() (ast.Expression, error) {
 
-	left, err := parse_object_expression() // To be switched out with objects
+	// Capture where the assignee starts, for error reporting.
+	start := p.at()
+
+	left, err := p.parse_object_expression() // To be switched out with objects
 	if err != nil {
 		return ast.Expr{}, err
 	}
 
-	if at().Type == lexer.Equals {
+	if p.at().Type == lexer.Equals {
 
-		eat() // Advance past Equals token.
+		p.eat() // Advance past Equals token.
 
-		value, err := parse_assignment_expression()
+		value, err := p.parse_assignment_expression()
 		if err != nil {
 			return ast.Expr{}, err
 		}
 
-		_, err = expect(lexer.EOL)
+		_, err = p.expect(lexer.EOL)
 		if err != nil {
 			return ast.Expr{}, err
 		}
 
 		return ast.AssignmentExpr{
 			Kind:    "AssignmentExprNode",
+			Span:    p.span(start),
 			Assigne: left,
 			Value:   value,
 		}, nil
-	} else if at().Type == lexer.Ternary {
+	} else if p.at().Type == lexer.Ternary {
 
-		eat() // Advance past ternary op.
+		p.eat() // Advance past ternary op.
 
 		// Now to capture the left expression.
-		trueExpr, err := parse_expression()
+		trueExpr, err := p.parse_expression()
 		if err != nil {
 			return nil, err
 		}
 
-		_, err = expect(lexer.Colon)
+		_, err = p.expect(lexer.Colon)
 		if err != nil {
 			return nil, err
 		}
 
 		// Capture rigth expression.
-		falseExpr, err := parse_expression()
+		falseExpr, err := p.parse_expression()
 		if err != nil {
 			return nil, err
 		}
 
 		return ast.TernaryCondition{
 			Kind:      ast.TernaryNode,
+			Span:      p.span(start),
 			Condition: left,
 			Left:      trueExpr,
 			Right:     falseExpr,
@@ -525,12 +830,12 @@ func parse_assignment_expression() (ast.Expression, error) {
 }
 
 // Parses a complex expression.
-func parse_object_expression() (ast.Expression, error) {
+func (p *Parser) parse_object_expression() (ast.Expression, error) {
 
 	// Non-map object.
-	if at().Type != lexer.OpenBrace {
+	if p.at().Type != lexer.OpenBrace {
 
-		add, err := parse_additive_expression()
+		add, err := p.parse_binary_expression(0)
 		if err != nil {
 			return ast.Expr{}, err
 		}
@@ -539,22 +844,22 @@ func parse_object_expression() (ast.Expression, error) {
 	}
 
 	// Advances past '{'
-	eat()
+	open := p.eat()
 
 	props := make([]ast.Property, 0)
 
 	// Continue reading unitl we get to the end of the object structure.
-	for notEof() && at().Type != lexer.CloseBrace {
+	for p.notEof() && p.at().Type != lexer.CloseBrace {
 
-		key, err := expect(lexer.Identifier)
+		key, err := p.expect(lexer.Identifier)
 		if err != nil {
 			return ast.Expr{}, err
 		}
 
 		// Allows short-hand definition, i.e.: { key, }
-		if at().Type == lexer.Comma {
+		if p.at().Type == lexer.Comma {
 
-			eat() // Skip past ','.
+			p.eat() // Skip past ','.
 
 			props = append(props, ast.Property{
 				Key:  key.Value,
@@ -562,7 +867,7 @@ func parse_object_expression() (ast.Expression, error) {
 			})
 
 			continue
-		} else if at().Type == lexer.CloseBrace {
+		} else if p.at().Type == lexer.CloseBrace {
 
 			// Allows short-hand definition, i.e.: { key }
 
@@ -574,98 +879,99 @@ func parse_object_expression() (ast.Expression, error) {
 			continue
 		}
 
-		_, err = expect(lexer.Colon)
+		_, err = p.expect(lexer.Colon)
 		if err != nil {
 			return ast.Expr{}, err
 		}
 
-		value, err := parse_expression()
+		value, err := p.parse_expression()
 		if err != nil {
 			return ast.Expr{}, err
 		}
 
 		props = append(props, ast.Property{Kind: "Property", Value: &value, Key: key.Value})
 
-		if at().Type != lexer.CloseBrace {
-			_, err = expect(lexer.Comma)
+		if p.at().Type != lexer.CloseBrace {
+			_, err = p.expect(lexer.Comma)
 			if err != nil {
 				return ast.Expr{}, err
 			}
 		}
 	}
 
-	_, err := expect(lexer.CloseBrace)
+	_, err := p.expect(lexer.CloseBrace)
 	if err != nil {
 		return ast.Expr{}, err
 	}
 
 	return ast.ObjectLiteral{
 		Kind:       "ObjectLiteral",
+		Span:       p.span(open),
 		Properties: props,
 	}, nil
 }
 
 // Parses incoming functions.
-func parse_fn_decleration() (ast.Expression, error) {
+func (p *Parser) parse_fn_decleration() (ast.Expression, error) {
 
 	// Eats fn keyword
-	eat()
+	keyword := p.eat()
+
+	// Optional return type, i.e. 'fn int AddOne(...)'.
+	var returnType *ast.TypeAnnotation
+	if p.isTypeAhead() {
+
+		t, err := p.parse_type_annotation()
+		if err != nil {
+			return nil, err
+		}
+
+		returnType = &t
+	}
 
 	// Get the identifier name of the function.
-	fnName, err := expect(lexer.Identifier)
+	fnName, err := p.expect(lexer.Identifier)
 	if err != nil {
 		return nil, err
 	}
 
-	// Args of the function.
-	args, err := parse_args()
+	// Params of the function.
+	params, err := p.parse_params()
 	if err != nil {
 		return nil, err
 	}
 
-	params := make([]string, 0)
-
-	for _, arg := range args {
-
-		i, isIden := arg.(ast.Identifier)
-		if !isIden {
-			return nil, fmt.Errorf("expected parameters to be of type string")
-		}
-
-		// Push symbol identifier into params list.
-		params = append(params, i.Symbol)
-	}
-
 	// Expect '{' at start of function body.
-	_, err = expect(lexer.OpenBrace)
+	_, err = p.expect(lexer.OpenBrace)
 	if err != nil {
 		return nil, err
 	}
 
-	body := make([]ast.Expression, 0)
-
-	// Until we hit the end of the funciton body.
-	for at().Type != lexer.CloseBrace && at().Type != lexer.EOF {
+	// Nested functions track their own yields.
+	outerYielded := p.yielded
+	p.yielded = false
+	p.fnDepth++
 
-		stmt, err := parse_statement()
-		if err != nil {
-			return nil, err
-		}
+	body := p.parse_block()
 
-		body = append(body, stmt)
-	}
+	generator := p.yielded
+	p.yielded = outerYielded
+	p.fnDepth--
 
 	// End of function, expect to see the closing brace.
-	_, err = expect(lexer.CloseBrace)
+	_, err = p.expect(lexer.CloseBrace)
 	if err != nil {
 		return nil, err
 	}
 
 	function := ast.FunctionDecleration{
-		Kind:   "FunctionDeclerationNode",
-		Name:   fnName.Value,
-		Params: params,
-		Body:   body,
+		Kind:       "FunctionDeclerationNode",
+		Span:       p.span(keyword),
+		Name:       fnName.Value,
+		Params:     params,
+		Body:       body,
+		ReturnType: returnType,
+		Generator:  generator,
 	}
 
 	return function, nil
@@ -677,64 +983,85 @@ Handles either:
   - let x;
   - const y = 9;
 */
-func parse_var_decleration() (ast.Expression, error) {
+func (p *Parser) parse_var_decleration() (ast.Expression, error) {
 
 	// true:  const x = 10;
 	// false: let x = 10;
-	isConst := eat().Type == lexer.Const
-	identifier, err := expect(lexer.Identifier)
+	keyword := p.eat()
+	isConst := keyword.Type == lexer.Const
+
+	// Destructuring, i.e. 'let [a, b] = arr;' or 'let {host, port} = cfg;'
+	if p.at().Type == lexer.OpenBracket || p.at().Type == lexer.OpenBrace {
+		return p.parse_destructuring_decleration(keyword, isConst)
+	}
+
+	// Optional type, i.e. 'let int x = 10;'.
+	var annotation *ast.TypeAnnotation
+	if p.isTypeAhead() {
+
+		t, err := p.parse_type_annotation()
+		if err != nil {
+			return ast.Expr{}, err
+		}
+
+		annotation = &t
+	}
+
+	identifier, err := p.expect(lexer.Identifier)
 	if err != nil {
 		return ast.Expr{}, err
 	}
 
-	if at().Type == lexer.EOL {
+	if p.at().Type == lexer.EOL {
 
 		// Consume the next token.
-		eat()
+		p.eat()
 
 		if isConst {
 			// Current token is an EOL however trying to define const. Error.
-			return ast.Expr{}, ErrorGenerator("no value provided for const decleration")
+			return ast.Expr{}, p.ErrorGenerator("no value provided for const decleration")
 		}
 
 		// E.g. 'let x;'
 		return ast.VariableDecleration{
 			Kind:       "VariableDeclerationNode",
+			Span:       p.span(keyword),
 			Constant:   isConst,
 			Identifier: identifier.Value,
 			Value:      ast.Expr{},
+			Type:       annotation,
 		}, nil
 	}
 
 	// Now we are checking 'let x = 10;'
-	_, err = expect(lexer.Equals)
+	_, err = p.expect(lexer.Equals)
 	if err != nil {
 		return ast.Expr{}, err
 	}
 
 	// In the case of 'let x = [];', an array is being declared.
-	if at().Type == lexer.OpenBracket {
+	if p.at().Type == lexer.OpenBracket {
 
 		// Eat the opening bracket.
-		eat()
+		p.eat()
 
 		// Attempt to capture all the expressions inside the array.
-		array_decleration, err := parse_array_decleration(identifier.Value, isConst)
+		array_decleration, err := p.parse_array_decleration(keyword, identifier.Value, isConst, annotation)
 		if err != nil {
 			return ast.Expr{}, err
 		}
 
 		return array_decleration, nil
 
-	} else if at().Type == lexer.OpenBrace {
+	} else if p.at().Type == lexer.OpenBrace {
 
 		// In the case of 'let x = {};', a map is being declared.
 
 		// Eat the opening brace.
-		eat()
+		p.eat()
 
 		// Attempt to capture all the expressions inside the array.
-		map_decleration, err := parse_map_decleration(identifier.Value, isConst)
+		map_decleration, err := p.parse_map_decleration(keyword, identifier.Value, isConst, annotation)
 		if err != nil {
 			return ast.Expr{}, err
 		}
@@ -744,96 +1071,217 @@ func parse_var_decleration() (ast.Expression, error) {
 
 	// Standard variable decleration, i.e. 'let x = 10;'
 
-	value, err := parse_expression()
+	value, err := p.parse_expression()
+	if err != nil {
+		return ast.Expr{}, err
+	}
+
+	_, err = p.expect(lexer.EOL)
 	if err != nil {
 		return ast.Expr{}, err
 	}
 
 	decleration := ast.VariableDecleration{
 		Kind:       "VariableDeclerationNode",
+		Span:       p.span(keyword),
 		Value:      value,
 		Identifier: identifier.Value,
 		Constant:   isConst,
+		Type:       annotation,
 	}
 
-	_, isCallExpr := decleration.Value.(ast.CallExpr)
-	if isCallExpr {
-		return decleration, nil
-	}
+	return decleration, nil
+}
+
+// Parses the rest of a destructuring decleration, i.e. '[a, b] = arr;'
+func (p *Parser) parse_destructuring_decleration(keyword lexer.Token, isConst bool) (ast.Expression, error) {
 
-	_, err = expect(lexer.EOL)
+	pattern, err := p.parse_pattern()
 	if err != nil {
 		return ast.Expr{}, err
 	}
 
-	return decleration, nil
-}
+	_, err = p.expect(lexer.Equals)
+	if err != nil {
+		return ast.Expr{}, err
+	}
 
-// Parses a statement that declares a new map.
-func parse_map_decleration(identifier string, isConst bool) (ast.Expression, error) {
+	value, err := p.parse_expression()
+	if err != nil {
+		return ast.Expr{}, err
+	}
 
-	keyValuePairs := make(map[ast.Expression]ast.Expression, 0)
+	_, err = p.expect(lexer.EOL)
+	if err != nil {
+		return ast.Expr{}, err
+	}
 
-	for at().Type != lexer.CloseBrace && at().Type != lexer.EOF {
+	return ast.DestructuringDecleration{
+		Kind:     ast.DestructuringNode,
+		Span:     p.span(keyword),
+		Pattern:  pattern,
+		Value:    value,
+		Constant: isConst,
+	}, nil
+}
+
+// Parses a statement that declares a new map.
+func (p *Parser) parse_map_decleration(keyword lexer.Token, identifier string, isConst bool, annotation *ast.TypeAnnotation) (ast.Expression, error) {
+
+	keyValuePairs := make([]ast.MapEntry, 0)
+	seen := make(map[string]bool, 0)
+
+	for p.at().Type != lexer.CloseBrace && p.at().Type != lexer.EOF {
 
 		// Capture the key defined.
-		key, err := parse_expression()
+		key, err := p.parse_expression()
 		if err != nil {
 			return ast.Expr{}, err
 		}
 
 		// Key must be of type IComparable
 		if !isComparableType(key) {
-			return nil, fmt.Errorf("invalid type provided for map key: %v", key)
+			return nil, fmt.Errorf("invalid type provided for map key: %v", ast.Describe(key))
 		}
 
 		// Next we expect to see a ':'.
-		_, err = expect(lexer.Colon)
+		_, err = p.expect(lexer.Colon)
 		if err != nil {
 			return nil, err
 		}
 
 		// Capture the value defined.
-		value, err := parse_expression()
+		value, err := p.parse_expression()
 		if err != nil {
 			return ast.Expr{}, err
 		}
 
 		// Need to make sure the keys are unique.
-		if _, ok := keyValuePairs[key]; ok {
-			return nil, fmt.Errorf("maps keys should be unique: %v", key)
+		if seen[map_key(key)] {
+			return nil, fmt.Errorf("maps keys should be unique: %v", map_key(key))
 		}
 
 		// Store the new key/value pair.
-		keyValuePairs[key] = value
+		seen[map_key(key)] = true
+		keyValuePairs = append(keyValuePairs, ast.MapEntry{Key: key, Value: value})
 
 		// Next we expect to see a ','.
-		_, err = expect(lexer.Comma)
+		_, err = p.expect(lexer.Comma)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	// End of map body, expect to see a closing bracket.
-	_, err := expect(lexer.CloseBrace)
+	_, err := p.expect(lexer.CloseBrace)
 	if err != nil {
 		return nil, err
 	}
 
 	// End of array decleration, expect to see an EOL.
-	_, err = expect(lexer.EOL)
+	_, err = p.expect(lexer.EOL)
 	if err != nil {
 		return nil, err
 	}
 
 	return ast.MapDecleration{
 		Kind:       "MapDeclerationNode",
+		Span:       p.span(keyword),
 		Identifier: identifier,
 		Value:      keyValuePairs,
 		Constant:   isConst,
+		Type:       annotation,
 	}, nil
 }
 
+// Number of type params each of the annotatable types takes, e.g. map<K, V> takes 2.
+var typeParams = map[string]int{
+	"int":      0,
+	"float":    0,
+	"string":   0,
+	"bytes":    0,
+	"bool":     0,
+	"any":      0,
+	"object":   0,
+	"iterator": 0,
+	"chan":     0,
+	"array":    1,
+	"set":      1,
+	"map":      2,
+}
+
+// Is a type annotation coming up? Types are identifiers followed by either the annotated
+// name (let int x) or their type params (let array<int> x).
+func (p *Parser) isTypeAhead() bool {
+	return p.at().Type == lexer.Identifier && (p.peek().Type == lexer.Identifier || p.peek().Value == "<")
+}
+
+// Parses a type annotation, e.g. int, array<string>, map<string, array<int>>
+func (p *Parser) parse_type_annotation() (ast.TypeAnnotation, error) {
+
+	name, err := p.expect(lexer.Identifier)
+	if err != nil {
+		return ast.TypeAnnotation{}, err
+	}
+
+	numParams, known := typeParams[name.Value]
+	if !known {
+		return ast.TypeAnnotation{}, fmt.Errorf("unknown type '%v'", name.Value)
+	}
+
+	annotation := ast.TypeAnnotation{
+		Name:   name.Value,
+		Params: []ast.TypeAnnotation{},
+		Line:   name.Line,
+		Col:    name.Col,
+	}
+
+	if numParams == 0 {
+		return annotation, nil
+	}
+
+	// Generic types, expect to see '<'.
+	if p.at().Value != "<" {
+		return ast.TypeAnnotation{}, fmt.Errorf("type '%v' expects %v type params", name.Value, numParams)
+	}
+	p.eat()
+
+	for {
+
+		param, err := p.parse_type_annotation()
+		if err != nil {
+			return ast.TypeAnnotation{}, err
+		}
+
+		annotation.Params = append(annotation.Params, param)
+
+		if p.at().Type != lexer.Comma {
+			break
+		}
+		p.eat()
+	}
+
+	// Nested type params close together, i.e. 'map<string, array<int>>', so only
+	// consume the first half of the '>>' here.
+	if p.at().Value == ">>" {
+		p.tokens[p.tokenPointer].Value = ">"
+		p.tokens[p.tokenPointer].Col++
+	} else {
+
+		// End of the type params, expect to see '>'.
+		if p.at().Value != ">" {
+			return ast.TypeAnnotation{}, fmt.Errorf("expecting token `>`")
+		}
+		p.eat()
+	}
+
+	if len(annotation.Params) != numParams {
+		return ast.TypeAnnotation{}, fmt.Errorf("type '%v' expects %v type params, got %v", name.Value, numParams, len(annotation.Params))
+	}
+
+	return annotation, nil
+}
+
 // Is the key type one of the valid types Goblin allows for its keys?
 func isComparableType(val any) bool {
 
@@ -845,24 +1293,40 @@ func isComparableType(val any) bool {
 	}
 }
 
+// Renders a map key as it would be written in source, so keys written in different places
+// compare equal.
+func map_key(key ast.Expression) string {
+
+	switch k := key.(type) {
+	case ast.NumericLiteral:
+		return fmt.Sprintf("%v", k.Value)
+	case ast.StringLiteral:
+		return fmt.Sprintf("%q", k.Value)
+	case ast.BooleanLiteral:
+		return fmt.Sprintf("%v", k.Value)
+	}
+
+	return ast.Describe(key)
+}
+
 // Parses a statement that declares a new array.
-func parse_array_decleration(identifier string, isConst bool) (ast.Expression, error) {
+func (p *Parser) parse_array_decleration(keyword lexer.Token, identifier string, isConst bool, annotation *ast.TypeAnnotation) (ast.Expression, error) {
 
 	expressions := make([]ast.Expression, 0)
 
-	for at().Type != lexer.CloseBracket && at().Type != lexer.EOF {
+	for p.at().Type != lexer.CloseBracket && p.at().Type != lexer.EOF {
 
-		value, err := parse_expression()
+		value, err := p.parse_expression()
 		if err != nil {
 			return ast.Expr{}, err
 		}
 
 		expressions = append(expressions, value)
 
-		if at().Type == lexer.CloseBracket {
+		if p.at().Type == lexer.CloseBracket {
 			break
 		} else {
-			_, err = expect(lexer.Comma)
+			_, err = p.expect(lexer.Comma)
 			if err != nil {
 				return nil, err
 			}
@@ -870,46 +1334,57 @@ func parse_array_decleration(identifier string, isConst bool) (ast.Expression, e
 	}
 
 	// End of array body, expect to see a closing bracket.
-	_, err := expect(lexer.CloseBracket)
+	_, err := p.expect(lexer.CloseBracket)
 	if err != nil {
 		return nil, err
 	}
 
 	// End of array decleration, expect to see an EOL.
-	_, err = expect(lexer.EOL)
+	_, err = p.expect(lexer.EOL)
 	if err != nil {
 		return nil, err
 	}
 
 	decleration := ast.ArrayDecleration{
 		Kind:       "ArrayDeclerationNode",
+		Span:       p.span(keyword),
 		Value:      expressions,
 		Identifier: identifier,
 		Constant:   isConst,
+		Type:       annotation,
 	}
 
 	return decleration, nil
 }
 
-// Defines how the interpreter handles additive expressions.
-func parse_additive_expression() (ast.Expression, error) {
+// Defines how the interpreter handles binary expressions, starting from the given
+// level of the precedence table.
+func (p *Parser) parse_binary_expression(level int) (ast.Expression, error) {
+
+	// Past the tightest binding binary operators.
+	if level == len(binaryPrecedence) {
+		return p.parse_unary_expression()
+	}
+
+	start := p.at()
 
-	left, err := parse_multiplicitive_expression()
+	left, err := p.parse_binary_expression(level + 1)
 	if err != nil {
 		return ast.Expr{}, err
 	}
 
-	for at().Value == "+" || at().Value == "-" || at().Value == "<" || at().Value == ">" || at().Value == "==" || at().Value == "!=" {
+	for isOperatorOf(p.at(), binaryPrecedence[level]) {
 
-		operator := eat().Value
+		operator := p.eat().Value
 
-		right, err := parse_multiplicitive_expression()
+		right, err := p.parse_binary_expression(level + 1)
 		if err != nil {
 			return ast.Expr{}, err
 		}
 
 		left = ast.BinaryExpr{
 			Kind:     "BinaryExprNode",
+			Span:     p.span(start),
 			Left:     left,
 			Right:    right,
 			Operator: operator,
@@ -919,17 +1394,93 @@ func parse_additive_expression() (ast.Expression, error) {
 	return left, nil
 }
 
-func parse_call_member_expression() (ast.Expression, error) {
+// Is the token one of the given binary operators?
+func isOperatorOf(tk lexer.Token, operators []string) bool {
+
+	switch tk.Type {
+	case lexer.BinaryOperator, lexer.ConditionalOperator, lexer.Equality, lexer.NotEquality:
+	default:
+		return false
+	}
+
+	for _, op := range operators {
+		if tk.Value == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Defines how the interpreter handles prefix operators, i.e. -x or ~x.
+func (p *Parser) parse_unary_expression() (ast.Expression, error) {
+
+	if p.at().Type == lexer.BinaryOperator && (p.at().Value == "-" || p.at().Value == "~") {
+
+		opp := p.eat()
+
+		arg, err := p.parse_unary_expression()
+		if err != nil {
+			return ast.Expr{}, err
+		}
+
+		return ast.UnaryExpr{
+			Kind:     ast.UnaryExprNode,
+			Span:     p.span(opp),
+			Operator: opp.Value,
+			Argument: arg,
+		}, nil
+	}
+
+	return p.parse_exponent_expression()
+}
+
+// Defines how the interpreter handles '**', which is right associative and binds tighter
+// than prefix operators on its left, i.e. -2 ** 2 == -4.
+func (p *Parser) parse_exponent_expression() (ast.Expression, error) {
+
+	start := p.at()
 
-	member, err := parse_member_expression()
+	base, err := p.parse_call_member_expression()
+	if err != nil {
+		return ast.Expr{}, err
+	}
+
+	if p.at().Type == lexer.BinaryOperator && p.at().Value == "**" {
+
+		operator := p.eat().Value
+
+		exponent, err := p.parse_unary_expression()
+		if err != nil {
+			return ast.Expr{}, err
+		}
+
+		return ast.BinaryExpr{
+			Kind:     "BinaryExprNode",
+			Span:     p.span(start),
+			Left:     base,
+			Right:    exponent,
+			Operator: operator,
+		}, nil
+	}
+
+	return base, nil
+}
+
+func (p *Parser) parse_call_member_expression() (ast.Expression, error) {
+
+	// Capture where the caller starts, for error reporting.
+	start := p.at()
+
+	member, err := p.parse_member_expression()
 	if err != nil {
 		return ast.Expr{}, err
 	}
 
 	// '(' found, go into a call expression.
-	if at().Type == lexer.OpenParen {
+	if p.at().Type == lexer.OpenParen {
 
-		val, err := parse_call_expression(member)
+		val, err := p.parse_call_expression(member, start)
 		if err != nil {
 			return ast.Expr{}, err
 		}
@@ -940,28 +1491,24 @@ func parse_call_member_expression() (ast.Expression, error) {
 	return member, nil
 }
 
-func parse_call_expression(caller ast.Expression) (ast.CallExpr, error) {
+func (p *Parser) parse_call_expression(caller ast.Expression, start lexer.Token) (ast.CallExpr, error) {
 
-	args, err := parse_args()
+	args, err := p.parse_args()
 	if err != nil {
 		return ast.CallExpr{}, err
 	}
 
 	call_expr := ast.CallExpr{
 		Kind:   "CallExpression",
+		Span:   p.span(start),
 		Caller: caller,
 		Args:   args,
 	}
 
 	// At another '('.
-	if at().Type == lexer.OpenParen {
+	if p.at().Type == lexer.OpenParen {
 
-		call_expr, err = parse_call_expression(call_expr)
-		if err != nil {
-			return ast.CallExpr{}, err
-		}
-	} else {
-		_, err := expect(lexer.EOL)
+		call_expr, err = p.parse_call_expression(call_expr, start)
 		if err != nil {
 			return ast.CallExpr{}, err
 		}
@@ -970,20 +1517,20 @@ func parse_call_expression(caller ast.Expression) (ast.CallExpr, error) {
 	return call_expr, nil
 }
 
-func parse_args() ([]ast.Expression, error) {
+func (p *Parser) parse_args() ([]ast.Expression, error) {
 
-	_, err := expect(lexer.OpenParen)
+	_, err := p.expect(lexer.OpenParen)
 	if err != nil {
 		return []ast.Expression{}, err
 	}
 
 	var args []ast.Expression
 
-	if at().Type == lexer.CloseParen {
+	if p.at().Type == lexer.CloseParen {
 		// Return an empty array.
 		args = []ast.Expression{}
 	} else {
-		argsList, err := parse_args_list()
+		argsList, err := p.parse_args_list()
 		if err != nil {
 			return []ast.Expression{}, err
 		}
@@ -991,7 +1538,7 @@ func parse_args() ([]ast.Expression, error) {
 		args = argsList
 	}
 
-	_, err = expect(lexer.CloseParen)
+	_, err = p.expect(lexer.CloseParen)
 	if err != nil {
 		return []ast.Expression{}, err
 	}
@@ -1000,20 +1547,20 @@ func parse_args() ([]ast.Expression, error) {
 }
 
 // Handles the following, e.g. foo(x = 5, v = "bar")
-func parse_args_list() ([]ast.Expression, error) {
+func (p *Parser) parse_args_list() ([]ast.Expression, error) {
 
 	args := make([]ast.Expression, 0)
 
-	arg1, err := parse_assignment_expression()
+	arg1, err := p.parse_arg()
 	if err != nil {
 		return []ast.Expression{}, err
 	}
 
 	args = append(args, arg1)
 
-	for at().Type == lexer.Comma && (eat() != lexer.Token{}) {
+	for p.at().Type == lexer.Comma && (p.eat() != lexer.Token{}) {
 
-		expr, err := parse_assignment_expression()
+		expr, err := p.parse_arg()
 		if err != nil {
 			return []ast.Expression{}, err
 		}
@@ -1024,50 +1571,427 @@ func parse_args_list() ([]ast.Expression, error) {
 	return args, nil
 }
 
+// Parses a single call argument, which may be spread, i.e. foo(...args)
+func (p *Parser) parse_arg() (ast.Expression, error) {
+
+	if p.at().Type == lexer.Ellipsis {
+
+		ellipsis := p.eat() // Move past the '...'.
+
+		arg, err := p.parse_assignment_expression()
+		if err != nil {
+			return nil, err
+		}
+
+		return ast.SpreadExpr{
+			Kind:     ast.SpreadExprNode,
+			Span:     p.span(ellipsis),
+			Argument: arg,
+		}, nil
+	}
+
+	return p.parse_assignment_expression()
+}
+
+// Parses the parameter list of a function decleration, e.g. (name, greeting = "hi", ...rest)
+func (p *Parser) parse_params() ([]ast.Parameter, error) {
+
+	_, err := p.expect(lexer.OpenParen)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]ast.Parameter, 0)
+	seen := make(map[string]bool)
+	hasDefault := false
+
+	for p.at().Type != lexer.CloseParen && p.at().Type != lexer.EOF {
+
+		rest := false
+		if p.at().Type == lexer.Ellipsis {
+			p.eat() // Move past the '...'.
+			rest = true
+		}
+
+		// Optional param type, i.e. '(int a)'.
+		var paramType *ast.TypeAnnotation
+		if p.isTypeAhead() {
+
+			t, err := p.parse_type_annotation()
+			if err != nil {
+				return nil, err
+			}
+
+			paramType = &t
+		}
+
+		var param ast.Parameter
+
+		// Destructured param, i.e. 'fn f([a, b], {host, port})'.
+		if !rest && paramType == nil && (p.at().Type == lexer.OpenBracket || p.at().Type == lexer.OpenBrace) {
+
+			pattern, err := p.parse_pattern()
+			if err != nil {
+				return nil, err
+			}
+
+			param = ast.Parameter{
+				Name:    pattern.String(),
+				Pattern: &pattern,
+			}
+		} else {
+
+			name, err := p.expect(lexer.Identifier)
+			if err != nil {
+				return nil, err
+			}
+
+			if seen[name.Value] {
+				return nil, fmt.Errorf("duplicate param '%v'", name.Value)
+			}
+			seen[name.Value] = true
+
+			param = ast.Parameter{
+				Name: name.Value,
+				Rest: rest,
+				Type: paramType,
+			}
+		}
+
+		if p.at().Type == lexer.Equals {
+
+			if rest {
+				return nil, fmt.Errorf("rest param '%v' cannot have a default value", param.Name)
+			}
+
+			p.eat() // Move past the '='.
+
+			def, err := p.parse_expression()
+			if err != nil {
+				return nil, err
+			}
+
+			param.Default = def
+			hasDefault = true
+
+		} else if hasDefault && !rest {
+			return nil, fmt.Errorf("param '%v' without a default value cannot follow one with a default", param.Name)
+		}
+
+		params = append(params, param)
+
+		// The rest param collects everything left over, so it has to come last.
+		if rest && p.at().Type != lexer.CloseParen {
+			return nil, fmt.Errorf("rest param '%v' must be the last param", param.Name)
+		}
+
+		if p.at().Type != lexer.CloseParen {
+			_, err = p.expect(lexer.Comma)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	_, err = p.expect(lexer.CloseParen)
+	if err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
 // Parses a 'using' directive.
-func parse_using_decleration() (ast.Expression, error) {
+func (p *Parser) parse_using_decleration() (ast.Expression, error) {
 
 	// Move past the 'using' keyword.
-	eat()
+	keyword := p.eat()
+
+	imports := make([]ast.Import, 0)
+
+	// A comma separated list, i.e. using "io", "strings" as s;
+	for {
+
+		imp, err := p.parse_import()
+		if err != nil {
+			return nil, err
+		}
+
+		imports = append(imports, imp)
+
+		if p.at().Type != lexer.Comma {
+			break
+		}
 
-	val, err := parse_statement()
+		p.eat()
+	}
+
+	// Always expect to see a ';' after a using directive.
+	_, err := p.expect(lexer.EOL)
 	if err != nil {
 		return nil, err
 	}
 
+	return ast.NamespaceDecleration{
+		Kind:    "NamespaceDecleration",
+		Span:    p.span(keyword),
+		Imports: imports,
+	}, nil
+}
+
+// Parses a single import of a using directive, i.e. "io", "./lib/math.gob" as m, or
+// { split, join } from "strings"
+func (p *Parser) parse_import() (ast.Import, error) {
+
+	var members []string
+
+	// Only bring the listed names into scope.
+	if p.at().Type == lexer.OpenBrace {
+
+		p.eat()
+
+		members = make([]string, 0)
+		for p.notEof() && p.at().Type != lexer.CloseBrace {
+
+			name, err := p.expect(lexer.Identifier)
+			if err != nil {
+				return ast.Import{}, fmt.Errorf("expecting a name to import")
+			}
+
+			members = append(members, name.Value)
+
+			if p.at().Type != lexer.CloseBrace {
+
+				_, err = p.expect(lexer.Comma)
+				if err != nil {
+					return ast.Import{}, err
+				}
+			}
+		}
+
+		_, err := p.expect(lexer.CloseBrace)
+		if err != nil {
+			return ast.Import{}, err
+		}
+
+		if len(members) == 0 {
+			return ast.Import{}, fmt.Errorf("expecting at least one name to import")
+		}
+
+		if p.at().Type != lexer.Identifier || p.at().Value != "from" {
+			return ast.Import{}, fmt.Errorf("expecting 'from' after the names to import")
+		}
+
+		p.eat()
+	}
+
+	val, err := p.parse_primary_expression()
+	if err != nil {
+		return ast.Import{}, err
+	}
+
 	str, ok := val.(ast.StringLiteral)
 	if !ok {
-		return nil, ErrorGenerator("string type required with using directives")
+		return ast.Import{}, p.ErrorGenerator("string type required with using directives")
 	}
 
-	// Always expect to see a ';' after a using directive.
-	_, err = expect(lexer.EOL)
+	// An optional alias, i.e. using "./lib/math.gob" as m;
+	alias := ""
+	if members == nil && p.at().Type == lexer.Identifier && p.at().Value == "as" {
+
+		p.eat()
+
+		name, err := p.expect(lexer.Identifier)
+		if err != nil {
+			return ast.Import{}, fmt.Errorf("expecting a name after 'as'")
+		}
+
+		alias = name.Value
+	}
+
+	return ast.Import{
+		Name:    str.Value,
+		Alias:   alias,
+		Members: members,
+	}, nil
+}
+
+// Parses an 'export' statement, i.e. export const pi = 3;
+func (p *Parser) parse_export_statement() (ast.Expression, error) {
+
+	// Move past the 'export' keyword.
+	keyword := p.eat()
+
+	dec, err := p.parse_statement()
 	if err != nil {
 		return nil, err
 	}
 
-	return ast.NamespaceDecleration{
-		Kind: "NamespaceDecleration",
-		Name: str.Value,
+	name := ""
+	switch d := dec.(type) {
+	case ast.VariableDecleration:
+		name = d.Identifier
+	case ast.ArrayDecleration:
+		name = d.Identifier
+	case ast.MapDecleration:
+		name = d.Identifier
+	case ast.FunctionDecleration:
+		name = d.Name
+	}
+
+	// Anonymous functions and destructuring have no single name to export.
+	if name == "" {
+		return nil, fmt.Errorf("export requires a let, const or fn decleration")
+	}
+
+	return ast.ExportStatement{
+		Kind:        ast.ExportStatementNode,
+		Span:        p.span(keyword),
+		Decleration: dec,
+		Name:        name,
+	}, nil
+}
+
+// Parses a 'defer' statement, i.e. defer io.close(f);
+func (p *Parser) parse_defer_statement() (ast.Expression, error) {
+
+	// Move past the 'defer' keyword.
+	keyword := p.eat()
+
+	expr, err := p.parse_expression()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only function calls can be deferred.
+	call, isCall := expr.(ast.CallExpr)
+	if !isCall {
+		return nil, fmt.Errorf("defer requires a function call")
+	}
+
+	_, err = p.expect(lexer.EOL)
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.DeferStatement{
+		Kind: ast.DeferStatementNode,
+		Span: p.span(keyword),
+		Call: call,
+	}, nil
+}
+
+// Parses a 'spawn' statement, i.e. spawn worker(jobs);
+func (p *Parser) parse_spawn_statement() (ast.Expression, error) {
+
+	// Move past the 'spawn' keyword.
+	keyword := p.eat()
+
+	expr, err := p.parse_expression()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only function calls can be spawned.
+	call, isCall := expr.(ast.CallExpr)
+	if !isCall {
+		return nil, fmt.Errorf("spawn requires a function call")
+	}
+
+	_, err = p.expect(lexer.EOL)
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.SpawnStatement{
+		Kind: ast.SpawnStatementNode,
+		Span: p.span(keyword),
+		Call: call,
+	}, nil
+}
+
+// Parses a 'return' statement, i.e. return x; or return;
+func (p *Parser) parse_return_statement() (ast.Expression, error) {
+
+	// Move past the 'return' keyword.
+	keyword := p.eat()
+
+	// Bare 'return;', nothing to give back to the caller.
+	if p.at().Type == lexer.EOL {
+
+		p.eat()
+
+		return ast.ReturnStatement{
+			Kind:  ast.ReturnStatementNode,
+			Span:  p.span(keyword),
+			Value: nil,
+		}, nil
+	}
+
+	value, err := p.parse_expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.expect(lexer.EOL)
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.ReturnStatement{
+		Kind:  ast.ReturnStatementNode,
+		Span:  p.span(keyword),
+		Value: value,
+	}, nil
+}
+
+// Parses a 'yield' statement, which turns the enclosing function into a generator.
+func (p *Parser) parse_yield_statement() (ast.Expression, error) {
+
+	// Move past the 'yield' keyword.
+	keyword := p.eat()
+
+	if p.fnDepth == 0 {
+		return nil, fmt.Errorf("yield can only be used inside a function")
+	}
+
+	p.yielded = true
+
+	value, err := p.parse_expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.expect(lexer.EOL)
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.YieldStatement{
+		Kind:  ast.YieldStatementNode,
+		Span:  p.span(keyword),
+		Value: value,
 	}, nil
 }
 
 // Parses how to access member fields from an object.
-func parse_member_expression() (ast.Expression, error) {
+func (p *Parser) parse_member_expression() (ast.Expression, error) {
+
+	start := p.at()
 
-	object, err := parse_primary_expression()
+	object, err := p.parse_primary_expression()
 	if err != nil {
 		return ast.Expr{}, err
 	}
 
-	for at().Type == lexer.Period || at().Type == lexer.OpenBracket {
+	for p.at().Type == lexer.Period || p.at().Type == lexer.OpenBracket {
 
 		// Gives us access to current operator, either '.' or '('
-		opp := eat()
+		opp := p.eat()
 		var computed bool
 
 		// Get the Identifier.
-		prop, err := parse_primary_expression()
+		prop, err := p.parse_primary_expression()
 		if err != nil {
 			return ast.Expr{}, err
 		}
@@ -1087,12 +2011,12 @@ func parse_member_expression() (ast.Expression, error) {
 			// This should allow us to do chaining.
 			computed = true
 
-			prop, err = parse_expression()
+			prop, err = p.parse_expression()
 			if err != nil {
 				return ast.Expr{}, err
 			}
 
-			_, err = expect(lexer.CloseBracket)
+			_, err = p.expect(lexer.CloseBracket)
 			if err != nil {
 				return ast.Expr{}, err
 			}
@@ -1100,6 +2024,7 @@ func parse_member_expression() (ast.Expression, error) {
 
 		object = ast.MemberExpr{
 			Kind:     "MemberExpressionNode",
+			Span:     p.span(start),
 			Object:   object,
 			Property: prop,
 			Computed: computed,
@@ -1109,68 +2034,51 @@ func parse_member_expression() (ast.Expression, error) {
 	return object, nil
 }
 
-// Defines how the interpreter handles multiplicitive expressions.
-func parse_multiplicitive_expression() (ast.Expression, error) {
-
-	left, err := parse_call_member_expression()
-	if err != nil {
-		return ast.Expr{}, err
-	}
-
-	for at().Value == "*" || at().Value == "/" || at().Value == "%" {
-
-		operator := eat().Value
-
-		right, err := parse_call_member_expression()
-		if err != nil {
-			return ast.Expr{}, err
-		}
-
-		left = ast.BinaryExpr{
-			Kind:     "BinaryExprNode",
-			Left:     left,
-			Right:    right,
-			Operator: operator,
-		}
-	}
-
-	return left, nil
-}
-
-func parse_identifier() (ast.Expression, error) {
+func (p *Parser) parse_identifier() (ast.Expression, error) {
 
 	// Normal identifier, or array identifier?
 	// Normal -> x
 	// Array -> x[0]
 	// Map -> x["foo"]
+	// Slice -> x[1:3], x[:2] or x[1:]
 	// Shorthand Operator -> x++ or x--
 
-	identifier := eat() // Capture the identifier value
+	identifier := p.eat() // Capture the identifier value
 
-	if at().Type == lexer.OpenBracket {
-		eat() // Eat the open bracket.
+	if p.at().Type == lexer.OpenBracket {
+		p.eat() // Eat the open bracket.
+
+		// Open start slice, i.e. x[:2].
+		if p.at().Type == lexer.Colon {
+			return p.parse_slice_expression(identifier, nil)
+		}
 
 		// Capture index, but we need to parse it as it could be a number or an identifier.
-		index, err := parse_expression()
+		index, err := p.parse_expression()
 		if err != nil {
 			return nil, err
 		}
 
+		if p.at().Type == lexer.Colon {
+			return p.parse_slice_expression(identifier, index)
+		}
+
 		// End of array/map body, expect to see a closing bracket.
-		_, err = expect(lexer.CloseBracket)
+		_, err = p.expect(lexer.CloseBracket)
 		if err != nil {
 			return nil, err
 		}
 
 		return ast.ArrayOrMapIdentifier{
 			Kind:   "ArrayOrMapIdentifierNode",
+			Span:   p.span(identifier),
 			Symbol: identifier.Value,
 			Index:  index,
 		}, nil
-	} else if at().Type == lexer.ShorthandOperator {
+	} else if p.at().Type == lexer.ShorthandOperator {
 
 		// Capture the operator type.
-		opp := eat()
+		opp := p.eat()
 
 		// Depending on shorthand operator used:
 		// x++;
@@ -1182,16 +2090,18 @@ func parse_identifier() (ast.Expression, error) {
 			// ++, --
 
 			// End of statement.
-			_, err := expect(lexer.EOL)
+			_, err := p.expect(lexer.EOL)
 			if err != nil {
 				return nil, err
 			}
 
 			return ast.ShorthandOperator{
 				Kind: "ShorthandOperatorNode",
+				Span: p.span(identifier),
 				Left: identifier.Value,
 				Right: ast.NumericLiteral{
 					Kind:  "NumberNode",
+					Span:  p.span(opp),
 					Value: 0,
 				},
 				Operator: opp.Value,
@@ -1199,19 +2109,20 @@ func parse_identifier() (ast.Expression, error) {
 
 		} else {
 
-			rhs, err := parse_expression()
+			rhs, err := p.parse_expression()
 			if err != nil {
-				return ast.Expr{}, nil
+				return ast.Expr{}, err
 			}
 
 			// End of statement.
-			_, err = expect(lexer.EOL)
+			_, err = p.expect(lexer.EOL)
 			if err != nil {
 				return nil, err
 			}
 
 			return ast.ShorthandOperator{
 				Kind:     "ShorthandOperatorNode",
+				Span:     p.span(identifier),
 				Left:     identifier.Value,
 				Right:    rhs,
 				Operator: opp.Value,
@@ -1221,55 +2132,163 @@ func parse_identifier() (ast.Expression, error) {
 		// Standard identifier.
 		return ast.Identifier{
 			Kind:   "IdentifierNode",
+			Span:   p.span(identifier),
 			Symbol: identifier.Value,
 		}, nil
 	}
 }
 
+// Parses the remainder of a slice, from the ':' onwards. The start index has already
+// been parsed, and is nil when left open.
+func (p *Parser) parse_slice_expression(identifier lexer.Token, start ast.Expression) (ast.Expression, error) {
+
+	// Eat the ':'.
+	p.eat()
+
+	var end ast.Expression
+
+	// Open end slice, i.e. x[1:].
+	if p.at().Type != lexer.CloseBracket {
+
+		e, err := p.parse_expression()
+		if err != nil {
+			return nil, err
+		}
+
+		end = e
+	}
+
+	_, err := p.expect(lexer.CloseBracket)
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.SliceExpr{
+		Kind:   ast.SliceExprNode,
+		Span:   p.span(identifier),
+		Symbol: identifier.Value,
+		Start:  start,
+		End:    end,
+	}, nil
+}
+
+// Parses an array used as a value, i.e. the rhs of 'arr[1:3] = [4, 5];'.
+func (p *Parser) parse_array_literal() (ast.Expression, error) {
+
+	// Eat the '['.
+	open := p.eat()
+
+	elements := make([]ast.Expression, 0)
+
+	for p.at().Type != lexer.CloseBracket && p.at().Type != lexer.EOF {
+
+		value, err := p.parse_expression()
+		if err != nil {
+			return ast.Expr{}, err
+		}
+
+		elements = append(elements, value)
+
+		if p.at().Type != lexer.CloseBracket {
+			_, err = p.expect(lexer.Comma)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	_, err := p.expect(lexer.CloseBracket)
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.ArrayLiteral{
+		Kind:     ast.ArrayLiteralNode,
+		Span:     p.span(open),
+		Elements: elements,
+	}, nil
+}
+
 // Defines how the interpreter handles primary expressions.
-func parse_primary_expression() (ast.Expression, error) {
+func (p *Parser) parse_primary_expression() (ast.Expression, error) {
 
-	tk := at().Type
+	tk := p.at().Type
 
 	switch tk {
 	case lexer.Identifier:
 		// Some form of Identifier coming in.
-		iden, err := parse_identifier()
+		iden, err := p.parse_identifier()
 		if err != nil {
-			return ast.Expr{}, nil
+			return ast.Expr{}, err
 		}
 
 		return iden, nil
 	case lexer.Boolean:
+		literal := p.eat()
+
 		return ast.BooleanLiteral{
 			Kind:  "BooleanLiteralNode",
-			Value: utils.StoB(eat().Value),
+			Span:  p.span(literal),
+			Value: utils.StoB(literal.Value),
 		}, nil
 	case lexer.String:
+		literal := p.eat()
+
 		return ast.StringLiteral{
 			Kind:  "StringLiteralNode",
-			Value: eat().Value,
+			Span:  p.span(literal),
+			Value: literal.Value,
+		}, nil
+	case lexer.Bytes:
+
+		literal := p.eat()
+
+		value, err := decode_bytes_literal(literal.Value)
+		if err != nil {
+			return ast.Expr{}, err
+		}
+
+		return ast.BytesLiteral{
+			Kind:  ast.BytesLiteralNode,
+			Span:  p.span(literal),
+			Value: value,
 		}, nil
 	case lexer.Number:
 		// Convert the tokens string value into a int.
-		val, err := utils.ToNumber(eat().Value)
+		literal := p.eat()
+		val, err := utils.ToNumber(literal.Value)
 		if err != nil {
-			return ast.Expr{}, err
+
+			// Too large for an int, keep it as a big int instead.
+			bigVal, ok := new(big.Int).SetString(literal.Value, 10)
+			if !ok {
+				return ast.Expr{}, err
+			}
+
+			return ast.BigIntLiteral{
+				Kind:  ast.BigIntLiteralNode,
+				Span:  p.span(literal),
+				Value: bigVal,
+			}, nil
 		}
 
 		return ast.NumericLiteral{
 			Kind:  "NumericLiteralNode",
+			Span:  p.span(literal),
 			Value: val,
 		}, nil
 
+	case lexer.OpenBracket:
+		return p.parse_array_literal()
+
 	case lexer.OpenParen:
-		eat() // Consume to remove.
-		v, err := parse_expression()
+		p.eat() // Consume to remove.
+		v, err := p.parse_expression()
 		if err != nil {
 			return ast.Expr{}, err
 		}
 		value := v
-		_, err = expect(lexer.CloseParen) // Consume to remove.
+		_, err = p.expect(lexer.CloseParen) // Consume to remove.
 		if err != nil {
 			return ast.Expr{}, err
 		}
@@ -1277,12 +2296,62 @@ func parse_primary_expression() (ast.Expression, error) {
 		return value, nil
 
 	default:
-		message := fmt.Sprintf("unexpected token found during parsing '%v'", at().Value)
+		message := fmt.Sprintf("unexpected token found during parsing '%v'", p.at().Value)
 		return ast.Expr{}, fmt.Errorf("%v", message)
 	}
 }
 
+// Decodes the escape sequences in the body of a bytes literal, i.e. b"\x00\xff\n".
+func decode_bytes_literal(raw string) ([]byte, error) {
+
+	decoded := make([]byte, 0, len(raw))
+
+	for i := 0; i < len(raw); i++ {
+
+		if raw[i] != '\\' {
+			decoded = append(decoded, raw[i])
+			continue
+		}
+
+		if i+1 >= len(raw) {
+			return nil, fmt.Errorf("unterminated escape sequence in bytes literal")
+		}
+
+		i++
+
+		switch raw[i] {
+		case 'x':
+
+			if i+2 >= len(raw) {
+				return nil, fmt.Errorf("invalid escape sequence `\\%v` in bytes literal", raw[i:])
+			}
+
+			b, err := strconv.ParseUint(raw[i+1:i+3], 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid escape sequence `\\%v` in bytes literal", raw[i:i+3])
+			}
+
+			decoded = append(decoded, byte(b))
+			i += 2
+		case 'n':
+			decoded = append(decoded, '\n')
+		case 't':
+			decoded = append(decoded, '\t')
+		case 'r':
+			decoded = append(decoded, '\r')
+		case '0':
+			decoded = append(decoded, 0)
+		case '\\', '"':
+			decoded = append(decoded, raw[i])
+		default:
+			return nil, fmt.Errorf("invalid escape sequence `\\%c` in bytes literal", raw[i])
+		}
+	}
+
+	return decoded, nil
+}
+
 // Checks to see if we have hit the end of the file.
-func notEof() bool {
-	return tokens[tokenPointer].Type != lexer.EOF
+func (p *Parser) notEof() bool {
+	return p.at().Type != lexer.EOF
 }
@@ -0,0 +1,696 @@
+package resolver
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"goblin.org/main/frontend/ast"
+	"goblin.org/main/utils"
+)
+
+/*
+Static scope resolver, run over the AST before it is evaluated.
+
+Every variable read or assigned is bound to where it lives: a global, looked up by name,
+or a slot in the frame of the function it was declared in, so many functions out. Each
+function gets one frame, holding its params and everything its body declares, including
+the scopes of the loops within it, each given a range of slots of its own.
+
+Scopes are the same as the interpreter's. Functions and for loops have their own, the
+bodies of if statements and while loops share the scope they are in. Within a function,
+a variable is only visible once its decleration has been reached, before then the name
+refers to whatever it meant outside. Functions can be called after the scopes they were
+declared in have carried on, so from a nested function every name of an enclosing scope
+is visible, wherever it was declared.
+*/
+
+// Origin every resolve error is prefixed with, used to line up the error underline.
+const Origin = "resolve error: "
+
+// The names already declared in the global scope a program runs in, i.e. builtins or the
+// variables of earlier REPL lines. Declaring one of them again is left for the interpreter
+// to report, should the decleration ever be reached.
+type Globals struct {
+	Variables  map[string]bool   // Whether each variable is constant.
+	Namespaces map[string]string // The name of the namespace each is used for, i.e. 'io'.
+}
+
+// Where a variable lives.
+type Binding struct {
+	Global bool // Looked up by name in the global scope, rather than held in a slot.
+	Depth  int  // How many functions out the variable was declared, 0 for the one using it.
+	Slot   int  // Where the variable is held in the frame of that function.
+}
+
+// A variable read or assigned, by the node it was written in and its name.
+type Ref struct {
+	Span ast.Span
+	Name string
+}
+
+// The slots of a call to a function, or of the top level of a program, by the name each
+// was declared under.
+type Frame struct {
+	Slots []string
+}
+
+type ScopeKind int
+
+const (
+	FunctionScope  ScopeKind = iota // The params and body of a function.
+	LoopScope                       // The variable of a for loop.
+	IterationScope                  // A single iteration of a for or for ... in loop.
+)
+
+// A scope, by the node that introduces it.
+type ScopeRef struct {
+	Span ast.Span
+	Kind ScopeKind
+}
+
+// The slots of a scope, which follow on from Base. They start off empty each time the
+// scope is entered.
+type Scope struct {
+	Base  int
+	Count int
+	Slots map[string]int
+}
+
+// Everything the resolver worked out about a program.
+type Resolution struct {
+	Program   *Frame              // Of the top level, whose own variables are globals.
+	Functions map[ast.Span]*Frame // Of each function, by where it was declared.
+	Scopes    map[ScopeRef]Scope  // Of each function and loop.
+	Bindings  map[Ref]Binding     // Of each variable read or assigned.
+}
+
+// Returns where a variable read or assigned lives.
+func (r *Resolution) Lookup(span ast.Span, name string) (Binding, bool) {
+
+	b, ok := r.Bindings[Ref{Span: span, Name: name}]
+
+	return b, ok
+}
+
+// Resolves an entire program run in a global scope already holding globals, returning
+// every undefined variable, duplicate decleration and assignment to a const found.
+func Resolve(program ast.Program, audit map[int]string, globals Globals) (*Resolution, []error) {
+
+	res := &Resolution{
+		Program:   &Frame{Slots: []string{}},
+		Functions: map[ast.Span]*Frame{},
+		Scopes:    map[ScopeRef]Scope{},
+		Bindings:  map[Ref]Binding{},
+	}
+
+	r := &resolver{res: res, audit: audit, globals: globals, errors: []error{}}
+	r.fn = &function{frame: res.Program}
+
+	// Namespaces are always added to the global scope, wherever the 'using' is.
+	r.namespaces = map[string]bool{}
+	for name := range globals.Namespaces {
+		r.namespaces[name] = true
+	}
+	collect_namespaces(program.Body, r.namespaces)
+
+	global := &scope{
+		fn:         r.fn,
+		global:     true,
+		slots:      map[string]int{},
+		constant:   map[string]bool{},
+		declared:   map[string]bool{},
+		definite:   map[string]bool{},
+		namespaces: map[string]string{},
+	}
+
+	for _, d := range declared(program.Body) {
+		global.hoist(d)
+	}
+
+	r.scope = global
+	r.block(program.Body)
+
+	return res, r.errors
+}
+
+type resolver struct {
+	res        *Resolution
+	audit      map[int]string
+	globals    Globals
+	namespaces map[string]bool // Every namespace used anywhere in the program.
+	fn         *function       // The function being resolved.
+	scope      *scope          // The innermost scope.
+	errors     []error
+}
+
+// A function being resolved, or the top level of the program.
+type function struct {
+	frame  *Frame
+	parent *function
+	depth  int
+}
+
+type scope struct {
+	fn     *function
+	parent *scope
+	global bool
+
+	slots    map[string]int  // Every name declared anywhere in the scope, see declared.
+	constant map[string]bool // Whether every decleration of each name is a const.
+	declared map[string]bool // The names whose decleration has been reached.
+	definite map[string]bool // The names declared on every path to here, declaring them again is an error.
+
+	namespaces map[string]string // Those definitely used by here, and what for, only for the global scope.
+}
+
+// A name a scope declares.
+type decleration struct {
+	name     string
+	constant bool
+}
+
+// Records a resolve error, formatted the same way as parser errors.
+func (r *resolver) report(span ast.Span, message string) {
+
+	line, col := span.Start.Line, span.Start.Col
+	m := utils.GenerateError(Origin, r.audit[line], "", line, col, message)
+
+	r.errors = append(r.errors, fmt.Errorf("%v", m))
+}
+
+// Notes a name the scope declares, giving it a slot unless the scope is the global one.
+func (s *scope) hoist(d decleration) {
+
+	if constant, exists := s.constant[d.name]; exists {
+		s.constant[d.name] = constant && d.constant
+		return
+	}
+
+	s.constant[d.name] = d.constant
+
+	if !s.global {
+		s.slots[d.name] = len(s.fn.frame.Slots)
+		s.fn.frame.Slots = append(s.fn.frame.Slots, d.name)
+	}
+}
+
+// Enters a new scope of the current function, declaring the given names.
+func (r *resolver) enter(ref ScopeRef, names []decleration) {
+
+	s := &scope{
+		fn:       r.fn,
+		parent:   r.scope,
+		slots:    map[string]int{},
+		constant: map[string]bool{},
+		declared: map[string]bool{},
+		definite: map[string]bool{},
+	}
+
+	base := len(r.fn.frame.Slots)
+
+	for _, d := range names {
+		s.hoist(d)
+	}
+
+	r.res.Scopes[ref] = Scope{Base: base, Count: len(r.fn.frame.Slots) - base, Slots: s.slots}
+	r.scope = s
+}
+
+func (r *resolver) leave() {
+	r.scope = r.scope.parent
+}
+
+// Declares a name in the innermost scope, reporting it if it is certainly declared already.
+func (r *resolver) declare(name string, span ast.Span) {
+
+	s := r.scope
+
+	if s.definite[name] {
+		r.report(span, fmt.Sprintf("'%v' already defined", name))
+	} else if _, isNamespace := s.namespaces[name]; s.global && isNamespace {
+		r.report(span, fmt.Sprintf("'%v' already defined as a namespace", name))
+	}
+
+	// Names the pre-scan missed still get a slot, if not a fresh one each time the scope is entered.
+	if _, exists := s.constant[name]; !exists {
+		s.hoist(decleration{name: name})
+	}
+
+	s.declared[name] = true
+	s.definite[name] = true
+}
+
+// Resolves a variable read or assigned, reporting it if it isn't declared anywhere. Also
+// returns whether it is certainly a constant.
+func (r *resolver) use(span ast.Span, name string) (Binding, bool) {
+
+	for s := r.scope; s != nil; s = s.parent {
+
+		// Within the function using it, a variable only exists once declared.
+		visible := s.fn != r.fn || s.declared[name]
+
+		if s.global {
+
+			if _, exists := s.constant[name]; exists && visible {
+				return r.bind(span, name, Binding{Global: true}), s.constant[name] && (s.fn != r.fn || s.definite[name])
+			}
+
+			if constant, exists := r.globals.Variables[name]; exists {
+				return r.bind(span, name, Binding{Global: true}), constant
+			}
+
+			if r.namespaces[name] {
+				return r.bind(span, name, Binding{Global: true}), false
+			}
+
+			break
+		}
+
+		if slot, exists := s.slots[name]; exists && visible {
+			b := Binding{Depth: r.fn.depth - s.fn.depth, Slot: slot}
+			return r.bind(span, name, b), s.constant[name] && (s.fn != r.fn || s.definite[name])
+		}
+	}
+
+	r.report(span, fmt.Sprintf("reference to undefined variable '%v'", name))
+
+	return Binding{Global: true}, false
+}
+
+func (r *resolver) bind(span ast.Span, name string, b Binding) Binding {
+
+	r.res.Bindings[Ref{Span: span, Name: name}] = b
+
+	return b
+}
+
+// Resolves a variable being assigned, reporting it if it is a constant.
+func (r *resolver) assign(span ast.Span, name string, at ast.Span) {
+
+	if _, constant := r.use(span, name); constant {
+		r.report(at, fmt.Sprintf("cannot reassign const value '%v'", name))
+	}
+}
+
+// Resolves each statement of a block in order.
+func (r *resolver) block(body []ast.Expression) {
+
+	for _, stmt := range body {
+		r.resolve(stmt)
+	}
+}
+
+// Resolves the body of an if statement or while loop. They share the scope they are in, but
+// may not run, so whatever they declare is only certain within them.
+func (r *resolver) branch(body []ast.Expression) {
+
+	definite := copy_set(r.scope.definite)
+	namespaces := copy_set(r.scope.namespaces)
+
+	r.block(body)
+
+	r.scope.definite = definite
+	r.scope.namespaces = namespaces
+}
+
+func copy_set[V any](set map[string]V) map[string]V {
+
+	if set == nil {
+		return nil
+	}
+
+	copied := make(map[string]V, len(set))
+	for k, v := range set {
+		copied[k] = v
+	}
+
+	return copied
+}
+
+// Resolves a node, in the order the interpreter evaluates it.
+func (r *resolver) resolve(node ast.Expression) {
+
+	switch n := node.(type) {
+	case ast.Identifier:
+		r.use(n.Span, n.Symbol)
+	case ast.ArrayOrMapIdentifier:
+		r.resolve(n.Index)
+		r.use(n.Span, n.Symbol)
+	case ast.SliceExpr:
+		r.use(n.Span, n.Symbol)
+		r.resolve(n.Start)
+		r.resolve(n.End)
+	case ast.ShorthandOperator:
+		r.assign(n.Span, n.Left, n.Span)
+		r.resolve(n.Right)
+	case ast.BinaryExpr:
+		r.resolve(n.Left)
+		r.resolve(n.Right)
+	case ast.UnaryExpr:
+		r.resolve(n.Argument)
+	case ast.TernaryCondition:
+		r.resolve(n.Condition)
+		r.resolve(n.Left)
+		r.resolve(n.Right)
+	case ast.ArrayLiteral:
+		r.block(n.Elements)
+	case ast.ObjectLiteral:
+
+		for _, prop := range n.Properties {
+
+			// Shorthand properties, i.e. '{x}', take the variable of the same name.
+			if prop.Value == nil {
+				r.use(n.Span, prop.Key)
+			} else {
+				r.resolve(*prop.Value)
+			}
+		}
+	case ast.CallExpr:
+		r.block(n.Args)
+		r.resolve(n.Caller)
+	case ast.SpreadExpr:
+		r.resolve(n.Argument)
+	case ast.MemberExpr:
+
+		// The property is looked up on the object, not in scope.
+		if obj, ok := n.Object.(ast.Identifier); ok {
+			r.use(obj.Span, obj.Symbol)
+		}
+	case ast.AssignmentExpr:
+
+		r.resolve(n.Value)
+
+		switch assigne := n.Assigne.(type) {
+		case ast.Identifier:
+			r.assign(assigne.Span, assigne.Symbol, n.Span)
+		case ast.ArrayOrMapIdentifier:
+			r.resolve(assigne.Index)
+			r.use(assigne.Span, assigne.Symbol)
+		case ast.SliceExpr:
+			r.resolve(assigne)
+		}
+	case ast.VariableDecleration:
+		r.resolve(n.Value)
+		r.declare(n.Identifier, n.Span)
+	case ast.ArrayDecleration:
+		r.block(n.Value)
+		r.declare(n.Identifier, n.Span)
+	case ast.MapDecleration:
+
+		for _, entry := range n.Value {
+			r.resolve(entry.Key)
+			r.resolve(entry.Value)
+		}
+
+		r.declare(n.Identifier, n.Span)
+	case ast.DestructuringDecleration:
+		r.resolve(n.Value)
+		r.element(ast.PatternElement{Pattern: &n.Pattern}, n.Span)
+	case ast.FunctionDecleration:
+		r.function(n)
+	case ast.NamespaceDecleration:
+		r.namespace(n)
+	case ast.ExportStatement:
+		r.resolve(n.Decleration)
+	case ast.ReturnStatement:
+		r.resolve(n.Value)
+	case ast.YieldStatement:
+		r.resolve(n.Value)
+	case ast.DeferStatement:
+		r.resolve(n.Call)
+	case ast.SpawnStatement:
+		r.resolve(n.Call)
+	case ast.IfCondition:
+		r.resolve(n.Condition)
+		r.branch(n.Body)
+		r.branch(n.ElseBody)
+	case ast.WhileLoop:
+		r.resolve(n.Condition)
+		r.branch(n.Body)
+	case ast.ForLoop:
+
+		r.enter(ScopeRef{Span: n.Span, Kind: LoopScope}, []decleration{{name: n.Assignment.Identifier, constant: n.Assignment.Constant}})
+		r.resolve(n.Assignment)
+		r.resolve(n.Condition)
+
+		r.enter(ScopeRef{Span: n.Span, Kind: IterationScope}, declared(n.Body))
+		r.block(n.Body)
+		r.leave()
+
+		r.resolve(n.Iterator)
+		r.leave()
+	case ast.ForInLoop:
+
+		r.resolve(n.Iterable)
+
+		names := []decleration{}
+		for _, name := range ElementNames(n.Binding) {
+			names = append(names, decleration{name: name})
+		}
+
+		r.enter(ScopeRef{Span: n.Span, Kind: IterationScope}, append(names, declared(n.Body)...))
+		r.element(n.Binding, n.Span)
+		r.block(n.Body)
+		r.leave()
+	}
+}
+
+// Declares the names a pattern element binds, resolving each default before the name it
+// is the default for.
+func (r *resolver) element(element ast.PatternElement, span ast.Span) {
+
+	r.resolve(element.Default)
+
+	if element.Pattern == nil {
+		r.declare(element.Name, span)
+		return
+	}
+
+	for _, el := range element.Pattern.Elements {
+		r.element(el, span)
+	}
+}
+
+func (r *resolver) function(f ast.FunctionDecleration) {
+
+	frame := &Frame{Slots: []string{}}
+	r.res.Functions[f.Span] = frame
+
+	outer, outerScope := r.fn, r.scope
+	r.fn = &function{frame: frame, parent: outer, depth: outer.depth + 1}
+
+	// The params and body share the scope of the call.
+	names := []decleration{}
+	for _, param := range f.Params {
+		for _, name := range ParamNames(param) {
+			names = append(names, decleration{name: name})
+		}
+	}
+
+	r.enter(ScopeRef{Span: f.Span, Kind: FunctionScope}, append(names, declared(f.Body)...))
+
+	for _, param := range f.Params {
+
+		if param.Pattern != nil {
+			r.resolve(param.Default)
+			r.element(ast.PatternElement{Pattern: param.Pattern}, f.Span)
+			continue
+		}
+
+		r.element(ast.PatternElement{Name: param.Name, Default: param.Default}, f.Span)
+	}
+
+	r.block(f.Body)
+
+	r.fn, r.scope = outer, outerScope
+
+	r.declare(f.Name, f.Span)
+}
+
+func (r *resolver) namespace(ns ast.NamespaceDecleration) {
+
+	global := r.scope
+	for !global.global {
+		global = global.parent
+	}
+
+	for _, imp := range ns.Imports {
+
+		// i.e. using { split, join } from "strings";
+		if imp.Members != nil {
+
+			for _, member := range imp.Members {
+
+				if r.scope.definite[member] {
+					r.report(ns.Span, fmt.Sprintf("import of '%v' from %v conflicts with existing variable '%v'", member, namespace_name(imp.Name), member))
+				}
+
+				r.declare_import(member)
+			}
+
+			continue
+		}
+
+		alias := imp.Alias
+		if alias == "" {
+			alias = namespace_name(imp.Name)
+		}
+
+		// Using inside a function only happens when it's called, so only the top level is certain.
+		if global.fn != r.fn {
+			continue
+		}
+
+		if global.definite[alias] {
+			r.report(ns.Span, fmt.Sprintf("namespace '%v' conflicts with existing variable '%v'", alias, alias))
+		}
+
+		used := namespace_source(imp.Name)
+
+		// Namespaces from earlier REPL lines are only known by name.
+		existing, exists := global.namespaces[alias]
+		if exists && existing != used {
+			r.report(ns.Span, fmt.Sprintf("namespace '%v' is already used for %v", alias, namespace_name(existing)))
+		} else if name, isGlobal := r.globals.Namespaces[alias]; !exists && isGlobal && name != namespace_name(imp.Name) {
+			r.report(ns.Span, fmt.Sprintf("namespace '%v' is already used for %v", alias, name))
+		}
+
+		global.namespaces[alias] = used
+	}
+}
+
+// Declares a member brought into scope by a using directive, conflicts are reported as such.
+func (r *resolver) declare_import(member string) {
+
+	s := r.scope
+
+	if _, exists := s.constant[member]; !exists {
+		s.hoist(decleration{name: member, constant: true})
+	}
+
+	s.declared[member] = true
+	s.definite[member] = true
+}
+
+// The name a namespace is used under by default, modules are named after their file.
+func namespace_name(name string) string {
+
+	if strings.HasSuffix(name, ".gob") {
+		return strings.TrimSuffix(filepath.Base(name), ".gob")
+	}
+
+	return name
+}
+
+// What a using directive names, so two spellings of the same module path compare equal.
+func namespace_source(name string) string {
+
+	if strings.HasSuffix(name, ".gob") {
+		return filepath.Clean(name)
+	}
+
+	return name
+}
+
+// Adds the names of every namespace used within a body.
+func collect_namespaces(body []ast.Expression, namespaces map[string]bool) {
+
+	for _, stmt := range body {
+
+		switch s := stmt.(type) {
+		case ast.NamespaceDecleration:
+
+			for _, imp := range s.Imports {
+
+				if imp.Members != nil {
+					continue
+				}
+
+				if imp.Alias != "" {
+					namespaces[imp.Alias] = true
+				} else {
+					namespaces[namespace_name(imp.Name)] = true
+				}
+			}
+		case ast.FunctionDecleration:
+			collect_namespaces(s.Body, namespaces)
+		case ast.ExportStatement:
+			collect_namespaces([]ast.Expression{s.Decleration}, namespaces)
+		case ast.IfCondition:
+			collect_namespaces(s.Body, namespaces)
+			collect_namespaces(s.ElseBody, namespaces)
+		case ast.WhileLoop:
+			collect_namespaces(s.Body, namespaces)
+		case ast.ForLoop:
+			collect_namespaces(s.Body, namespaces)
+		case ast.ForInLoop:
+			collect_namespaces(s.Body, namespaces)
+		}
+	}
+}
+
+// Returns the names declared directly in a body, including within the bodies of if
+// statements and while loops, which share its scope.
+func declared(body []ast.Expression) []decleration {
+
+	names := []decleration{}
+
+	for _, stmt := range body {
+
+		switch s := stmt.(type) {
+		case ast.VariableDecleration:
+			names = append(names, decleration{name: s.Identifier, constant: s.Constant})
+		case ast.ArrayDecleration:
+			names = append(names, decleration{name: s.Identifier, constant: s.Constant})
+		case ast.MapDecleration:
+			names = append(names, decleration{name: s.Identifier, constant: s.Constant})
+		case ast.FunctionDecleration:
+			names = append(names, decleration{name: s.Name, constant: true})
+		case ast.DestructuringDecleration:
+			for _, name := range ElementNames(ast.PatternElement{Pattern: &s.Pattern}) {
+				names = append(names, decleration{name: name, constant: s.Constant})
+			}
+		case ast.ExportStatement:
+			names = append(names, declared([]ast.Expression{s.Decleration})...)
+		case ast.NamespaceDecleration:
+			for _, imp := range s.Imports {
+				for _, member := range imp.Members {
+					names = append(names, decleration{name: member, constant: true})
+				}
+			}
+		case ast.IfCondition:
+			names = append(names, declared(s.Body)...)
+			names = append(names, declared(s.ElseBody)...)
+		case ast.WhileLoop:
+			names = append(names, declared(s.Body)...)
+		}
+	}
+
+	return names
+}
+
+// Returns the names a pattern element binds.
+func ElementNames(element ast.PatternElement) []string {
+
+	if element.Pattern == nil {
+		return []string{element.Name}
+	}
+
+	names := []string{}
+	for _, el := range element.Pattern.Elements {
+		names = append(names, ElementNames(el)...)
+	}
+
+	return names
+}
+
+// Returns the names a param binds.
+func ParamNames(param ast.Parameter) []string {
+
+	if param.Pattern != nil {
+		return ElementNames(ast.PatternElement{Pattern: param.Pattern})
+	}
+
+	return []string{param.Name}
+}
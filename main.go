@@ -27,6 +27,52 @@ func main() {
 	reader := bufio.NewReader(os.Stdin)
 	args := os.Args
 
+	// Flags come before the file, i.e. 'goblin --vm --optimise file.gob'.
+	options := program.Options{Engine: program.TreeWalker}
+	dump := false
+
+	for len(args) > 1 && strings.HasPrefix(args[1], "--") {
+
+		switch args[1] {
+		case "--vm":
+			// Run on the bytecode VM rather than the tree-walker, saying so when it can't.
+			options.Engine = program.Bytecode
+			options.Warnings = os.Stderr
+		case "--optimise":
+			// Fold constants and drop dead code before running.
+			options.Optimise = true
+		case "--dump-ast":
+			// Print the optimised AST of the file rather than running it.
+			dump = true
+		default:
+			utils.Stdout(fmt.Sprintf("Error: unknown flag %v", args[1]), env.Stdout)
+			os.Exit(1)
+		}
+
+		args = append([]string{args[0]}, args[2:]...)
+	}
+
+	// Check mode, i.e. 'goblin check file.gob'.
+	if len(args) == 3 && args[1] == "check" {
+
+		source := args[2]
+
+		content, err := os.ReadFile(source)
+		if err != nil {
+			e := fmt.Sprintf("Error reading file: %v", err)
+			utils.Stdout(e, env.Stdout)
+			os.Exit(1)
+		}
+
+		err = program.Check(string(content))
+		if err != nil {
+			utils.Stdout(err.Error()+"\n", env.Stdout)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	// Execute file mode.
 	if len(args) == 2 {
 
@@ -58,10 +104,22 @@ func main() {
 			return
 		}
 
+		if dump {
+
+			tree, err := program.DumpAST(string(content))
+			if err != nil {
+				utils.Stdout(err.Error()+"\n", env.Stdout)
+				os.Exit(1)
+			}
+
+			utils.Stdout(tree, env.Stdout)
+			return
+		}
+
 		// Run the program.
-		result, err := program.Run(string(content), env)
+		result, err := program.RunWith(string(content), env, options)
 		if err != nil {
-			utils.Stdout(err.Error(), env.Stdout)
+			printError(err, env)
 		} else {
 			// Only really want to print to console if its a statement that needs returning.
 			if result != nil {
@@ -104,9 +162,9 @@ func main() {
 			}
 
 			// Run the program.
-			result, err := program.Run(input, env)
+			result, err := program.RunWith(input, env, options)
 			if err != nil {
-				utils.Stdout(err.Error(), env.Stdout)
+				printError(err, env)
 			} else {
 				// Only really want to print to console if its a statement that needs returning.
 				if result != nil {
@@ -117,3 +175,14 @@ func main() {
 		}
 	}
 }
+
+// Prints an error raised by a program, followed by the stack trace of the calls it was
+// raised in.
+func printError(err error, env runtime.Environment) {
+
+	utils.Stdout(err.Error(), env.Stdout)
+
+	if trace := runtime.StackTrace(err); len(trace) > 0 {
+		utils.Stdout("\n"+runtime.FormatStackTrace(trace), env.Stdout)
+	}
+}
@@ -1,34 +1,80 @@
 package program
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 
+	"goblin.org/main/frontend/ast"
+	"goblin.org/main/frontend/checker"
 	"goblin.org/main/frontend/lexer"
 	"goblin.org/main/frontend/parser"
+	"goblin.org/main/frontend/resolver"
 	runtime "goblin.org/main/runtime"
 	"goblin.org/main/utils"
 )
 
+// Modules loaded with 'using' go through the same stages as the program that uses them.
+func init() {
+	runtime.Compile = func(source string) (ast.Program, map[int]string, error) {
+
+		// Modules run in a global scope of their own, holding just the builtins.
+		program, audit, _, err := compile(source, builtins())
+
+		return program, audit, err
+	}
+}
+
+// Which engine runs a program once it has been parsed and checked.
+type Engine int
+
+const (
+	TreeWalker Engine = iota // Evaluates the AST directly, the reference implementation.
+	Bytecode                 // Compiles the AST to bytecode first, then runs it on a VM.
+)
+
+// How a program is run once it has been parsed and checked.
+type Options struct {
+	Engine   Engine
+	Optimise bool      // Fold constants and drop dead code before running, see runtime.Optimise.
+	Warnings io.Writer // Told when a program falls back to the tree-walker, nil to stay quiet.
+}
+
 // Where the source goes to be lexed, parsed, interpreted, and returned.
 func Run(input string, env runtime.Environment) (runtime.RuntimeValue, error) {
+	return RunWith(input, env, Options{Engine: TreeWalker})
+}
 
-	// Stage 1. Lex the input.
-	tokens, audit := lexer.Tokenize(input)
-
-	// fmt.Printf("Audit: %v\nTokens: %v\n", audit, tokens)
+// Runs the source with the given options. Programs the bytecode compiler can't handle yet are
+// run by the tree-walker instead, with a warning saying why written to options.Warnings.
+// Modules they use are always loaded by the tree-walker, and never optimised.
+func RunWith(input string, env runtime.Environment, options Options) (runtime.RuntimeValue, error) {
 
-	// Stage 2. Produce the Abstract Syntax Tree.
-	program, err := parser.ProduceAST(tokens, audit)
+	// Stage 1 & 2. Lex, parse, type check and resolve the input.
+	program, audit, res, err := compile(input, globals(env))
 	if err != nil {
-		return nil, fmt.Errorf("parse error: %v", err.Error())
+		return nil, err
 	}
 
 	// fmt.Printf("Program: %v\n", program)
 
-	// Stage 3. Interprete the AST.
-	evaluation, err := runtime.Evaluate(program, env)
+	if options.Optimise {
+		program = runtime.Optimise(program)
+	}
+
+	// Stage 3. Interprete the AST, runtime errors point back at the source.
+	env.Audit = audit
+	evaluation, err := execute(program, audit, res, env, options)
 	if err != nil {
-		return nil, fmt.Errorf("interpreter error: %v", err.Error())
+
+		// Modules that didn't compile point at their own source, not the using directive.
+		var compile runtime.ModuleCompileError
+		if errors.As(err, &compile) {
+			return nil, compile
+		}
+
+		return nil, runtime.UncaughtError{Origin: "interpreter error: ", Err: err}
 	}
 
 	// fmt.Printf("Eval: %v\n\n", evaluation)
@@ -42,3 +88,152 @@ func Run(input string, env runtime.Environment) (runtime.RuntimeValue, error) {
 
 	return nil, nil
 }
+
+// Runs a checked program on the engine the options ask for.
+func execute(program ast.Program, audit map[int]string, res *resolver.Resolution, env runtime.Environment, options Options) (runtime.RuntimeValue, error) {
+
+	if options.Engine == Bytecode {
+
+		code, err := runtime.CompileBytecode(program, audit, res)
+		if err == nil {
+			return runtime.Execute(code, env)
+		}
+
+		if _, unsupported := err.(runtime.UnsupportedError); !unsupported {
+			return nil, err
+		}
+
+		if options.Warnings != nil {
+			fmt.Fprintf(options.Warnings, "warning: %v, running on the tree-walker instead\n", err)
+		}
+	}
+
+	return runtime.Interpret(program, env)
+}
+
+// Lexes, parses, type checks and resolves the input without running it, as if it were run
+// in a fresh global scope.
+func Check(input string) error {
+
+	_, _, _, err := compile(input, builtins())
+
+	return err
+}
+
+// Lexes, parses, checks and optimises the input without running it, returning the optimised
+// AST as an indented tree.
+func DumpAST(input string) (string, error) {
+
+	program, _, _, err := compile(input, builtins())
+	if err != nil {
+		return "", err
+	}
+
+	return ast.Dump(runtime.Optimise(program)), nil
+}
+
+// Lexes, parses, type checks and resolves the input, ready to be evaluated in a global scope
+// already holding globals. Also returns the audit of the source, so runtime errors can point
+// back at it.
+func compile(input string, globals resolver.Globals) (ast.Program, map[int]string, *resolver.Resolution, error) {
+
+	tokens, audit, err := lexer.Tokenize(input)
+	if err != nil {
+		return ast.Program{}, nil, nil, fmt.Errorf("parse error: %v", err.Error())
+	}
+
+	program, err := parser.ProduceAST(tokens, audit)
+	if err != nil {
+		return ast.Program{}, nil, nil, parseError(err)
+	}
+
+	err = typeCheck(program, audit)
+	if err != nil {
+		return ast.Program{}, nil, nil, err
+	}
+
+	res, err := resolve(program, audit, globals)
+	if err != nil {
+		return ast.Program{}, nil, nil, err
+	}
+
+	return program, audit, res, nil
+}
+
+// Prefixes each syntax error found by the parser, one after the other.
+func parseError(err error) error {
+
+	diagnostics, isDiagnostics := err.(parser.Diagnostics)
+	if !isDiagnostics {
+		return fmt.Errorf("parse error: %v", err.Error())
+	}
+
+	messages := make([]string, 0)
+	for _, d := range diagnostics {
+		messages = append(messages, "parse error: "+d.Error())
+	}
+
+	return fmt.Errorf("%v", strings.Join(messages, "\n"))
+}
+
+// Runs the static type checker, combining every type error found into one.
+func typeCheck(program ast.Program, audit map[int]string) error {
+
+	errs := checker.Check(program, audit)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0)
+	for _, e := range errs {
+		messages = append(messages, checker.Origin+e.Error())
+	}
+
+	return fmt.Errorf("%v", strings.Join(messages, "\n"))
+}
+
+// Runs the scope resolver, combining every resolve error found into one.
+func resolve(program ast.Program, audit map[int]string, globals resolver.Globals) (*resolver.Resolution, error) {
+
+	res, errs := resolver.Resolve(program, audit, globals)
+	if len(errs) == 0 {
+		return res, nil
+	}
+
+	messages := make([]string, 0)
+	for _, e := range errs {
+		messages = append(messages, resolver.Origin+e.Error())
+	}
+
+	return nil, fmt.Errorf("%v", strings.Join(messages, "\n"))
+}
+
+// Returns the names already declared in the global scope of env, i.e. by earlier REPL lines.
+func globals(env runtime.Environment) resolver.Globals {
+
+	variables := map[string]bool{}
+	for name := range env.Variables {
+		variables[name] = env.Constants[name]
+	}
+
+	namespaces := map[string]string{}
+	for name, namespace := range env.Namespaces {
+		namespaces[name] = namespace.Name
+	}
+
+	return resolver.Globals{Variables: variables, Namespaces: namespaces}
+}
+
+// Returns the names declared in a fresh global scope, just the builtins.
+func builtins() resolver.Globals {
+
+	env := runtime.Environment{
+		Variables:  map[string]runtime.RuntimeValue{},
+		Constants:  map[string]bool{},
+		Namespaces: map[string]runtime.Namespace{},
+	}
+
+	env.Setup()
+
+	return globals(env)
+}
@@ -0,0 +1,98 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Returns true if the Runtime value is an int, of either size.
+func IsInteger(r RuntimeValue) bool {
+
+	switch r.(type) {
+	case NumberValue, BigIntValue:
+		return true
+	}
+
+	return false
+}
+
+// Converts an int of either size into a big.Int. The result is always a new value, so it
+// is safe to modify.
+func ToBigInt(r RuntimeValue) *big.Int {
+
+	switch n := r.(type) {
+	case NumberValue:
+		return big.NewInt(int64(n.Value))
+	case BigIntValue:
+		return new(big.Int).Set(n.Value)
+	}
+
+	return new(big.Int)
+}
+
+// Evaluates a numeric expression where at least one side doesn't fit in an int, or the
+// result would overflow one.
+func eval_big_expression(lhs *big.Int, rhs *big.Int, opp string) (RuntimeValue, error) {
+
+	result := new(big.Int)
+
+	switch opp {
+	case "+":
+		result.Add(lhs, rhs)
+	case "-":
+		result.Sub(lhs, rhs)
+	case "*":
+		result.Mul(lhs, rhs)
+	case "/", "~/", "%":
+
+		if rhs.Sign() == 0 {
+			if opp == "%" {
+				return nil, fmt.Errorf("modulo by zero")
+			}
+			return nil, fmt.Errorf("division by zero")
+		}
+
+		remainder := new(big.Int)
+		result.QuoRem(lhs, rhs, remainder)
+
+		if opp == "%" {
+			result = remainder
+		} else if opp == "~/" && remainder.Sign() != 0 && (remainder.Sign() < 0) != (rhs.Sign() < 0) {
+			// Floor division rounds towards negative infinity, rather than towards zero.
+			result.Sub(result, big.NewInt(1))
+		}
+	case "**":
+
+		if rhs.Sign() < 0 {
+			return nil, fmt.Errorf("negative exponent %v for int", rhs)
+		}
+
+		result.Exp(lhs, rhs, nil)
+	case "&":
+		result.And(lhs, rhs)
+	case "|":
+		result.Or(lhs, rhs)
+	case "^":
+		result.Xor(lhs, rhs)
+	case "<<", ">>":
+
+		if rhs.Sign() < 0 {
+			return nil, fmt.Errorf("negative shift count %v", rhs)
+		}
+
+		if !rhs.IsInt64() || rhs.Int64() > math.MaxUint32 {
+			return nil, fmt.Errorf("shift count %v too large", rhs)
+		}
+
+		if opp == "<<" {
+			result.Lsh(lhs, uint(rhs.Int64()))
+		} else {
+			result.Rsh(lhs, uint(rhs.Int64()))
+		}
+	default:
+		return nil, fmt.Errorf("invalid binop provided: %v", opp)
+	}
+
+	return MK_INTEGER(result), nil
+}
@@ -0,0 +1,242 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"goblin.org/main/frontend/ast"
+)
+
+// A single bytecode instruction. Each is followed in the code by its operands, see operands.
+type Opcode int32
+
+const (
+	OpConstant     Opcode = iota // k: pushes constant k.
+	OpCopyConstant               // k: pushes a copy of constant k, for bytes and big ints.
+	OpNull                       // Pushes null.
+	OpNil                        // Pushes nothing at all, the value of a using directive.
+	OpPop                        // Drops the top of the stack.
+	OpOrNull                     // Replaces nothing at all on top of the stack with null.
+
+	OpGetLocal      // slot, mode: pushes a local, see the lookup modes.
+	OpGetUpvalue    // upvalue, mode: pushes a variable of an enclosing scope.
+	OpGetGlobal     // name, mode: pushes a global.
+	OpSetLocal      // slot: assigns the top of the stack to a local.
+	OpSetUpvalue    // upvalue: assigns the top of the stack to a variable of an enclosing scope.
+	OpSetGlobal     // name: assigns the top of the stack to a global.
+	OpDeclareLocal  // slot, const: declares a local holding the top of the stack.
+	OpDeclareGlobal // name, const: declares a global holding the top of the stack.
+	OpBind          // binding: declares the names bound by a pattern from the top of the stack.
+	OpExport        // name: exports a global, when there is anyone to export to.
+
+	OpArray    // n: pops n values into a new array.
+	OpMap      // n: pops n key and value pairs into a new map.
+	OpObject   // n: pops n key and value pairs into a new object.
+	OpIndex    // name: pops an index and what is indexed, pushing the element.
+	OpSetIndex // name: pops a value, an index and what is indexed, assigning the element.
+	OpSlice    // name, flags: pops what is sliced and any bounds, pushing the slice.
+	OpSetSlice // name, flags: pops a value, what is sliced and any bounds, replacing the slice.
+
+	OpBinary         // operator: pops two operands, pushing the result.
+	OpUnary          // operator: pops an operand, pushing the result.
+	OpCheckShorthand // operator: checks the variable a shorthand operator is used on holds an int.
+	OpShorthand      // operator: pops the current value and the right, pushing the new value.
+
+	OpJump              // target
+	OpJumpUnlessIf      // operator, target: pops two operands, jumps unless the if condition holds.
+	OpJumpUnlessWhile   // operator, target: pops two operands, jumps unless the while condition holds.
+	OpJumpUnlessFor     // operator, target: pops two operands, jumps unless the for condition holds.
+	OpJumpUnlessBool    // target: pops a condition written as a lone variable, jumps unless true.
+	OpJumpUnlessTernary // target: pops the condition of a ternary, jumps unless true.
+
+	OpAppend       // Pops a value onto the end of the args array below it.
+	OpAppendSpread // Pops an array, adding its elements to the end of the args array below it.
+	OpCall         // argc, site: pops the args and the function, pushing the result.
+	OpDefer        // argc, site: pops the args and the function, calling it once the function returns.
+	OpSpawn        // argc, site: pops the args and the function, calling it as a new task.
+	OpMember       // object, property, error: pops the object, pushing the member.
+	OpClosure      // function: pushes a new closure over the current scope.
+	OpBindParams   // binding: binds the args of the call to its params.
+	OpReturn       // Pops the value to return from the function.
+	OpUnwind       // Pops the value of a 'return' outside of any function, which is an error.
+	OpEndThunk     // Pops the value of a default, handing it back to whoever asked for it.
+
+	OpNamespace    // name: resolves a namespace, pushing it.
+	OpAddNamespace // alias: pops a namespace, adding it to the global scope.
+	OpImportLocal  // member, slot: imports a member of the namespace on top of the stack.
+	OpImportGlobal // member: imports a member of the namespace on top of the stack.
+
+	OpSchedule   // Gives other tasks a turn every so often, once per loop iteration.
+	OpResetScope // base, count: clears the slots of a scope being entered again.
+	OpIterStart  // Pops a value, starting to iterate over it.
+	OpIterNext   // target: pushes the next value of the innermost iteration, jumps once there are none.
+	OpIterEnd    // Stops the innermost iteration.
+	OpFail       // error: raises a compile time known error.
+)
+
+// How a variable is read, the tree-walker reads them in two ways.
+const (
+	modeValue  = iota // As a value, names of namespaces give the namespace.
+	modeLookup        // Just the variable, namespaces give nothing at all.
+)
+
+// Which bounds a slice instruction pops.
+const (
+	sliceStart = 1 << iota
+	sliceEnd
+)
+
+// Set in place of argc when the args were collected into an array, because they were spread.
+const spreadArgs = -1
+
+var opcodeNames = map[Opcode]string{
+	OpConstant: "CONSTANT", OpCopyConstant: "COPY_CONSTANT", OpNull: "NULL", OpNil: "NIL", OpPop: "POP", OpOrNull: "OR_NULL",
+	OpGetLocal: "GET_LOCAL", OpGetUpvalue: "GET_UPVALUE", OpGetGlobal: "GET_GLOBAL",
+	OpSetLocal: "SET_LOCAL", OpSetUpvalue: "SET_UPVALUE", OpSetGlobal: "SET_GLOBAL",
+	OpDeclareLocal: "DECLARE_LOCAL", OpDeclareGlobal: "DECLARE_GLOBAL", OpBind: "BIND", OpExport: "EXPORT",
+	OpArray: "ARRAY", OpMap: "MAP", OpObject: "OBJECT",
+	OpIndex: "INDEX", OpSetIndex: "SET_INDEX", OpSlice: "SLICE", OpSetSlice: "SET_SLICE",
+	OpBinary: "BINARY", OpUnary: "UNARY", OpCheckShorthand: "CHECK_SHORTHAND", OpShorthand: "SHORTHAND",
+	OpJump: "JUMP", OpJumpUnlessIf: "JUMP_UNLESS_IF", OpJumpUnlessWhile: "JUMP_UNLESS_WHILE",
+	OpJumpUnlessFor: "JUMP_UNLESS_FOR", OpJumpUnlessBool: "JUMP_UNLESS_BOOL", OpJumpUnlessTernary: "JUMP_UNLESS_TERNARY",
+	OpAppend: "APPEND", OpAppendSpread: "APPEND_SPREAD", OpCall: "CALL", OpDefer: "DEFER", OpSpawn: "SPAWN",
+	OpMember: "MEMBER", OpClosure: "CLOSURE", OpBindParams: "BIND_PARAMS",
+	OpReturn: "RETURN", OpUnwind: "UNWIND", OpEndThunk: "END_THUNK",
+	OpNamespace: "NAMESPACE", OpAddNamespace: "ADD_NAMESPACE", OpImportLocal: "IMPORT_LOCAL", OpImportGlobal: "IMPORT_GLOBAL",
+	OpSchedule: "SCHEDULE", OpResetScope: "RESET_SCOPE", OpIterStart: "ITER_START", OpIterNext: "ITER_NEXT",
+	OpIterEnd: "ITER_END", OpFail: "FAIL",
+}
+
+// How many operands follow each instruction.
+var operands = map[Opcode]int{
+	OpConstant: 1, OpCopyConstant: 1,
+	OpGetLocal: 2, OpGetUpvalue: 2, OpGetGlobal: 2,
+	OpSetLocal: 1, OpSetUpvalue: 1, OpSetGlobal: 1,
+	OpDeclareLocal: 2, OpDeclareGlobal: 2, OpBind: 1, OpExport: 1,
+	OpArray: 1, OpMap: 1, OpObject: 1,
+	OpIndex: 1, OpSetIndex: 1, OpSlice: 2, OpSetSlice: 2,
+	OpBinary: 1, OpUnary: 1, OpCheckShorthand: 1, OpShorthand: 1,
+	OpJump: 1, OpJumpUnlessIf: 2, OpJumpUnlessWhile: 2, OpJumpUnlessFor: 2, OpJumpUnlessBool: 1, OpJumpUnlessTernary: 1,
+	OpCall: 2, OpDefer: 2, OpSpawn: 2, OpMember: 3, OpClosure: 1, OpBindParams: 1,
+	OpNamespace: 1, OpAddNamespace: 1, OpImportLocal: 2, OpImportGlobal: 1,
+	OpResetScope: 2, OpIterNext: 1, OpFail: 1,
+}
+
+// A function, or the top level of a program, compiled to bytecode. Everything an
+// instruction refers to is kept in one of the pools, by index.
+type Bytecode struct {
+	Name   string
+	Params []ast.Parameter
+	Code   []int32
+
+	Constants []RuntimeValue
+	Names     []string    // Globals, operators, namespaces and members, by name.
+	Functions []*Bytecode // The functions declared within this one.
+	Bindings  []binding   // The patterns names are bound by.
+	Sites     []ast.Span  // Where each call was made from.
+	Errors    []error     // Errors known to be raised before running, i.e. a 'defer' outside a function.
+
+	Slots     int      // How many locals a call needs.
+	SlotNames []string // The name each slot was declared under.
+	Upvalues  []upvalueRef
+
+	Audit map[int]string // The lines of the source it was compiled from, to point at runtime errors.
+	spans []spanRun
+}
+
+// Where a name is bound to, resolved at compile time.
+type target struct {
+	kind  int // One of the target kinds.
+	index int // The slot, upvalue or name.
+}
+
+const (
+	targetLocal = iota
+	targetUpvalue
+	targetGlobal
+)
+
+// Binds the names of a pattern, the params of a function or the variable of a for ... in loop.
+type binding struct {
+	Element  ast.PatternElement
+	Targets  map[string]target // Where each name bound is declared, always a local or a global.
+	Defaults map[ast.Span]int  // Where the code for each default value starts, by where it was written.
+	Constant bool
+}
+
+// A variable of an enclosing function captured by a closure, either a local of the function
+// directly enclosing it or one of that function's own upvalues.
+type upvalueRef struct {
+	Local bool
+	Index int
+	Name  string
+}
+
+// From instruction pc onwards, the instructions were compiled from the node at span.
+type spanRun struct {
+	pc   int
+	span ast.Span
+}
+
+// Returns where the instruction at pc was compiled from, or a zero span.
+func (b *Bytecode) spanAt(pc int) ast.Span {
+
+	i := sort.Search(len(b.spans), func(i int) bool { return b.spans[i].pc > pc })
+	if i == 0 {
+		return ast.Span{}
+	}
+
+	return b.spans[i-1].span
+}
+
+// Renders the instructions one per line, followed by those of each function declared within.
+func (b *Bytecode) Disassemble() string {
+
+	var builder strings.Builder
+
+	name := b.Name
+	if name == "" {
+		name = "<program>"
+	}
+
+	fmt.Fprintf(&builder, "== %v (%v slots) ==\n", name, b.Slots)
+
+	for pc := 0; pc < len(b.Code); {
+
+		op := Opcode(b.Code[pc])
+		args := b.Code[pc+1 : pc+1+operands[op]]
+
+		fmt.Fprintf(&builder, "%04d %-20v", pc, opcodeNames[op])
+		for _, arg := range args {
+			fmt.Fprintf(&builder, " %v", arg)
+		}
+
+		switch op {
+		case OpConstant, OpCopyConstant:
+			fmt.Fprintf(&builder, "  ; %v", printHelper(b.Constants[args[0]]))
+		case OpGetGlobal, OpSetGlobal, OpDeclareGlobal, OpExport, OpIndex, OpSetIndex, OpSlice, OpSetSlice,
+			OpBinary, OpUnary, OpCheckShorthand, OpShorthand, OpJumpUnlessIf, OpJumpUnlessWhile, OpJumpUnlessFor, OpNamespace, OpAddNamespace, OpImportLocal, OpImportGlobal:
+			fmt.Fprintf(&builder, "  ; %v", b.Names[args[0]])
+		case OpGetLocal, OpSetLocal, OpDeclareLocal:
+			fmt.Fprintf(&builder, "  ; %v", b.SlotNames[args[0]])
+		case OpGetUpvalue, OpSetUpvalue:
+			fmt.Fprintf(&builder, "  ; %v", b.Upvalues[args[0]].Name)
+		case OpMember:
+			fmt.Fprintf(&builder, "  ; %v.%v", b.Names[args[0]], b.Names[args[1]])
+		case OpClosure:
+			fmt.Fprintf(&builder, "  ; %v", b.Functions[args[0]].Name)
+		case OpFail:
+			fmt.Fprintf(&builder, "  ; %v", b.Errors[args[0]])
+		}
+
+		builder.WriteString("\n")
+		pc += 1 + len(args)
+	}
+
+	for _, fn := range b.Functions {
+		builder.WriteString("\n" + fn.Disassemble())
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,297 @@
+package runtime
+
+import (
+	"fmt"
+)
+
+// A channel between tasks, made with chan(n). Sends block until there is room in the buffer,
+// or, for an unbuffered channel, until another task receives.
+type ChannelValue struct {
+	Type  ValueType
+	Value *channelState
+}
+
+func (c ChannelValue) runtime() {}
+
+// The state shared by every copy of a channel value. Only read and written while holding the
+// lock of its scheduler, which tasks wait on for it.
+type channelState struct {
+	scheduler *Scheduler
+	size      int
+	buffer    []RuntimeValue
+	senders   []*pendingSend // Waiting for room in the buffer, or a receiver if unbuffered.
+	closed    bool
+}
+
+// A value a task is waiting to send.
+type pendingSend struct {
+	value RuntimeValue
+	taken bool
+}
+
+func MK_CHANNEL(size int, scheduler *Scheduler) ChannelValue {
+
+	return ChannelValue{
+		Type:  Chan,
+		Value: &channelState{scheduler: scheduler, size: size},
+	}
+}
+
+// chan, returns a new channel, buffering up to size values
+// chan(), chan(size int) chan
+var chanFn FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs > 1 {
+		return nil, fmt.Errorf("unexpected number of args for chan, expected 0 or 1 got %v", numArgs)
+	}
+
+	size := 0
+
+	if numArgs == 1 {
+
+		n, isInt := args[0].(NumberValue)
+		if !isInt || n.Value < 0 {
+			return nil, fmt.Errorf("chan expectes arg1 to be a positive int, %v given", printHelper(args[0]))
+		}
+
+		size = n.Value
+	}
+
+	return MK_CHANNEL(size, env.Scheduler), nil
+}
+
+// send, sends a value on a channel, waiting until it can be sent
+// send(ch chan, val any)
+var sendFn FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	c, err := channelArg("send", args, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.send(args[1]); err != nil {
+		return nil, err
+	}
+
+	return MK_NULL(), nil
+}
+
+// receive, waits for a value from a channel. Returns null once the channel is closed and empty
+// receive(ch chan) any
+var receiveFn FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	c, err := channelArg("receive", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	value, _, err := c.receive()
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// close, closes a channel, tasks waiting to receive from it are woken up
+// close(ch chan)
+var closeFn FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	c, err := channelArg("close", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.closed {
+		return nil, fmt.Errorf("close of closed channel")
+	}
+
+	// Tasks waiting to send are woken up too, with an error.
+	c.closed = true
+	c.senders = nil
+	c.scheduler.notify()
+
+	return MK_NULL(), nil
+}
+
+// select, waits for a value from whichever channel is ready first, returning [index, value].
+// The value is null if that channel was closed. When wait is false and no channel is ready,
+// returns [-1, null] straight away.
+// select(channels array), select(channels array, wait bool) array
+var selectFn FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 1 && numArgs != 2 {
+		return nil, fmt.Errorf("unexpected number of args for select, expected 1 or 2 got %v", numArgs)
+	}
+
+	arr, isArr := args[0].(ArrayValue)
+	if !isArr {
+		return nil, fmt.Errorf("select expectes arg1 to be an array of channels, %v given", TypeName(args[0]))
+	}
+
+	wait := true
+	if numArgs == 2 {
+
+		b, isBool := args[1].(BooleanValue)
+		if !isBool {
+			return nil, fmt.Errorf("select expectes arg2 to be of type bool, %v given", TypeName(args[1]))
+		}
+
+		wait = b.Value
+	}
+
+	channels := make([]*channelState, 0, len(*arr.Value))
+	for _, el := range *arr.Value {
+
+		c, isChan := el.(ChannelValue)
+		if !isChan {
+			return nil, fmt.Errorf("select expectes arg1 to be an array of channels, found %v", TypeName(el))
+		}
+
+		channels = append(channels, c.Value)
+	}
+
+	chosen := -1
+	var value RuntimeValue = MK_NULL()
+
+	ready := func() bool {
+
+		for i, c := range channels {
+			if received, _, taken := c.take(); taken {
+				chosen, value = i, received
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if !wait {
+
+		// Nothing was ready.
+		if !ready() {
+			return MK_ARRAY([]RuntimeValue{MK_NUMBER(-1), MK_NULL()}), nil
+		}
+	} else if err := env.Scheduler.wait(ready); err != nil {
+		return nil, err
+	}
+
+	return MK_ARRAY([]RuntimeValue{MK_NUMBER(chosen), value}), nil
+}
+
+// Sends a value, waiting for room in the buffer, or for a receiver if unbuffered.
+func (c *channelState) send(value RuntimeValue) error {
+
+	if c.closed {
+		return fmt.Errorf("send on closed channel")
+	}
+
+	if len(c.buffer) < c.size {
+		c.buffer = append(c.buffer, value)
+		c.scheduler.notify()
+		return nil
+	}
+
+	pending := &pendingSend{value: value}
+	c.senders = append(c.senders, pending)
+	c.scheduler.notify()
+
+	// The channel may be closed while we wait.
+	err := c.scheduler.wait(func() bool { return pending.taken || c.closed })
+	if err == nil && !pending.taken {
+		err = fmt.Errorf("send on closed channel")
+	}
+
+	if err != nil {
+		for i, p := range c.senders {
+			if p == pending {
+				c.senders = append(c.senders[:i], c.senders[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return err
+}
+
+// Waits for a value, returns false once the channel is closed and empty.
+func (c *channelState) receive() (RuntimeValue, bool, error) {
+
+	var value RuntimeValue
+	var ok bool
+
+	err := c.scheduler.wait(func() bool {
+
+		var taken bool
+		value, ok, taken = c.take()
+
+		return taken
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return value, ok, nil
+}
+
+// Takes the next value without waiting, taken is false if there isn't one yet. Once the
+// channel is closed and empty, returns null and false.
+func (c *channelState) take() (value RuntimeValue, ok bool, taken bool) {
+
+	switch {
+	case len(c.buffer) > 0:
+		value, c.buffer = c.buffer[0], c.buffer[1:]
+	case len(c.senders) > 0:
+		value = c.senders[0].value
+		c.senders[0].taken = true
+		c.senders = c.senders[1:]
+	case c.closed:
+		return MK_NULL(), false, true
+	default:
+		return nil, false, false
+	}
+
+	// Make room for the next sender.
+	if len(c.senders) > 0 && len(c.buffer) < c.size {
+		c.buffer = append(c.buffer, c.senders[0].value)
+		c.senders[0].taken = true
+		c.senders = c.senders[1:]
+	}
+
+	c.scheduler.notify()
+
+	return value, true, true
+}
+
+// Returns an iterator receiving from a channel until it is closed.
+func channelValues(c ChannelValue) IteratorValue {
+
+	return MK_ITERATOR(func() (RuntimeValue, bool, error) {
+
+		value, ok, err := c.Value.receive()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+
+		return value, true, nil
+	}, nil)
+}
+
+// Checks the args of a channel function, returning the channel they start with.
+func channelArg(name string, args []RuntimeValue, expected int) (*channelState, error) {
+
+	numArgs := len(args)
+	if numArgs != expected {
+		return nil, fmt.Errorf("unexpected number of args for %v, expected %v got %v", name, expected, numArgs)
+	}
+
+	c, isChan := args[0].(ChannelValue)
+	if !isChan {
+		return nil, fmt.Errorf("%v expectes arg1 to be of type chan, %v given", name, TypeName(args[0]))
+	}
+
+	return c.Value, nil
+}
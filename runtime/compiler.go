@@ -0,0 +1,1192 @@
+package runtime
+
+import (
+	"fmt"
+
+	"goblin.org/main/frontend/ast"
+	"goblin.org/main/frontend/resolver"
+)
+
+// Raised when a program uses something the bytecode compiler doesn't handle yet. Such
+// programs can still be run by the tree-walker.
+type UnsupportedError struct {
+	Feature string
+	Span    ast.Span
+}
+
+func (e UnsupportedError) Error() string {
+	return fmt.Sprintf("bytecode compiler does not support %v yet, on line %v col %v", e.Feature, e.Span.Start.Line, e.Span.Start.Col)
+}
+
+// Compiles a program to bytecode, ready to be run with Execute. The audit is the source the
+// program was parsed from, used to point runtime errors back at it.
+//
+// Variables are laid out by the resolver. Those declared by a function, or by the body of a
+// loop that gets a scope of its own, are held in a slot of the frame of each call. Those of
+// the top level of the program are globals, looked up by name in the environment the program
+// is run in, the same as the tree-walker.
+func CompileBytecode(program ast.Program, audit map[int]string, res *resolver.Resolution) (*Bytecode, error) {
+
+	c := new_compiler(&Bytecode{Audit: audit}, nil, res)
+	c.frame(res.Program)
+
+	// A program evaluates to the value of its last statement.
+	if err := c.statements(program.Body, OpNil); err != nil {
+		return nil, err
+	}
+
+	c.emit(OpReturn)
+
+	return c.code, nil
+}
+
+// Compiles a single function, or the top level of a program.
+type compiler struct {
+	code      *Bytecode
+	enclosing *compiler            // The compiler of the function this one is declared in.
+	scope     *scope               // The innermost scope, nil at the top level of a program.
+	res       *resolver.Resolution // Where each variable of the program lives.
+	function  bool                 // Compiling the body of a function, rather than the top level of a program.
+	span      ast.Span             // Where the node being compiled was written.
+
+	names     map[string]int
+	constants map[any]int
+}
+
+// A scope with slots of its own, either the body of a function or of a for loop. Every
+// name declared anywhere in it has a slot, including those declared in the bodies of if
+// statements and while loops, which share the scope they are in.
+type scope struct {
+	slots  map[string]int
+	base   int // The first slot of the scope, the rest follow on.
+	count  int
+	parent *scope
+}
+
+func new_compiler(code *Bytecode, enclosing *compiler, res *resolver.Resolution) *compiler {
+
+	return &compiler{
+		code:      code,
+		enclosing: enclosing,
+		res:       res,
+		names:     map[string]int{},
+		constants: map[any]int{},
+	}
+}
+
+// Adds an instruction, returning where it starts.
+func (c *compiler) emit(op Opcode, args ...int) int {
+
+	pc := len(c.code.Code)
+
+	// Only the instructions starting a new run of nodes need to record where they came from.
+	if n := len(c.code.spans); n == 0 || c.code.spans[n-1].span != c.span {
+		c.code.spans = append(c.code.spans, spanRun{pc: pc, span: c.span})
+	}
+
+	c.code.Code = append(c.code.Code, int32(op))
+	for _, arg := range args {
+		c.code.Code = append(c.code.Code, int32(arg))
+	}
+
+	return pc
+}
+
+// Adds a jump, returning where its target is so it can be patched once known.
+func (c *compiler) emit_jump(op Opcode, args ...int) int {
+	return c.emit(op, append(args, 0)...) + len(args) + 1
+}
+
+// Points the jump with its target at operand to the next instruction.
+func (c *compiler) patch(operand int) {
+	c.code.Code[operand] = int32(len(c.code.Code))
+}
+
+// Returns the index of a name in the names pool, adding it if needed.
+func (c *compiler) name(name string) int {
+
+	if i, exists := c.names[name]; exists {
+		return i
+	}
+
+	c.code.Names = append(c.code.Names, name)
+	c.names[name] = len(c.code.Names) - 1
+
+	return c.names[name]
+}
+
+// Adds an instruction pushing the value, ints and strings are only added to the pool once.
+func (c *compiler) emit_constant(value RuntimeValue) {
+
+	var key any
+	switch v := value.(type) {
+	case NumberValue:
+		key = v.Value
+	case StringValue:
+		key = "s" + v.Value
+	}
+
+	i, exists := c.constants[key]
+	if key == nil || !exists {
+
+		c.code.Constants = append(c.code.Constants, value)
+		i = len(c.code.Constants) - 1
+
+		if key != nil {
+			c.constants[key] = i
+		}
+	}
+
+	c.emit(OpConstant, i)
+}
+
+// Adds an instruction raising err.
+func (c *compiler) emit_error(err error) {
+
+	c.code.Errors = append(c.code.Errors, err)
+	c.emit(OpFail, len(c.code.Errors)-1)
+}
+
+// Adds a call site to the pool.
+func (c *compiler) site(span ast.Span) int {
+
+	c.code.Sites = append(c.code.Sites, span)
+
+	return len(c.code.Sites) - 1
+}
+
+// Gives the code the slots of a frame laid out by the resolver.
+func (c *compiler) frame(f *resolver.Frame) {
+
+	c.code.Slots = len(f.Slots)
+	c.code.SlotNames = append([]string{}, f.Slots...)
+}
+
+// Enters the scope the resolver laid out for a node.
+func (c *compiler) enter(span ast.Span, kind resolver.ScopeKind) (*scope, error) {
+
+	laid, exists := c.res.Scopes[resolver.ScopeRef{Span: span, Kind: kind}]
+	if !exists {
+		return nil, UnsupportedError{Feature: "this scope", Span: span}
+	}
+
+	c.scope = &scope{slots: laid.Slots, base: laid.Base, count: laid.Count, parent: c.scope}
+
+	return c.scope, nil
+}
+
+func (c *compiler) leave() {
+	c.scope = c.scope.parent
+}
+
+// Returns where the resolver found a variable read or assigned: a slot of this function, a
+// variable captured from a function this one is declared in, or a global.
+func (c *compiler) resolve(span ast.Span, name string) (target, error) {
+
+	b, exists := c.res.Lookup(span, name)
+	if !exists {
+		return target{}, UnsupportedError{Feature: fmt.Sprintf("the variable '%v' here", name), Span: span}
+	}
+
+	switch {
+	case b.Global:
+		return target{kind: targetGlobal, index: c.name(name)}, nil
+	case b.Depth == 0:
+		return target{kind: targetLocal, index: b.Slot}, nil
+	}
+
+	return target{kind: targetUpvalue, index: c.capture(b.Depth, b.Slot, name)}, nil
+}
+
+// Captures the slot of the function so many out from this one, through each function in
+// between.
+func (c *compiler) capture(depth int, slot int, name string) int {
+
+	if depth == 1 {
+		return c.add_upvalue(upvalueRef{Local: true, Index: slot, Name: name})
+	}
+
+	return c.add_upvalue(upvalueRef{Local: false, Index: c.enclosing.capture(depth-1, slot, name), Name: name})
+}
+
+func (c *compiler) add_upvalue(ref upvalueRef) int {
+
+	for i, existing := range c.code.Upvalues {
+		if existing.Local == ref.Local && existing.Index == ref.Index {
+			return i
+		}
+	}
+
+	c.code.Upvalues = append(c.code.Upvalues, ref)
+
+	return len(c.code.Upvalues) - 1
+}
+
+// Resolves where a declaration puts its name, always the innermost scope.
+func (c *compiler) declaration(name string) (target, error) {
+
+	if c.scope == nil {
+		return target{kind: targetGlobal, index: c.name(name)}, nil
+	}
+
+	// Names outside the slots of the scope wouldn't start off empty each time it is entered.
+	slot, exists := c.scope.slots[name]
+	if !exists || slot < c.scope.base || slot >= c.scope.base+c.scope.count {
+		return target{}, UnsupportedError{Feature: fmt.Sprintf("declaring '%v' here", name), Span: c.span}
+	}
+
+	return target{kind: targetLocal, index: slot}, nil
+}
+
+// Adds an instruction pushing the variable read at span.
+func (c *compiler) variable(span ast.Span, name string, mode int) error {
+
+	t, err := c.resolve(span, name)
+	if err != nil {
+		return err
+	}
+
+	c.load(t, mode)
+
+	return nil
+}
+
+// Adds an instruction pushing a variable.
+func (c *compiler) load(t target, mode int) {
+
+	switch t.kind {
+	case targetLocal:
+		c.emit(OpGetLocal, t.index, mode)
+	case targetUpvalue:
+		c.emit(OpGetUpvalue, t.index, mode)
+	default:
+		c.emit(OpGetGlobal, t.index, mode)
+	}
+}
+
+// Adds an instruction assigning the top of the stack to a variable.
+func (c *compiler) store(t target) {
+
+	switch t.kind {
+	case targetLocal:
+		c.emit(OpSetLocal, t.index)
+	case targetUpvalue:
+		c.emit(OpSetUpvalue, t.index)
+	default:
+		c.emit(OpSetGlobal, t.index)
+	}
+}
+
+// Adds an instruction declaring a variable holding the top of the stack.
+func (c *compiler) declare(name string, isConst bool) error {
+
+	t, err := c.declaration(name)
+	if err != nil {
+		return err
+	}
+
+	if t.kind == targetLocal {
+		c.emit(OpDeclareLocal, t.index, bool_operand(isConst))
+	} else {
+		c.emit(OpDeclareGlobal, t.index, bool_operand(isConst))
+	}
+
+	return nil
+}
+
+func bool_operand(b bool) int {
+
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// Compiles a list of statements leaving the value of the last one, or empty when there are
+// none, the same as the tree-walker gives a body.
+func (c *compiler) statements(body []ast.Expression, empty Opcode) error {
+
+	if len(body) == 0 {
+		c.emit(empty)
+		return nil
+	}
+
+	for i, stmt := range body {
+
+		if err := c.compile(stmt); err != nil {
+			return err
+		}
+
+		if i < len(body)-1 {
+			c.emit(OpPop)
+		}
+	}
+
+	return nil
+}
+
+// Compiles the body of a loop, whose values are thrown away.
+func (c *compiler) loop_body(body []ast.Expression) error {
+
+	for _, stmt := range body {
+
+		if err := c.compile(stmt); err != nil {
+			return err
+		}
+
+		c.emit(OpPop)
+	}
+
+	return nil
+}
+
+// Compiles a node to instructions leaving its value on the stack. Instructions are marked
+// with where the node was written, so the errors they raise point at the same place the
+// tree-walker would.
+func (c *compiler) compile(node ast.Expression) error {
+
+	if node == nil {
+		c.emit_error(fmt.Errorf("unrecognised node in source: %v", ast.Describe(node)))
+		return nil
+	}
+
+	outer := c.span
+	defer func() { c.span = outer }()
+
+	if span := node.Location(); !span.IsZero() {
+		c.span = span
+	}
+
+	switch n := node.(type) {
+	case ast.NumericLiteral:
+		c.emit_constant(MK_NUMBER(n.Value))
+	case ast.BigIntLiteral:
+		c.code.Constants = append(c.code.Constants, MK_INTEGER(n.Value))
+		c.emit(OpCopyConstant, len(c.code.Constants)-1)
+	case ast.StringLiteral:
+		c.emit_constant(MK_STRING(n.Value))
+	case ast.BytesLiteral:
+		c.code.Constants = append(c.code.Constants, MK_BYTES(n.Value))
+		c.emit(OpCopyConstant, len(c.code.Constants)-1)
+	case ast.BooleanLiteral:
+		c.emit_constant(MK_BOOL(n.Value))
+	case ast.Identifier:
+		return c.variable(n.Span, n.Symbol, modeValue)
+	case ast.ShorthandOperator:
+		return c.shorthand(n)
+	case ast.BinaryExpr:
+		return c.binary(n)
+	case ast.UnaryExpr:
+
+		if err := c.compile(n.Argument); err != nil {
+			return err
+		}
+
+		c.emit(OpUnary, c.name(n.Operator))
+	case ast.WhileLoop:
+		return c.while(n)
+	case ast.ForLoop:
+		return c.for_loop(n)
+	case ast.ForInLoop:
+		return c.for_in(n)
+	case ast.IfCondition:
+		return c.if_condition(n)
+	case ast.TernaryCondition:
+		return c.ternary(n)
+	case ast.ArrayOrMapIdentifier:
+
+		// The index is evaluated before the variable is looked up.
+		if err := c.compile(n.Index); err != nil {
+			return err
+		}
+
+		if err := c.variable(n.Span, n.Symbol, modeLookup); err != nil {
+			return err
+		}
+
+		c.emit(OpIndex, c.name(n.Symbol))
+	case ast.SliceExpr:
+
+		if err := c.variable(n.Span, n.Symbol, modeLookup); err != nil {
+			return err
+		}
+
+		flags, err := c.slice_bounds(n)
+		if err != nil {
+			return err
+		}
+
+		c.emit(OpSlice, c.name(n.Symbol), flags)
+	case ast.ArrayLiteral:
+
+		for _, el := range n.Elements {
+			if err := c.compile(el); err != nil {
+				return err
+			}
+		}
+
+		c.emit(OpArray, len(n.Elements))
+	case ast.ObjectLiteral:
+		return c.object(n)
+	case ast.CallExpr:
+
+		argc, err := c.args(n.Args)
+		if err != nil {
+			return err
+		}
+
+		if err := c.compile(n.Caller); err != nil {
+			return err
+		}
+
+		c.emit(OpCall, argc, c.site(n.Span))
+	case ast.MemberExpr:
+		return c.member(n)
+	case ast.VariableDecleration:
+		return c.var_decleration(n)
+	case ast.DestructuringDecleration:
+
+		if err := c.compile(n.Value); err != nil {
+			return err
+		}
+
+		b, err := c.binding(ast.PatternElement{Pattern: &n.Pattern}, n.Constant)
+		if err != nil {
+			return err
+		}
+
+		c.emit(OpBind, b)
+	case ast.ArrayDecleration:
+
+		for _, el := range n.Value {
+			if err := c.compile(el); err != nil {
+				return err
+			}
+		}
+
+		c.emit(OpArray, len(n.Value))
+
+		return c.declare(n.Identifier, n.Constant)
+	case ast.MapDecleration:
+
+		for _, entry := range n.Value {
+
+			if err := c.compile(entry.Key); err != nil {
+				return err
+			}
+
+			if err := c.compile(entry.Value); err != nil {
+				return err
+			}
+		}
+
+		c.emit(OpMap, len(n.Value))
+
+		return c.declare(n.Identifier, n.Constant)
+	case ast.FunctionDecleration:
+		return c.function_decleration(n)
+	case ast.AssignmentExpr:
+		return c.assignment(n)
+	case ast.NamespaceDecleration:
+		return c.namespace_decleration(n)
+	case ast.DeferStatement:
+		return c.deferred(n)
+	case ast.SpawnStatement:
+
+		argc, err := c.args(n.Call.Args)
+		if err != nil {
+			return err
+		}
+
+		if err := c.compile(n.Call.Caller); err != nil {
+			return err
+		}
+
+		c.emit(OpSpawn, argc, c.site(n.Call.Span))
+		c.emit(OpNull)
+	case ast.ExportStatement:
+
+		if err := c.compile(n.Decleration); err != nil {
+			return err
+		}
+
+		c.emit(OpExport, c.name(n.Name))
+	case ast.ReturnStatement:
+
+		if n.Value != nil {
+
+			if err := c.compile(n.Value); err != nil {
+				return err
+			}
+
+			c.emit(OpOrNull)
+		} else {
+			c.emit(OpNull)
+		}
+
+		// Outside of a function, the tree-walker reports the 'return' as an error.
+		if c.function {
+			c.emit(OpReturn)
+		} else {
+			c.emit(OpUnwind)
+		}
+	case ast.YieldStatement:
+		return UnsupportedError{Feature: "generators", Span: c.span}
+	case ast.SpreadExpr:
+		c.emit_error(fmt.Errorf("spread syntax can only be used in function call args"))
+	case ast.Program:
+		return UnsupportedError{Feature: "nested programs", Span: c.span}
+	default:
+		c.emit_error(fmt.Errorf("unrecognised node in source: %v", ast.Describe(node)))
+	}
+
+	return nil
+}
+
+func (c *compiler) binary(binop ast.BinaryExpr) error {
+
+	if err := c.compile(binop.Left); err != nil {
+		return err
+	}
+
+	if err := c.compile(binop.Right); err != nil {
+		return err
+	}
+
+	c.emit(OpBinary, c.name(binop.Operator))
+
+	return nil
+}
+
+// Compiles the args of a call, returning how many there are. Spread args are collected
+// into an array instead.
+func (c *compiler) args(args []ast.Expression) (int, error) {
+
+	spread := false
+	for _, arg := range args {
+		if _, isSpread := arg.(ast.SpreadExpr); isSpread {
+			spread = true
+		}
+	}
+
+	if !spread {
+
+		for _, arg := range args {
+			if err := c.compile(arg); err != nil {
+				return 0, err
+			}
+		}
+
+		return len(args), nil
+	}
+
+	c.emit(OpArray, 0)
+
+	for _, arg := range args {
+
+		if s, isSpread := arg.(ast.SpreadExpr); isSpread {
+
+			if err := c.compile(s.Argument); err != nil {
+				return 0, err
+			}
+
+			c.emit(OpAppendSpread)
+			continue
+		}
+
+		if err := c.compile(arg); err != nil {
+			return 0, err
+		}
+
+		c.emit(OpAppend)
+	}
+
+	return spreadArgs, nil
+}
+
+// Compiles the optional bounds of a slice, returning which of them are given.
+func (c *compiler) slice_bounds(slice ast.SliceExpr) (int, error) {
+
+	flags := 0
+
+	if slice.Start != nil {
+
+		if err := c.compile(slice.Start); err != nil {
+			return 0, err
+		}
+
+		flags |= sliceStart
+	}
+
+	if slice.End != nil {
+
+		if err := c.compile(slice.End); err != nil {
+			return 0, err
+		}
+
+		flags |= sliceEnd
+	}
+
+	return flags, nil
+}
+
+func (c *compiler) object(obj ast.ObjectLiteral) error {
+
+	for _, prop := range obj.Properties {
+
+		c.emit_constant(MK_STRING(prop.Key))
+
+		// Shorthand properties, i.e. '{x}', take the variable of the same name.
+		if prop.Value == nil {
+
+			if err := c.variable(obj.Span, prop.Key, modeLookup); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := c.compile(*prop.Value); err != nil {
+			return err
+		}
+	}
+
+	c.emit(OpObject, len(obj.Properties))
+
+	return nil
+}
+
+func (c *compiler) member(mem ast.MemberExpr) error {
+
+	obj, ok := mem.Object.(ast.Identifier)
+	if !ok {
+		c.emit_error(fmt.Errorf("object identifier invalid: %v", ast.Describe(mem.Object)))
+		return nil
+	}
+
+	prop, ok := mem.Property.(ast.Identifier)
+	if !ok {
+		c.emit_error(fmt.Errorf("object property invalid: %v", ast.Describe(mem.Property)))
+		return nil
+	}
+
+	if err := c.variable(obj.Span, obj.Symbol, modeLookup); err != nil {
+		return err
+	}
+
+	c.code.Errors = append(c.code.Errors, fmt.Errorf("undefined member call: %v.%v", obj.Symbol, prop.Symbol))
+	c.emit(OpMember, c.name(obj.Symbol), c.name(prop.Symbol), len(c.code.Errors)-1)
+
+	return nil
+}
+
+func (c *compiler) var_decleration(dec ast.VariableDecleration) error {
+
+	// 'let x;' has no value to evaluate, it starts off as null.
+	if _, isEmpty := dec.Value.(ast.Expr); isEmpty {
+		c.emit(OpNull)
+	} else {
+
+		if err := c.compile(dec.Value); err != nil {
+			return err
+		}
+
+		c.emit(OpOrNull)
+	}
+
+	return c.declare(dec.Identifier, dec.Constant)
+}
+
+func (c *compiler) assignment(node ast.AssignmentExpr) error {
+
+	if err := c.compile(node.Value); err != nil {
+		return err
+	}
+
+	switch assigne := node.Assigne.(type) {
+	case ast.Identifier:
+
+		t, err := c.resolve(assigne.Span, assigne.Symbol)
+		if err != nil {
+			return err
+		}
+
+		c.store(t)
+	case ast.ArrayOrMapIdentifier:
+
+		// Element assignment, e.g. arr[0] = 10
+		if err := c.compile(assigne.Index); err != nil {
+			return err
+		}
+
+		if err := c.variable(assigne.Span, assigne.Symbol, modeLookup); err != nil {
+			return err
+		}
+
+		c.emit(OpSetIndex, c.name(assigne.Symbol))
+	case ast.SliceExpr:
+
+		// Slice assignment, e.g. arr[1:3] = [4, 5]
+		if err := c.variable(assigne.Span, assigne.Symbol, modeLookup); err != nil {
+			return err
+		}
+
+		flags, err := c.slice_bounds(assigne)
+		if err != nil {
+			return err
+		}
+
+		c.emit(OpSetSlice, c.name(assigne.Symbol), flags)
+	default:
+		c.emit_error(fmt.Errorf("invalid lhs in expression: %v", ast.Describe(node.Assigne)))
+	}
+
+	return nil
+}
+
+func (c *compiler) shorthand(sho ast.ShorthandOperator) error {
+
+	t, err := c.resolve(sho.Span, sho.Left)
+	if err != nil {
+		return err
+	}
+
+	c.load(t, modeLookup)
+	c.emit(OpCheckShorthand, c.name(sho.Operator))
+
+	// Simple Shorthand (x++;) has nothing on the right.
+	if sho.Operator == "++" || sho.Operator == "--" {
+		c.emit_constant(MK_NUMBER(1))
+	} else if err := c.compile(sho.Right); err != nil {
+		return err
+	}
+
+	c.emit(OpShorthand, c.name(sho.Operator))
+	c.store(t)
+
+	return nil
+}
+
+// Compiles the condition of an if statement or while loop, returning the jumps taken when
+// it doesn't hold. Like the tree-walker, conditions other than a comparison, a bool or a
+// lone variable never hold, and aren't evaluated.
+func (c *compiler) condition(condition ast.Expression, comparison Opcode) ([]int, error) {
+
+	switch cond := condition.(type) {
+	case ast.BinaryExpr:
+
+		if err := c.compile(cond.Left); err != nil {
+			return nil, err
+		}
+
+		if err := c.compile(cond.Right); err != nil {
+			return nil, err
+		}
+
+		return []int{c.emit_jump(comparison, c.name(cond.Operator))}, nil
+	case ast.BooleanLiteral:
+
+		if cond.Value {
+			return nil, nil
+		}
+	case ast.Identifier:
+
+		if err := c.variable(cond.Span, cond.Symbol, modeLookup); err != nil {
+			return nil, err
+		}
+
+		return []int{c.emit_jump(OpJumpUnlessBool)}, nil
+	}
+
+	return []int{c.emit_jump(OpJump)}, nil
+}
+
+func (c *compiler) if_condition(iif ast.IfCondition) error {
+
+	exits, err := c.condition(iif.Condition, OpJumpUnlessIf)
+	if err != nil {
+		return err
+	}
+
+	if err := c.statements(iif.Body, OpNull); err != nil {
+		return err
+	}
+
+	end := c.emit_jump(OpJump)
+
+	for _, exit := range exits {
+		c.patch(exit)
+	}
+
+	if iif.ElseCatch && iif.ElseBody != nil {
+		if err := c.statements(iif.ElseBody, OpNull); err != nil {
+			return err
+		}
+	} else {
+		c.emit(OpNull)
+	}
+
+	c.patch(end)
+
+	return nil
+}
+
+func (c *compiler) ternary(t ast.TernaryCondition) error {
+
+	if err := c.compile(t.Condition); err != nil {
+		return err
+	}
+
+	otherwise := c.emit_jump(OpJumpUnlessTernary)
+
+	if err := c.compile(t.Left); err != nil {
+		return err
+	}
+
+	end := c.emit_jump(OpJump)
+	c.patch(otherwise)
+
+	if err := c.compile(t.Right); err != nil {
+		return err
+	}
+
+	c.patch(end)
+
+	return nil
+}
+
+// While loops share the scope they are in, so their body is declared in it.
+func (c *compiler) while(w ast.WhileLoop) error {
+
+	start := len(c.code.Code)
+
+	exits, err := c.condition(w.Condition, OpJumpUnlessWhile)
+	if err != nil {
+		return err
+	}
+
+	c.emit(OpSchedule)
+
+	if err := c.loop_body(w.Body); err != nil {
+		return err
+	}
+
+	c.emit(OpJump, start)
+
+	for _, exit := range exits {
+		c.patch(exit)
+	}
+
+	c.emit(OpNull)
+
+	return nil
+}
+
+// For loops have a scope holding the loop variable, and each iteration of the body has a
+// scope of its own within it.
+func (c *compiler) for_loop(f ast.ForLoop) error {
+
+	loop, err := c.enter(f.Span, resolver.LoopScope)
+	if err != nil {
+		return err
+	}
+
+	c.emit(OpResetScope, loop.base, loop.count)
+
+	if err := c.var_decleration(f.Assignment); err != nil {
+		return err
+	}
+
+	c.emit(OpPop)
+
+	start := len(c.code.Code)
+
+	if err := c.compile(f.Condition.Left); err != nil {
+		return err
+	}
+
+	if err := c.compile(f.Condition.Right); err != nil {
+		return err
+	}
+
+	exit := c.emit_jump(OpJumpUnlessFor, c.name(f.Condition.Operator))
+	c.emit(OpSchedule)
+
+	iteration, err := c.enter(f.Span, resolver.IterationScope)
+	if err != nil {
+		return err
+	}
+
+	c.emit(OpResetScope, iteration.base, iteration.count)
+
+	if err := c.loop_body(f.Body); err != nil {
+		return err
+	}
+
+	c.leave()
+
+	if err := c.shorthand(f.Iterator); err != nil {
+		return err
+	}
+
+	c.emit(OpPop)
+	c.emit(OpJump, start)
+	c.patch(exit)
+
+	c.leave()
+	c.emit(OpNull)
+
+	return nil
+}
+
+// Each iteration of a for ... in loop has a scope of its own, holding the loop variable.
+func (c *compiler) for_in(f ast.ForInLoop) error {
+
+	if err := c.compile(f.Iterable); err != nil {
+		return err
+	}
+
+	c.emit(OpIterStart)
+
+	next := len(c.code.Code)
+	exit := c.emit_jump(OpIterNext)
+	c.emit(OpSchedule)
+
+	iteration, err := c.enter(f.Span, resolver.IterationScope)
+	if err != nil {
+		return err
+	}
+
+	c.emit(OpResetScope, iteration.base, iteration.count)
+
+	b, err := c.binding(f.Binding, false)
+	if err != nil {
+		return err
+	}
+
+	c.emit(OpBind, b)
+	c.emit(OpPop)
+
+	if err := c.loop_body(f.Body); err != nil {
+		return err
+	}
+
+	c.leave()
+
+	c.emit(OpJump, next)
+	c.patch(exit)
+	c.emit(OpIterEnd)
+	c.emit(OpNull)
+
+	return nil
+}
+
+func (c *compiler) function_decleration(f ast.FunctionDecleration) error {
+
+	if f.Generator {
+		return UnsupportedError{Feature: "generators", Span: c.span}
+	}
+
+	frame, exists := c.res.Functions[f.Span]
+	if !exists {
+		return UnsupportedError{Feature: "this function", Span: c.span}
+	}
+
+	fc := new_compiler(&Bytecode{Name: f.Name, Params: f.Params, Audit: c.code.Audit}, c, c.res)
+	fc.function = true
+	fc.frame(frame)
+
+	// The params and body share the scope of the call.
+	if _, err := fc.enter(f.Span, resolver.FunctionScope); err != nil {
+		return err
+	}
+
+	b, err := fc.param_binding(f.Params)
+	if err != nil {
+		return err
+	}
+
+	fc.emit(OpBindParams, b)
+
+	// A function returns the value of its last statement, unless it returns before then.
+	if err := fc.statements(f.Body, OpNull); err != nil {
+		return err
+	}
+
+	fc.emit(OpReturn)
+
+	c.code.Functions = append(c.code.Functions, fc.code)
+	c.emit(OpClosure, len(c.code.Functions)-1)
+
+	return c.declare(f.Name, true)
+}
+
+// Compiles where the names of a pattern element are bound, and the code for any default
+// values it has.
+func (c *compiler) binding(element ast.PatternElement, isConst bool) (int, error) {
+
+	b := binding{Element: element, Targets: map[string]target{}, Defaults: map[ast.Span]int{}, Constant: isConst}
+
+	for _, name := range resolver.ElementNames(element) {
+
+		t, err := c.declaration(name)
+		if err != nil {
+			return 0, err
+		}
+
+		b.Targets[name] = t
+	}
+
+	if err := c.thunks(element_defaults(element), b.Defaults); err != nil {
+		return 0, err
+	}
+
+	c.code.Bindings = append(c.code.Bindings, b)
+
+	return len(c.code.Bindings) - 1, nil
+}
+
+// Compiles where the params of a function are bound, and the code for their defaults.
+func (c *compiler) param_binding(params []ast.Parameter) (int, error) {
+
+	b := binding{Targets: map[string]target{}, Defaults: map[ast.Span]int{}}
+
+	defaults := []ast.Expression{}
+
+	for _, param := range params {
+
+		for _, name := range resolver.ParamNames(param) {
+
+			t, err := c.declaration(name)
+			if err != nil {
+				return 0, err
+			}
+
+			b.Targets[name] = t
+		}
+
+		if param.Default != nil {
+			defaults = append(defaults, param.Default)
+		}
+
+		if param.Pattern != nil {
+			defaults = append(defaults, element_defaults(ast.PatternElement{Pattern: param.Pattern})...)
+		}
+	}
+
+	if err := c.thunks(defaults, b.Defaults); err != nil {
+		return 0, err
+	}
+
+	c.code.Bindings = append(c.code.Bindings, b)
+
+	return len(c.code.Bindings) - 1, nil
+}
+
+// Compiles default values out of line, each ending in a return to whoever asked for it.
+// They are only run when needed, so are jumped over otherwise. Defaults are found by where
+// they were written, so each needs a place of its own.
+func (c *compiler) thunks(defaults []ast.Expression, starts map[ast.Span]int) error {
+
+	if len(defaults) == 0 {
+		return nil
+	}
+
+	over := c.emit_jump(OpJump)
+
+	for _, def := range defaults {
+
+		span := def.Location()
+		if _, exists := starts[span]; exists || span.IsZero() {
+			return UnsupportedError{Feature: "this default value", Span: c.span}
+		}
+
+		starts[span] = len(c.code.Code)
+
+		if err := c.compile(def); err != nil {
+			return err
+		}
+
+		c.emit(OpEndThunk)
+	}
+
+	c.patch(over)
+
+	return nil
+}
+
+func (c *compiler) namespace_decleration(ns ast.NamespaceDecleration) error {
+
+	for _, imp := range ns.Imports {
+
+		c.emit(OpNamespace, c.name(imp.Name))
+
+		// i.e. using { split, join } from "strings";
+		if imp.Members != nil {
+
+			for _, member := range imp.Members {
+
+				t, err := c.declaration(member)
+				if err != nil {
+					return err
+				}
+
+				if t.kind == targetLocal {
+					c.emit(OpImportLocal, c.name(member), t.index)
+				} else {
+					c.emit(OpImportGlobal, c.name(member))
+				}
+			}
+
+			c.emit(OpPop)
+			continue
+		}
+
+		c.emit(OpAddNamespace, c.name(imp.Alias))
+	}
+
+	c.emit(OpNil)
+
+	return nil
+}
+
+func (c *compiler) deferred(d ast.DeferStatement) error {
+
+	if !c.function {
+		c.emit_error(fmt.Errorf("defer can only be used inside a function"))
+		return nil
+	}
+
+	argc, err := c.args(d.Call.Args)
+	if err != nil {
+		return err
+	}
+
+	if err := c.compile(d.Call.Caller); err != nil {
+		return err
+	}
+
+	c.emit(OpDefer, argc, c.site(d.Call.Span))
+	c.emit(OpNull)
+
+	return nil
+}
+
+// Returns the default values within a pattern element.
+func element_defaults(element ast.PatternElement) []ast.Expression {
+
+	defaults := []ast.Expression{}
+
+	if element.Default != nil {
+		defaults = append(defaults, element.Default)
+	}
+
+	if element.Pattern != nil {
+		for _, el := range element.Pattern.Elements {
+			defaults = append(defaults, element_defaults(el)...)
+		}
+	}
+
+	return defaults
+}
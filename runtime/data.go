@@ -21,6 +21,54 @@ var Data = Namespace{
 			Type: "NativeFn",
 			Call: size,
 		},
+		"set": {
+			Type: "NativeFn",
+			Call: set,
+		},
+		"add": {
+			Type: "NativeFn",
+			Call: add,
+		},
+		"remove": {
+			Type: "NativeFn",
+			Call: remove,
+		},
+		"contains": {
+			Type: "NativeFn",
+			Call: contains,
+		},
+		"union": {
+			Type: "NativeFn",
+			Call: union,
+		},
+		"intersection": {
+			Type: "NativeFn",
+			Call: intersection,
+		},
+		"difference": {
+			Type: "NativeFn",
+			Call: difference,
+		},
+		"subset": {
+			Type: "NativeFn",
+			Call: subset,
+		},
+		"keys": {
+			Type: "NativeFn",
+			Call: keys,
+		},
+		"values": {
+			Type: "NativeFn",
+			Call: values,
+		},
+		"delete": {
+			Type: "NativeFn",
+			Call: deleteKey,
+		},
+		"sortedmap": {
+			Type: "NativeFn",
+			Call: sortedmap,
+		},
 	},
 }
 
@@ -39,6 +87,10 @@ var push FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue
 		return nil, fmt.Errorf("data.push must be used on array type, %v type given", arr.Type)
 	}
 
+	if IsFrozen(arr) {
+		return nil, frozenError("data.push", arr)
+	}
+
 	// The value we want to push into the array.
 	value := args[1]
 	*arr.Value = append(*arr.Value, value)
@@ -61,16 +113,103 @@ var put FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue,
 		return nil, fmt.Errorf("data.put must be used on map type, %v type given", mapp.Type)
 	}
 
+	if IsFrozen(mapp) {
+		return nil, frozenError("data.put", mapp)
+	}
+
 	// The value we want to push into the array.
 	key := args[1]
 	value := args[2]
 
-	tmp := *mapp.Value
-	tmp[key] = value
+	if err := mapp.Value.Set(key, value); err != nil {
+		return nil, err
+	}
 
 	return MK_NULL(), nil
 }
 
+// keys, returns the keys of a map as an array, in the order they were inserted
+// data.keys(m map) array
+var keys FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	mapp, err := mapArg("data.keys", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := []RuntimeValue{}
+	for _, entry := range mapp.Value.Entries() {
+		elements = append(elements, entry.Key)
+	}
+
+	return MK_ARRAY(elements), nil
+}
+
+// values, returns the values of a map as an array, in the order their keys were inserted
+// data.values(m map) array
+var values FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	mapp, err := mapArg("data.values", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := []RuntimeValue{}
+	for _, entry := range mapp.Value.Entries() {
+		elements = append(elements, entry.Value)
+	}
+
+	return MK_ARRAY(elements), nil
+}
+
+// delete, removes a key from a map, returns true if the key was there
+// data.delete(m map, key any) bool
+var deleteKey FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	mapp, err := mapArg("data.delete", args, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsFrozen(mapp) {
+		return nil, frozenError("data.delete", mapp)
+	}
+
+	exists, err := mapp.Value.Delete(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return MK_BOOL(exists), nil
+}
+
+// sortedmap, returns a new map that keeps its keys sorted rather than in insertion order,
+// holding the entries of the given map. Returns an empty sorted map when called without args.
+// data.sortedmap(), data.sortedmap(m map) map
+var sortedmap FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs > 1 {
+		return nil, fmt.Errorf("unexpected number of args for data.sortedmap, expected 0 or 1 got %v", numArgs)
+	}
+
+	sorted := NewSortedHashMap()
+
+	if numArgs == 1 {
+
+		mapp, err := mapArg("data.sortedmap", args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range mapp.Value.Entries() {
+			sorted.Set(entry.Key, entry.Value)
+		}
+	}
+
+	return MK_MAP(sorted), nil
+}
+
 // pop, returns the last element of the specified array
 // data.pop(a array)
 var pop FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
@@ -84,7 +223,11 @@ var pop FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue,
 	arr, isArr := a.(ArrayValue)
 
 	if !isArr {
-		return nil, fmt.Errorf("unexpected type provided for data.pop, got %v", a)
+		return nil, fmt.Errorf("unexpected type provided for data.pop, got %v", TypeName(a))
+	}
+
+	if IsFrozen(arr) {
+		return nil, frozenError("data.pop", arr)
 	}
 
 	if len(*arr.Value) > 0 {
@@ -99,8 +242,8 @@ var pop FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue,
 	return nil, fmt.Errorf("cannot pop an empty array")
 }
 
-// size, returns the size of the array or map specified
-// data.size(a array), data.size(m map)
+// size, returns the size of the array, map, set or bytes specified
+// data.size(a array), data.size(m map), data.size(s set), data.size(b bytes)
 var size FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
 
 	numArgs := len(args)
@@ -111,17 +254,245 @@ var size FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue
 	a := args[0]
 	arr, isArr := a.(ArrayValue)
 	mapp, isMap := a.(MapValue)
+	b, isBytes := a.(BytesValue)
+	set, isSet := a.(SetValue)
 
-	if !isArr && !isMap {
-		return nil, fmt.Errorf("unexpected type provided for data.size, got %v", a)
+	if !isArr && !isMap && !isBytes && !isSet {
+		return nil, fmt.Errorf("unexpected type provided for data.size, got %v", TypeName(a))
 	}
 
 	size := 0
 	if isArr {
 		size = len(*arr.Value)
 	} else if isMap {
-		size = len(*mapp.Value)
+		size = mapp.Value.Len()
+	} else if isBytes {
+		size = len(b.Value)
+	} else if isSet {
+		size = len(*set.Value)
 	}
 
 	return MK_NUMBER(size), nil
 }
+
+// set, returns a new set holding the unique values of an array, string, bytes, map keys or
+// another set. Returns an empty set when called without args.
+// data.set(), data.set(values any)
+var set FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs > 1 {
+		return nil, fmt.Errorf("unexpected number of args for data.set, expected 0 or 1 got %v", numArgs)
+	}
+
+	elements := map[string]RuntimeValue{}
+
+	if numArgs == 1 {
+
+		values, err := iteration_values(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("data.set cannot be made from %v", TypeName(args[0]))
+		}
+
+		for _, v := range values {
+
+			key, err := HashKey(v)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, exists := elements[key]; !exists {
+				elements[key] = frozenKey(v)
+			}
+		}
+	}
+
+	return MK_SET(elements), nil
+}
+
+// add, adds a value to a set, does nothing if the value is already there
+// data.add(s set, val any)
+var add FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	set, key, err := setAndKey("data.add", args)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsFrozen(set) {
+		return nil, frozenError("data.add", set)
+	}
+
+	if _, exists := (*set.Value)[key]; !exists {
+		(*set.Value)[key] = frozenKey(args[1])
+	}
+
+	return MK_NULL(), nil
+}
+
+// remove, removes a value from a set, returns true if the value was there
+// data.remove(s set, val any) bool
+var remove FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	set, key, err := setAndKey("data.remove", args)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsFrozen(set) {
+		return nil, frozenError("data.remove", set)
+	}
+
+	_, exists := (*set.Value)[key]
+	delete(*set.Value, key)
+
+	return MK_BOOL(exists), nil
+}
+
+// contains, returns true if the value is in the set
+// data.contains(s set, val any) bool
+var contains FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	set, key, err := setAndKey("data.contains", args)
+	if err != nil {
+		return nil, err
+	}
+
+	_, exists := (*set.Value)[key]
+
+	return MK_BOOL(exists), nil
+}
+
+// union, returns a new set holding the values in either set
+// data.union(a set, b set) set
+var union FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	a, b, err := setPair("data.union", args)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := map[string]RuntimeValue{}
+	for key, v := range *a.Value {
+		elements[key] = v
+	}
+	for key, v := range *b.Value {
+		elements[key] = v
+	}
+
+	return MK_SET(elements), nil
+}
+
+// intersection, returns a new set holding the values in both sets
+// data.intersection(a set, b set) set
+var intersection FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	a, b, err := setPair("data.intersection", args)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := map[string]RuntimeValue{}
+	for key, v := range *a.Value {
+		if _, ok := (*b.Value)[key]; ok {
+			elements[key] = v
+		}
+	}
+
+	return MK_SET(elements), nil
+}
+
+// difference, returns a new set holding the values in the first set but not the second
+// data.difference(a set, b set) set
+var difference FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	a, b, err := setPair("data.difference", args)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := map[string]RuntimeValue{}
+	for key, v := range *a.Value {
+		if _, ok := (*b.Value)[key]; !ok {
+			elements[key] = v
+		}
+	}
+
+	return MK_SET(elements), nil
+}
+
+// subset, returns true if every value in the first set is also in the second
+// data.subset(a set, b set) bool
+var subset FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	a, b, err := setPair("data.subset", args)
+	if err != nil {
+		return nil, err
+	}
+
+	for key := range *a.Value {
+		if _, ok := (*b.Value)[key]; !ok {
+			return MK_BOOL(false), nil
+		}
+	}
+
+	return MK_BOOL(true), nil
+}
+
+// Helper for the single set functions, checks the args are a set and a hashable value.
+func setAndKey(name string, args []RuntimeValue) (SetValue, string, error) {
+
+	numArgs := len(args)
+	if numArgs != 2 {
+		return SetValue{}, "", fmt.Errorf("unexpected number of args for %v, expected 2 got %v", name, numArgs)
+	}
+
+	set, isSet := args[0].(SetValue)
+	if !isSet {
+		return SetValue{}, "", fmt.Errorf("%v must be used on set type, %v type given", name, TypeName(args[0]))
+	}
+
+	key, err := HashKey(args[1])
+	if err != nil {
+		return SetValue{}, "", err
+	}
+
+	return set, key, nil
+}
+
+// Helper for the set algebra functions, checks both args are sets.
+func setPair(name string, args []RuntimeValue) (SetValue, SetValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 2 {
+		return SetValue{}, SetValue{}, fmt.Errorf("unexpected number of args for %v, expected 2 got %v", name, numArgs)
+	}
+
+	a, isSet := args[0].(SetValue)
+	if !isSet {
+		return SetValue{}, SetValue{}, fmt.Errorf("%v must be used on set type, %v type given", name, TypeName(args[0]))
+	}
+
+	b, isSet := args[1].(SetValue)
+	if !isSet {
+		return SetValue{}, SetValue{}, fmt.Errorf("%v must be used on set type, %v type given", name, TypeName(args[1]))
+	}
+
+	return a, b, nil
+}
+
+// Checks the args of a map function, returning the map they start with.
+func mapArg(name string, args []RuntimeValue, expected int) (MapValue, error) {
+
+	numArgs := len(args)
+	if numArgs != expected {
+		return MapValue{}, fmt.Errorf("unexpected number of args for %v, expected %v got %v", name, expected, numArgs)
+	}
+
+	mapp, isMap := args[0].(MapValue)
+	if !isMap {
+		return MapValue{}, fmt.Errorf("%v must be used on map type, %v type given", name, TypeName(args[0]))
+	}
+
+	return mapp, nil
+}
@@ -3,22 +3,54 @@ package runtime
 import (
 	"fmt"
 	"io"
+	"unicode/utf8"
+
+	"goblin.org/main/frontend/ast"
 )
 
 var register = map[string]Namespace{
 	"io":      IO,
 	"data":    Data,
 	"strings": Strings,
+	"sync":    Sync,
+}
+
+// The builtins declared in every program's global scope.
+var builtins = map[string]FunctionCall{
+	"freeze":   freezeFn,
+	"copy":     copyFn,
+	"deepcopy": deepcopyFn,
+	"chan":     chanFn,
+	"send":     sendFn,
+	"receive":  receiveFn,
+	"close":    closeFn,
+	"select":   selectFn,
 }
 
+// A scope. Environments are shared between tasks, so are only read and written while
+// holding the lock of their scheduler, see Scheduler.
 type Environment struct {
 	Parent        *Environment
 	Stdout        io.Writer
 	Stdin         io.Reader
 	EntryLocation string
+	Audit         map[int]string // The lines of the source being run, to point at runtime errors.
 	Variables     map[string]RuntimeValue
 	Constants     map[string]bool
 	Namespaces    map[string]Namespace
+	Deferred      *[]DeferredCall          // Calls scheduled with 'defer', only set on function scopes.
+	Yield         func(RuntimeValue) error // Hands a value out of a generator, only set on generator scopes.
+	Exports       *[]string                // Names given to 'export', only set on the global scope of a module.
+	Stack         *[]Frame                 // The user-defined function calls being evaluated by this task.
+	Modules       *Modules                 // The modules loaded by the program run this scope is part of.
+	Scheduler     *Scheduler               // Runs the tasks of the program this scope is part of.
+}
+
+// A call scheduled with 'defer', the function and its args are resolved at defer time.
+type DeferredCall struct {
+	Fn   RuntimeValue
+	Args []RuntimeValue
+	Site ast.Span // Where the call was deferred.
 }
 
 // Used to declare a new variable. Includes checking for variable already existing.
@@ -31,6 +63,15 @@ func (e Environment) Declare(var_ string, value RuntimeValue, isConst bool) (Run
 		return nil, fmt.Errorf("'%v' already defined", var_)
 	}
 
+	if _, isNamespace := e.Namespaces[var_]; isNamespace {
+		return nil, fmt.Errorf("'%v' already defined as a namespace", var_)
+	}
+
+	// Constant collections can't be modified either.
+	if isConst {
+		Freeze(value)
+	}
+
 	// If not, set it.
 	e.Variables[var_] = value
 
@@ -66,9 +107,17 @@ func (e Environment) DeclareArray(var_ string, values []RuntimeValue, isConst bo
 		return nil, fmt.Errorf("'%v' already defined", var_)
 	}
 
+	if _, isNamespace := e.Namespaces[var_]; isNamespace {
+		return nil, fmt.Errorf("'%v' already defined as a namespace", var_)
+	}
+
 	// Make the array object here, which contains all the RuntimeValues the user specified.
 	arr := MK_ARRAY(values)
 
+	if isConst {
+		Freeze(arr)
+	}
+
 	// If not, set it.
 	e.Variables[var_] = arr
 
@@ -79,7 +128,7 @@ func (e Environment) DeclareArray(var_ string, values []RuntimeValue, isConst bo
 }
 
 // Used to declare a new map. Includes checking for map that might already existing.
-func (e Environment) DeclareMap(var_ string, values map[RuntimeValue]RuntimeValue, isConst bool) (RuntimeValue, error) {
+func (e Environment) DeclareMap(var_ string, values *HashMap, isConst bool) (RuntimeValue, error) {
 
 	_, exists := e.Variables[var_]
 
@@ -88,9 +137,17 @@ func (e Environment) DeclareMap(var_ string, values map[RuntimeValue]RuntimeValu
 		return nil, fmt.Errorf("'%v' already defined", var_)
 	}
 
+	if _, isNamespace := e.Namespaces[var_]; isNamespace {
+		return nil, fmt.Errorf("'%v' already defined as a namespace", var_)
+	}
+
 	// Make the array object here, which contains all the RuntimeValues the user specified.
 	map_ := MK_MAP(values)
 
+	if isConst {
+		Freeze(map_)
+	}
+
 	// If not, set it.
 	e.Variables[var_] = map_
 
@@ -108,7 +165,7 @@ func (e Environment) Assign(var_ string, value RuntimeValue) (RuntimeValue, erro
 		return nil, err
 	}
 
-	hasConstant := e.Constants[var_]
+	hasConstant := env.Constants[var_]
 
 	if hasConstant {
 		// Cannot assign to a constant.
@@ -120,7 +177,9 @@ func (e Environment) Assign(var_ string, value RuntimeValue) (RuntimeValue, erro
 	return value, nil
 }
 
-// Used to find the specific Environment a variable is located in (scope resolution).
+// Used to find the specific Environment a variable is located in (scope resolution). The
+// tree-walker still finds every variable by name through here, only the bytecode VM uses the
+// slots laid out by the resolver.
 func (e Environment) Resolve(var_ string) (Environment, error) {
 
 	// Variable in this scope?
@@ -151,6 +210,36 @@ func (e Environment) Resolve(var_ string) (Environment, error) {
 	return res, nil
 }
 
+// Used to find the defer stack of the function enclosing this scope.
+func (e Environment) ResolveDeferred() (*[]DeferredCall, error) {
+
+	if e.Deferred != nil {
+		return e.Deferred, nil
+	}
+
+	// Reached the global scope without passing through a function.
+	if e.Parent == nil {
+		return nil, fmt.Errorf("defer can only be used inside a function")
+	}
+
+	return e.Parent.ResolveDeferred()
+}
+
+// Used to find the yield of the generator enclosing this scope.
+func (e Environment) ResolveYield() (func(RuntimeValue) error, error) {
+
+	if e.Yield != nil {
+		return e.Yield, nil
+	}
+
+	// Yields are only parsed inside functions, so this is a yield in a normal function.
+	if e.Parent == nil || e.Deferred != nil {
+		return nil, fmt.Errorf("yield can only be used inside a generator")
+	}
+
+	return e.Parent.ResolveYield()
+}
+
 // Returns the value of the variable.
 func (e Environment) Lookup(var_ string) (RuntimeValue, error) {
 
@@ -162,18 +251,64 @@ func (e Environment) Lookup(var_ string) (RuntimeValue, error) {
 	return env.Variables[var_], nil
 }
 
-// Attempts to add a new namespace to an environment.
-func (e Environment) AddNamespace(var_ string) error {
+// Finds the namespace a using directive names, loading it first if it is a module.
+func (e Environment) ResolveNamespace(var_ string) (Namespace, error) {
+
+	if IsModulePath(var_) {
+		return e.LoadModule(var_)
+	}
 
 	namespace, ok := register[var_]
-	if ok {
-		// Add namespace.
-		e.Namespaces[var_] = namespace
-		return nil
+	if !ok {
+		// Namespace does not exist, perhaps not added to stdlib yet?
+		return Namespace{}, fmt.Errorf("unrecognised namespace: %v", var_)
+	}
+
+	return namespace, nil
+}
+
+// Attempts to add a new namespace to an environment, under the given name. Using the same
+// namespace under the same name again does nothing.
+func (e Environment) AddNamespace(var_ string, namespace Namespace) error {
+
+	if _, isVariable := e.Variables[var_]; isVariable {
+		return fmt.Errorf("namespace '%v' conflicts with existing variable '%v'", var_, var_)
+	}
+
+	existing, exists := e.Namespaces[var_]
+	if exists && !existing.Same(namespace) {
+		return fmt.Errorf("namespace '%v' is already used for %v", var_, existing.Name)
+	}
+
+	// Add namespace.
+	e.Namespaces[var_] = namespace
+
+	return nil
+}
+
+// Brings a single member of a namespace into scope, as a constant. Unlike a const
+// decleration, the value isn't frozen, as it still belongs to the namespace.
+func (e Environment) Import(var_ string, value RuntimeValue, from Namespace) error {
+
+	if _, exists := e.Variables[var_]; exists {
+		return fmt.Errorf("import of '%v' from %v conflicts with existing variable '%v'", var_, from.Name, var_)
+	}
+
+	e.Variables[var_] = value
+	e.Constants[var_] = true
+
+	return nil
+}
+
+// Returns the global scope of the program or module this scope is part of. Namespaces are
+// only ever added here, so each module sees just the ones it used itself.
+func (e Environment) Global() Environment {
+
+	if e.Parent == nil {
+		return e
 	}
 
-	// Namespace does not exist, perhaps not added to stdlib yet?
-	return fmt.Errorf("unrecognised namespace: %v", var_)
+	return e.Parent.Global()
 }
 
 // Attempts to resolve the namespace this variable maps to.
@@ -189,12 +324,22 @@ func (e Environment) LookupNamespace(var_ string) (*Namespace, error) {
 	return &n, nil
 }
 
-// Attemptsm to resolve a namespace property to a function.
-func (e Environment) LookupNativeFunction(ns Namespace, prop string) (NativeFunction, error) {
+// Attempts to resolve a namespace property to a stdlib function, or a value exported by a module.
+func (e Environment) LookupMember(ns Namespace, prop string) (RuntimeValue, error) {
+
+	if ns.Exports != nil {
+
+		value, ok := ns.Exports[prop]
+		if !ok {
+			return nil, fmt.Errorf("undefined export: %v for module: %v", prop, ns.Name)
+		}
+
+		return value, nil
+	}
 
 	fn, ok := ns.Functions[prop]
 	if !ok {
-		return NativeFunction{}, fmt.Errorf("undefined fucntion: %v for namespace: %v", prop, ns.Name)
+		return nil, fmt.Errorf("undefined fucntion: %v for namespace: %v", prop, ns.Name)
 	}
 
 	return fn, nil
@@ -207,39 +352,113 @@ func (e Environment) ArrayOrMapLookup(var_ string, i RuntimeValue) (RuntimeValue
 		return nil, err
 	}
 
-	// Dealing with an array.
-	if e.IsArray(datastructure) {
-
-		// Arrays can only use ints as their indexer.
-		if index, ok := i.(NumberValue); ok {
+	return index_value(var_, datastructure, i)
+}
 
-			val, err := e.ArrayLookup(var_, index.Value)
-			if err != nil {
-				return nil, err
-			}
+// Looks up a single element of the array, map, string or bytes held by the variable var_.
+func index_value(var_ string, datastructure RuntimeValue, i RuntimeValue) (RuntimeValue, error) {
 
-			return val, nil
+	switch ds := datastructure.(type) {
+	case ArrayValue, StringValue, BytesValue:
 
-		} else {
+		// Arrays, strings and bytes can only use ints as their indexer.
+		index, ok := i.(NumberValue)
+		if !ok {
 			return nil, fmt.Errorf("array index must be of type int")
 		}
 
-	} else if e.IsMap(datastructure) {
-		// Dealing with a map.
+		length := 0
+		switch ds := ds.(type) {
+		case ArrayValue:
+			length = len(*ds.Value)
+		case StringValue:
+			// Indices count characters rather than bytes.
+			length = utf8.RuneCountInString(ds.Value)
+		case BytesValue:
+			length = len(ds.Value)
+		}
 
-		val, err := e.MapLookup(var_, i)
+		pos, err := NormaliseIndex(index.Value, length)
 		if err != nil {
 			return nil, err
 		}
 
+		switch ds := ds.(type) {
+		case ArrayValue:
+			return (*ds.Value)[pos], nil
+		case StringValue:
+			return MK_STRING(string([]rune(ds.Value)[pos])), nil
+		case BytesValue:
+			return MK_NUMBER(int(ds.Value[pos])), nil
+		}
+	case MapValue:
+
+		val, ok, err := ds.Value.Get(i)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("key `%v` does not exist for map: %v", i, var_)
+		}
+
 		return val, nil
+	}
+
+	return nil, fmt.Errorf("unrecognised datastructure provided: %v", datastructure)
+}
 
-	} else {
-		return nil, fmt.Errorf("unrecognised datastructure provided: %v", datastructure)
+// Used to assign a value to a single element of an array or map, i.e. 'arr[0] = 10;'.
+func (e Environment) ArrayOrMapAssign(var_ string, i RuntimeValue, value RuntimeValue) (RuntimeValue, error) {
+
+	datastructure, err := e.Lookup(var_)
+	if err != nil {
+		return nil, err
 	}
+
+	return assign_index(var_, datastructure, i, value)
+}
+
+// Assigns a value to a single element of the array or map held by the variable var_.
+func assign_index(var_ string, datastructure RuntimeValue, i RuntimeValue, value RuntimeValue) (RuntimeValue, error) {
+
+	if IsFrozen(datastructure) {
+		return nil, fmt.Errorf("cannot assign to index of '%v', %v is frozen", var_, TypeName(datastructure))
+	}
+
+	switch ds := datastructure.(type) {
+	case ArrayValue:
+
+		index, ok := i.(NumberValue)
+		if !ok {
+			return nil, fmt.Errorf("array index must be of type int")
+		}
+
+		pos, err := NormaliseIndex(index.Value, len(*ds.Value))
+		if err != nil {
+			return nil, err
+		}
+
+		(*ds.Value)[pos] = value
+		return value, nil
+	case MapValue:
+
+		if err := ds.Value.Set(i, value); err != nil {
+			return nil, err
+		}
+
+		return value, nil
+	case StringValue:
+		return nil, fmt.Errorf("cannot assign to index of '%v', strings are immutable", var_)
+	case BytesValue:
+		return nil, fmt.Errorf("cannot assign to index of '%v', bytes are immutable", var_)
+	}
+
+	return nil, fmt.Errorf("unrecognised datastructure provided: %v", datastructure)
 }
 
 // Used in the specific case of looking up individual elements in an array.
+// Negative indices count back from the end of the array.
 func (e Environment) ArrayLookup(var_ string, index int) (RuntimeValue, error) {
 
 	arr_, err := e.Lookup(var_)
@@ -249,18 +468,58 @@ func (e Environment) ArrayLookup(var_ string, index int) (RuntimeValue, error) {
 
 	if arr, ok := arr_.(ArrayValue); ok {
 
-		// The specified index value is out of bounds!
-		if index >= len(*arr.Value) {
-			return nil, fmt.Errorf("index out of bounds for index %v", index)
+		pos, err := NormaliseIndex(index, len(*arr.Value))
+		if err != nil {
+			return nil, err
 		}
 
 		a := *arr.Value
-		return a[index], nil
+		return a[pos], nil
 	}
 
 	return nil, fmt.Errorf("invalid array: %v", arr_)
 }
 
+// Used in the specific case of looking up individual characters in a string.
+// Indices count characters rather than bytes.
+func (e Environment) StringLookup(var_ string, index int) (RuntimeValue, error) {
+
+	str_, err := e.Lookup(var_)
+	if err != nil {
+		return nil, err
+	}
+
+	if str, ok := str_.(StringValue); ok {
+
+		runes := []rune(str.Value)
+
+		pos, err := NormaliseIndex(index, len(runes))
+		if err != nil {
+			return nil, err
+		}
+
+		return MK_STRING(string(runes[pos])), nil
+	}
+
+	return nil, fmt.Errorf("invalid string: %v", str_)
+}
+
+// Converts a possibly negative index into a position within a sequence of the given length.
+func NormaliseIndex(index int, length int) (int, error) {
+
+	pos := index
+	if pos < 0 {
+		pos += length
+	}
+
+	// The specified index value is out of bounds!
+	if pos < 0 || pos >= length {
+		return 0, fmt.Errorf("index out of bounds for index %v", index)
+	}
+
+	return pos, nil
+}
+
 // Used in the specific case of looking up individual elements in a map
 func (e Environment) MapLookup(var_ string, index RuntimeValue) (RuntimeValue, error) {
 
@@ -271,9 +530,12 @@ func (e Environment) MapLookup(var_ string, index RuntimeValue) (RuntimeValue, e
 
 	if mapp, ok := mapp_.(MapValue); ok {
 
-		m := *mapp.Value
 		// The specified index value is out of bounds!
-		val, ok := m[index]
+		val, ok, err := mapp.Value.Get(index)
+		if err != nil {
+			return nil, err
+		}
+
 		if ok {
 			return val, nil
 		} else {
@@ -294,6 +556,26 @@ func (e Environment) IsArray(r RuntimeValue) bool {
 	return false
 }
 
+// Returns true if the Runtime value is a String type.
+func (e Environment) IsString(r RuntimeValue) bool {
+
+	if _, ok := r.(StringValue); ok {
+		return true
+	}
+
+	return false
+}
+
+// Returns true if the Runtime value is a Bytes type.
+func (e Environment) IsBytes(r RuntimeValue) bool {
+
+	if _, ok := r.(BytesValue); ok {
+		return true
+	}
+
+	return false
+}
+
 // Returns true if the Runtime value is a Map type.
 func (e Environment) IsMap(r RuntimeValue) bool {
 
@@ -304,9 +586,17 @@ func (e Environment) IsMap(r RuntimeValue) bool {
 	return false
 }
 
-func (e Environment) Setup() {
+// Sets up a global scope, declaring the builtins. The scope gets a scheduler of its own,
+// shared by every program run in it and the tasks they spawn.
+func (e *Environment) Setup() {
+
+	e.Scheduler = NewScheduler()
 
 	e.Declare("null", MK_NULL(), true)
 	e.Declare("true", MK_BOOL(true), true)
 	e.Declare("false", MK_BOOL(false), true)
+
+	for name, call := range builtins {
+		e.Declare(name, MK_NATIVE_FN(call), true)
+	}
 }
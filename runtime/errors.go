@@ -0,0 +1,174 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"goblin.org/main/frontend/ast"
+	"goblin.org/main/utils"
+)
+
+// An error raised while evaluating a node, remembering where in the source that node was.
+type RuntimeError struct {
+	Err    error
+	Span   ast.Span
+	Source string  // The line the node starts on, as the lexer saw it.
+	Trace  []Frame // The calls being evaluated when it was raised, innermost first.
+}
+
+func (e RuntimeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// Attaches the location of node to err. Errors that already know where they came from keep
+// the innermost location, as that is the node that actually went wrong.
+func locate(err error, node ast.Expression, env Environment) error {
+
+	if node == nil {
+		return err
+	}
+
+	return locate_span(err, node.Location(), env)
+}
+
+// Attaches span to err, for errors raised by code that has no node to hand, i.e. bytecode
+// compiled from it.
+func locate_span(err error, span ast.Span, env Environment) error {
+
+	// Signals unwinding the stack aren't errors, they need to reach whoever catches them as is.
+	switch err.(type) {
+	case returnSignal, generatorStop:
+		return err
+	}
+
+	var located RuntimeError
+	if errors.As(err, &located) || span.IsZero() {
+		return err
+	}
+
+	return RuntimeError{Err: err, Span: span, Source: env.Audit[span.Start.Line], Trace: env.trace()}
+}
+
+// The deepest calls can be nested before a stack overflow is raised, well short of where
+// the Go stack would run out.
+const maxCallDepth = 10000
+
+// How many frames of a stack trace are shown, a stack overflow would otherwise print
+// thousands of them.
+const maxTraceFrames = 20
+
+// A call to a user-defined function that is still being evaluated.
+type Frame struct {
+	Function string
+	CallSite ast.Span // Zero when called by a native function, i.e. iter.map.
+	Source   string   // The line it was called from.
+}
+
+// Pushes a frame onto the call stack of this task, returning the function that pops it.
+// Raises a stack overflow once the calls are nested too deep.
+func (e Environment) pushFrame(frame Frame) (func(), error) {
+
+	if e.Stack == nil {
+		return func() {}, nil
+	}
+
+	if len(*e.Stack) >= maxCallDepth {
+		return nil, fmt.Errorf("stack overflow: more than %v nested calls", maxCallDepth)
+	}
+
+	*e.Stack = append(*e.Stack, frame)
+
+	return func() {
+		*e.Stack = (*e.Stack)[:len(*e.Stack)-1]
+	}, nil
+}
+
+// Returns a copy of the call stack, innermost call first.
+func (e Environment) trace() []Frame {
+
+	if e.Stack == nil {
+		return nil
+	}
+
+	frames := make([]Frame, len(*e.Stack))
+	for i, frame := range *e.Stack {
+		frames[len(frames)-1-i] = frame
+	}
+
+	return frames
+}
+
+// Returns the stack trace of a runtime error, innermost call first. Empty when the error
+// was raised outside of any function.
+func StackTrace(err error) []Frame {
+
+	var located RuntimeError
+	if !errors.As(err, &located) {
+		return nil
+	}
+
+	return located.Trace
+}
+
+// Formats a stack trace for printing, one call per line.
+func FormatStackTrace(trace []Frame) string {
+
+	if len(trace) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("stack trace:\n")
+
+	for i, frame := range trace {
+
+		if i == maxTraceFrames {
+			fmt.Fprintf(&b, "    ... %v more\n", len(trace)-i)
+			break
+		}
+
+		if frame.CallSite.IsZero() {
+			fmt.Fprintf(&b, "    %v, called by a native function\n", frame.Function)
+			continue
+		}
+
+		start := frame.CallSite.Start
+		fmt.Fprintf(&b, "    %v, called on line %v col %v: %v\n", frame.Function, start.Line, start.Col, strings.TrimSpace(frame.Source))
+	}
+
+	return b.String()
+}
+
+// An error that went uncaught, printed after its origin. Unwraps to the error raised, so
+// embedding hosts can still get at its location and stack trace.
+type UncaughtError struct {
+	Origin string // i.e. 'interpreter error: '
+	Err    error
+}
+
+func (e UncaughtError) Error() string {
+	return FormatError(e.Origin, e.Err)
+}
+
+func (e UncaughtError) Unwrap() error {
+	return e.Err
+}
+
+// Formats err for printing after origin, e.g. 'interpreter error: '. When the error knows
+// where it came from, the line is shown underlined the same way parse errors are.
+func FormatError(origin string, err error) string {
+
+	var located RuntimeError
+	if !errors.As(err, &located) {
+		return origin + err.Error()
+	}
+
+	start := located.Span.Start
+
+	return origin + utils.GenerateError(origin, located.Source, "", start.Line, start.Col, err.Error())
+}
@@ -0,0 +1,227 @@
+package runtime
+
+import "fmt"
+
+// freeze, deep-freezes a value so it, and anything it holds, can no longer be modified.
+// Returns the value. Every variable holding the same array, map or set sees it frozen.
+// freeze(x any) any
+var freezeFn FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 1 {
+		return nil, fmt.Errorf("unexpected number of args for freeze, expected 1 got %v", numArgs)
+	}
+
+	return Freeze(args[0]), nil
+}
+
+// copy, returns a shallow copy of an array, map, set or object. The copy is never frozen,
+// but the values it holds are shared with the original.
+// copy(x any) any
+var copyFn FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 1 {
+		return nil, fmt.Errorf("unexpected number of args for copy, expected 1 got %v", numArgs)
+	}
+
+	return CopyValue(args[0]), nil
+}
+
+// deepcopy, returns a copy of a value where every array, map, set and object it holds is
+// copied as well. Nothing in the copy is frozen.
+// deepcopy(x any) any
+var deepcopyFn FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 1 {
+		return nil, fmt.Errorf("unexpected number of args for deepcopy, expected 1 got %v", numArgs)
+	}
+
+	return deepCopy(args[0], map[any]RuntimeValue{}), nil
+}
+
+// Returns true if the value has been frozen. Values that can't be modified anyway, like
+// ints and strings, are never reported as frozen.
+func IsFrozen(value RuntimeValue) bool {
+
+	switch v := value.(type) {
+	case ArrayValue:
+		return v.Frozen != nil && *v.Frozen
+	case MapValue:
+		return v.Frozen != nil && *v.Frozen
+	case SetValue:
+		return v.Frozen != nil && *v.Frozen
+	case ObjectVal:
+		return v.Frozen != nil && *v.Frozen
+	}
+
+	return false
+}
+
+// Freezes a value, and everything it holds. Values that are already frozen are skipped, so
+// values holding themselves don't loop forever.
+func Freeze(value RuntimeValue) RuntimeValue {
+
+	if IsFrozen(value) {
+		return value
+	}
+
+	switch v := value.(type) {
+	case ArrayValue:
+
+		if v.Frozen != nil {
+			*v.Frozen = true
+		}
+
+		for _, el := range *v.Value {
+			Freeze(el)
+		}
+	case MapValue:
+
+		if v.Frozen != nil {
+			*v.Frozen = true
+		}
+
+		for _, entry := range v.Value.Entries() {
+			Freeze(entry.Key)
+			Freeze(entry.Value)
+		}
+	case SetValue:
+
+		if v.Frozen != nil {
+			*v.Frozen = true
+		}
+
+		for _, el := range *v.Value {
+			Freeze(el)
+		}
+	case ObjectVal:
+
+		if v.Frozen != nil {
+			*v.Frozen = true
+		}
+
+		for _, prop := range v.Properties {
+			Freeze(prop)
+		}
+	}
+
+	return value
+}
+
+// Returns a shallow copy of an array, map, set or object, any other value is returned as is.
+func CopyValue(value RuntimeValue) RuntimeValue {
+
+	switch v := value.(type) {
+	case ArrayValue:
+
+		elements := make([]RuntimeValue, len(*v.Value))
+		copy(elements, *v.Value)
+
+		return MK_ARRAY(elements)
+	case MapValue:
+
+		m := emptyLike(v.Value)
+		for _, entry := range v.Value.Entries() {
+			m.Set(entry.Key, entry.Value)
+		}
+
+		return MK_MAP(m)
+	case SetValue:
+
+		elements := make(map[string]RuntimeValue, len(*v.Value))
+		for key, el := range *v.Value {
+			elements[key] = el
+		}
+
+		return MK_SET(elements)
+	case ObjectVal:
+
+		props := make(map[string]RuntimeValue, len(v.Properties))
+		for name, prop := range v.Properties {
+			props[name] = prop
+		}
+
+		return ObjectVal{Type: v.Type, Properties: props, Frozen: new(bool)}
+	}
+
+	return value
+}
+
+// Copies a value and everything it holds. Copies already made are looked up by the storage
+// they were made from, so values holding themselves are copied once.
+func deepCopy(value RuntimeValue, seen map[any]RuntimeValue) RuntimeValue {
+
+	switch v := value.(type) {
+	case ArrayValue:
+
+		if c, done := seen[v.Value]; done {
+			return c
+		}
+
+		elements := make([]RuntimeValue, len(*v.Value))
+		arr := MK_ARRAY(elements)
+		seen[v.Value] = arr
+
+		for i, el := range *v.Value {
+			(*arr.Value)[i] = deepCopy(el, seen)
+		}
+
+		return arr
+	case MapValue:
+
+		if c, done := seen[v.Value]; done {
+			return c
+		}
+
+		m := MK_MAP(emptyLike(v.Value))
+		seen[v.Value] = m
+
+		// Keys are hashed by value, so a copy finds the same entry.
+		for _, entry := range v.Value.Entries() {
+			m.Value.Set(deepCopy(entry.Key, seen), deepCopy(entry.Value, seen))
+		}
+
+		return m
+	case SetValue:
+
+		if c, done := seen[v.Value]; done {
+			return c
+		}
+
+		set := MK_SET(make(map[string]RuntimeValue, len(*v.Value)))
+		seen[v.Value] = set
+
+		for key, el := range *v.Value {
+			(*set.Value)[key] = deepCopy(el, seen)
+		}
+
+		return set
+	case ObjectVal:
+
+		props := make(map[string]RuntimeValue, len(v.Properties))
+		for name, prop := range v.Properties {
+			props[name] = deepCopy(prop, seen)
+		}
+
+		return ObjectVal{Type: v.Type, Properties: props, Frozen: new(bool)}
+	}
+
+	return value
+}
+
+// Returns an empty map that orders its entries the same way as m.
+func emptyLike(m *HashMap) *HashMap {
+
+	if m.Sorted() {
+		return NewSortedHashMap()
+	}
+
+	return NewHashMap()
+}
+
+// The error returned when a native tries to modify a frozen value.
+func frozenError(name string, value RuntimeValue) error {
+	return fmt.Errorf("%v cannot modify a frozen %v", name, TypeName(value))
+}
@@ -0,0 +1,361 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Returns the canonical key for a value. Values that are equal always share a key, regardless
+// of how they were made, so 1 and a big int holding 1 share a key, as do two arrays with the
+// same contents. Functions, namespaces, file objects and arrays or maps holding themselves
+// can't be hashed.
+func HashKey(value RuntimeValue) (string, error) {
+
+	builder := strings.Builder{}
+
+	if err := writeHashKey(&builder, value, false, map[any]bool{}); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+// Writes the key for a value into the builder. Every part is tagged with its type, and
+// variable length parts are prefixed with their length, so different values can't collide.
+// When byIdentity is set, values holding shared state, i.e. channels and file objects, are
+// keyed by the state they hold rather than refused, so they only equal themselves. Open
+// holds the storage of the arrays and maps being written, to refuse those holding themselves.
+func writeHashKey(builder *strings.Builder, value RuntimeValue, byIdentity bool, open map[any]bool) error {
+
+	switch v := value.(type) {
+	case NullValue:
+		builder.WriteString("n")
+	case BooleanValue:
+		if v.Value {
+			builder.WriteString("t")
+		} else {
+			builder.WriteString("f")
+		}
+	case NumberValue:
+		fmt.Fprintf(builder, "i%v;", v.Value)
+	case BigIntValue:
+		fmt.Fprintf(builder, "i%v;", v.Value.String())
+	case StringValue:
+		fmt.Fprintf(builder, "s%v:%v", len(v.Value), v.Value)
+	case BytesValue:
+		fmt.Fprintf(builder, "b%v:%v", len(v.Value), string(v.Value))
+	case ArrayValue:
+
+		if open[v.Value] {
+			return cyclicError(byIdentity)
+		}
+
+		open[v.Value] = true
+		defer delete(open, v.Value)
+
+		fmt.Fprintf(builder, "a%v[", len(*v.Value))
+		for _, el := range *v.Value {
+			if err := writeHashKey(builder, el, byIdentity, open); err != nil {
+				return err
+			}
+		}
+		builder.WriteString("]")
+	case SetValue:
+
+		// Already stored by key, so only the order needs fixing.
+		keys := make([]string, 0, len(*v.Value))
+		for key := range *v.Value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintf(builder, "S%v{%v}", len(keys), strings.Join(keys, ""))
+	case MapValue:
+
+		if open[v.Value] {
+			return cyclicError(byIdentity)
+		}
+
+		open[v.Value] = true
+		defer delete(open, v.Value)
+
+		entries := make([]string, 0, v.Value.Len())
+		for _, entry := range v.Value.Entries() {
+
+			val := strings.Builder{}
+			if err := writeHashKey(&val, entry.Value, byIdentity, open); err != nil {
+				return err
+			}
+
+			entries = append(entries, entry.Hash+val.String())
+		}
+		sort.Strings(entries)
+
+		fmt.Fprintf(builder, "m%v{%v}", len(entries), strings.Join(entries, ""))
+	case ObjectVal:
+
+		props := make([]string, 0, len(v.Properties))
+		for name, prop := range v.Properties {
+
+			val := strings.Builder{}
+			if err := writeHashKey(&val, prop, byIdentity, open); err != nil {
+				return err
+			}
+
+			props = append(props, fmt.Sprintf("%v:%v%v", len(name), name, val.String()))
+		}
+		sort.Strings(props)
+
+		fmt.Fprintf(builder, "o%v{%v}", len(props), strings.Join(props, ""))
+	case ChannelValue:
+
+		if !byIdentity {
+			return fmt.Errorf("unhashable type %v", TypeName(value))
+		}
+
+		fmt.Fprintf(builder, "c%p", v.Value)
+	case WaitGroupValue:
+
+		if !byIdentity {
+			return fmt.Errorf("unhashable type %v", TypeName(value))
+		}
+
+		fmt.Fprintf(builder, "w%p", v.Value)
+	case MutexValue:
+
+		if !byIdentity {
+			return fmt.Errorf("unhashable type %v", TypeName(value))
+		}
+
+		fmt.Fprintf(builder, "x%p", v.Value)
+	case FileObjectValue:
+
+		if !byIdentity {
+			return fmt.Errorf("unhashable type %v", TypeName(value))
+		}
+
+		fmt.Fprintf(builder, "F%p", v.File)
+	default:
+
+		// Functions and iterators hold no state of their own to tell them apart by.
+		if byIdentity {
+			return fmt.Errorf("cannot compare %v values", TypeName(value))
+		}
+
+		return fmt.Errorf("unhashable type %v", TypeName(value))
+	}
+
+	return nil
+}
+
+// Raised for an array or map holding itself, which has no end to write a key for.
+func cyclicError(byIdentity bool) error {
+
+	if byIdentity {
+		return fmt.Errorf("cannot compare cyclic value")
+	}
+
+	return fmt.Errorf("unhashable cyclic value")
+}
+
+// Compares two values by value, rather than by identity. Channels, wait groups, mutexes and
+// file objects are only equal to themselves. Values of different types are never equal, and
+// functions, iterators and arrays or maps holding themselves can't be compared with each other.
+func ValuesEqual(a RuntimeValue, b RuntimeValue) (bool, error) {
+
+	if TypeName(a) != TypeName(b) {
+		return false, nil
+	}
+
+	ka := strings.Builder{}
+	if err := writeHashKey(&ka, a, true, map[any]bool{}); err != nil {
+		return false, err
+	}
+
+	kb := strings.Builder{}
+	if err := writeHashKey(&kb, b, true, map[any]bool{}); err != nil {
+		return false, err
+	}
+
+	return ka.String() == kb.String(), nil
+}
+
+// A key and value pair held by a map.
+type MapEntry struct {
+	Hash  string
+	Key   RuntimeValue
+	Value RuntimeValue
+}
+
+// The storage behind a map, keyed by the hash of each key so lookups work by value. Entries
+// keep the order they were first inserted in, unless the map is sorted, in which case they are
+// kept in key order.
+type HashMap struct {
+	entries map[string]MapEntry
+	order   []string
+	sorted  bool
+}
+
+func NewHashMap() *HashMap {
+	return &HashMap{entries: map[string]MapEntry{}}
+}
+
+// Returns a map that keeps its entries in key order, see SortedElements for the order used.
+func NewSortedHashMap() *HashMap {
+	return &HashMap{entries: map[string]MapEntry{}, sorted: true}
+}
+
+// Returns true if the map keeps its entries in key order.
+func (m *HashMap) Sorted() bool {
+	return m.sorted
+}
+
+// Returns the value stored under the key, and whether it was there.
+func (m *HashMap) Get(key RuntimeValue) (RuntimeValue, bool, error) {
+
+	hash, err := HashKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	entry, exists := m.entries[hash]
+	return entry.Value, exists, nil
+}
+
+// Stores the value under the key, replacing any value already there.
+func (m *HashMap) Set(key RuntimeValue, value RuntimeValue) error {
+
+	hash, err := HashKey(key)
+	if err != nil {
+		return err
+	}
+
+	// Replacing a value keeps the key where it was, and the key it was stored under.
+	if entry, exists := m.entries[hash]; exists {
+		key = entry.Key
+	} else {
+		m.order = append(m.order, hash)
+		key = frozenKey(key)
+	}
+
+	m.entries[hash] = MapEntry{Hash: hash, Key: key, Value: value}
+	return nil
+}
+
+// Returns a frozen copy of a key holding other values, i.e. an array, so changing the value
+// it was made from can't change the key stored under its hash. Other keys are returned as is.
+func frozenKey(key RuntimeValue) RuntimeValue {
+
+	switch key.(type) {
+	case ArrayValue, MapValue, SetValue, ObjectVal:
+		return Freeze(deepCopy(key, map[any]RuntimeValue{}))
+	}
+
+	return key
+}
+
+// Removes the key, returns true if it was there.
+func (m *HashMap) Delete(key RuntimeValue) (bool, error) {
+
+	hash, err := HashKey(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, exists := m.entries[hash]
+	if !exists {
+		return false, nil
+	}
+
+	delete(m.entries, hash)
+
+	for i, h := range m.order {
+		if h == hash {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+
+	return true, nil
+}
+
+// Returns the number of entries in the map.
+func (m *HashMap) Len() int {
+	return len(m.entries)
+}
+
+// Returns every entry in the map, in insertion order, or key order for a sorted map.
+func (m *HashMap) Entries() []MapEntry {
+
+	entries := make([]MapEntry, 0, len(m.order))
+	for _, hash := range m.order {
+		entries = append(entries, m.entries[hash])
+	}
+
+	if m.sorted {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return lessValue(entries[i].Key, entries[j].Key, entries[i].Hash, entries[j].Hash)
+		})
+	}
+
+	return entries
+}
+
+// Returns the elements of a set in a stable order: bools, then ints, then strings, each
+// sorted by value, then anything else sorted by its hash key. Used for printing and iteration.
+func SortedElements(set SetValue) []RuntimeValue {
+
+	type element struct {
+		key   string
+		value RuntimeValue
+	}
+
+	elements := make([]element, 0, len(*set.Value))
+	for key, el := range *set.Value {
+		elements = append(elements, element{key: key, value: el})
+	}
+
+	sort.Slice(elements, func(i, j int) bool {
+		return lessValue(elements[i].value, elements[j].value, elements[i].key, elements[j].key)
+	})
+
+	sorted := make([]RuntimeValue, 0, len(elements))
+	for _, el := range elements {
+		sorted = append(sorted, el.value)
+	}
+
+	return sorted
+}
+
+// Orders two values for sorted sets and maps: bools, then ints, then strings, each sorted by
+// value, then anything else sorted by its hash key.
+func lessValue(a RuntimeValue, b RuntimeValue, aKey string, bKey string) bool {
+
+	rank := func(v RuntimeValue) int {
+		switch v.(type) {
+		case BooleanValue:
+			return 0
+		case NumberValue, BigIntValue:
+			return 1
+		case StringValue:
+			return 2
+		}
+		return 3
+	}
+
+	if rank(a) != rank(b) {
+		return rank(a) < rank(b)
+	}
+
+	switch x := a.(type) {
+	case BooleanValue:
+		return !x.Value && b.(BooleanValue).Value
+	case NumberValue, BigIntValue:
+		return ToBigInt(a).Cmp(ToBigInt(b)) < 0
+	case StringValue:
+		return x.Value < b.(StringValue).Value
+	}
+
+	return aKey < bKey
+}
@@ -2,17 +2,36 @@ package runtime
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
 
 	"goblin.org/main/frontend/ast"
 )
 
+// Evaluates a node, any error raised is given the location of the node it came from.
 func Evaluate(astNode ast.Expression, env Environment) (RuntimeValue, error) {
 
+	value, err := evaluate(astNode, env)
+	if err != nil {
+		return value, locate(err, astNode, env)
+	}
+
+	return value, nil
+}
+
+func evaluate(astNode ast.Expression, env Environment) (RuntimeValue, error) {
+
 	// Check the type of the expression coming in for resolution later on.
 	if value, ok := astNode.(ast.NumericLiteral); ok {
 
 		return MK_NUMBER(value.Value), nil
 
+	} else if value, ok := astNode.(ast.BigIntLiteral); ok {
+
+		return MK_INTEGER(new(big.Int).Set(value.Value)), nil
+
 	} else if sho, ok := astNode.(ast.ShorthandOperator); ok {
 
 		shoVal, err := eval_shorthand_operator_expression(sho, env)
@@ -49,6 +68,15 @@ func Evaluate(astNode ast.Expression, env Environment) (RuntimeValue, error) {
 
 		return for_, err
 
+	} else if f, ok := astNode.(ast.ForInLoop); ok {
+
+		forIn, err := eval_for_in_expression(f, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return forIn, err
+
 	} else if str, ok := astNode.(ast.StringLiteral); ok {
 
 		str, err := eval_string_expression(str, env)
@@ -58,6 +86,11 @@ func Evaluate(astNode ast.Expression, env Environment) (RuntimeValue, error) {
 
 		return str, err
 
+	} else if b, ok := astNode.(ast.BytesLiteral); ok {
+
+		// Copied, so the literal in the AST can't be changed through the value.
+		return MK_BYTES(append([]byte{}, b.Value...)), nil
+
 	} else if b, ok := astNode.(ast.BooleanLiteral); ok {
 
 		boolean := BooleanValue{
@@ -103,6 +136,33 @@ func Evaluate(astNode ast.Expression, env Environment) (RuntimeValue, error) {
 
 		return aom, nil
 
+	} else if slice, ok := astNode.(ast.SliceExpr); ok {
+
+		sliced, err := eval_slice_expression(slice, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return sliced, nil
+
+	} else if arr, ok := astNode.(ast.ArrayLiteral); ok {
+
+		array, err := eval_array_literal(arr, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return array, nil
+
+	} else if u, ok := astNode.(ast.UnaryExpr); ok {
+
+		unary, err := eval_unary_expression(u, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return unary, nil
+
 	} else if object, ok := astNode.(ast.ObjectLiteral); ok {
 
 		obj, err := eval_object_expr(object, env)
@@ -139,6 +199,15 @@ func Evaluate(astNode ast.Expression, env Environment) (RuntimeValue, error) {
 
 		return varDec, nil
 
+	} else if d, ok := astNode.(ast.DestructuringDecleration); ok {
+
+		destructured, err := eval_destructuring_decleration(d, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return destructured, nil
+
 	} else if arr, ok := astNode.(ast.ArrayDecleration); ok {
 
 		arrDec, err := eval_arr_decleration(arr, env)
@@ -182,6 +251,49 @@ func Evaluate(astNode ast.Expression, env Environment) (RuntimeValue, error) {
 		}
 
 		return using, nil
+	} else if d, ok := astNode.(ast.DeferStatement); ok {
+
+		deferred, err := eval_defer_statement(d, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return deferred, nil
+	} else if r, ok := astNode.(ast.ReturnStatement); ok {
+
+		ret, err := eval_return_statement(r, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return ret, nil
+	} else if s, ok := astNode.(ast.SpawnStatement); ok {
+
+		spawn, err := eval_spawn_statement(s, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return spawn, nil
+	} else if e, ok := astNode.(ast.ExportStatement); ok {
+
+		export, err := eval_export_statement(e, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return export, nil
+	} else if y, ok := astNode.(ast.YieldStatement); ok {
+
+		yield, err := eval_yield_statement(y, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return yield, nil
+	} else if _, ok := astNode.(ast.SpreadExpr); ok {
+
+		return nil, fmt.Errorf("spread syntax can only be used in function call args")
 	} else if mem, ok := astNode.(ast.MemberExpr); ok {
 
 		member, err := eval_member_expression(mem, env)
@@ -192,7 +304,7 @@ func Evaluate(astNode ast.Expression, env Environment) (RuntimeValue, error) {
 		return member, nil
 	}
 
-	return nil, fmt.Errorf("unrecognised node in source %v", astNode)
+	return nil, fmt.Errorf("unrecognised node in source: %v", ast.Describe(astNode))
 }
 
 // Interpreter entry. Evaluates an entire program.
@@ -215,8 +327,13 @@ func eval_program(prog ast.Program, env Environment) (RuntimeValue, error) {
 
 // Evaluates the provided identifier.
 func eval_identifier(iden ast.Identifier, env Environment) (RuntimeValue, error) {
+	return identifier_value(iden.Symbol, env)
+}
+
+// Returns the value of a variable, or the namespace of that name.
+func identifier_value(name string, env Environment) (RuntimeValue, error) {
 
-	val, err := env.Lookup(iden.Symbol)
+	val, err := env.Lookup(name)
 	if err != nil {
 		return nil, err
 	}
@@ -227,7 +344,7 @@ func eval_identifier(iden ast.Identifier, env Environment) (RuntimeValue, error)
 	} else {
 
 		// Potentially a Namespace?
-		namespace, err := env.LookupNamespace(iden.Symbol)
+		namespace, err := env.LookupNamespace(name)
 		if err != nil {
 			return nil, err
 		}
@@ -252,10 +369,164 @@ func eval_array_or_map_identifier(arr ast.ArrayOrMapIdentifier, env Environment)
 	return ds, nil
 }
 
+// Evaluates a slice of an array or string, e.g. arr[1:3]. Always returns a new value.
+func eval_slice_expression(slice ast.SliceExpr, env Environment) (RuntimeValue, error) {
+
+	value, err := env.Lookup(slice.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return slice_value(value, func(length int) (int, int, error) {
+		return slice_bounds(slice, length, env)
+	})
+}
+
+// Slices an array, string or bytes. The bounds are only resolved once the length of what is
+// being sliced is known.
+func slice_value(value RuntimeValue, bounds func(length int) (int, int, error)) (RuntimeValue, error) {
+
+	switch v := value.(type) {
+	case ArrayValue:
+
+		start, end, err := bounds(len(*v.Value))
+		if err != nil {
+			return nil, err
+		}
+
+		elements := make([]RuntimeValue, end-start)
+		copy(elements, (*v.Value)[start:end])
+
+		return MK_ARRAY(elements), nil
+	case StringValue:
+
+		// Slice by character rather than by byte.
+		runes := []rune(v.Value)
+
+		start, end, err := bounds(len(runes))
+		if err != nil {
+			return nil, err
+		}
+
+		return MK_STRING(string(runes[start:end])), nil
+	case BytesValue:
+
+		start, end, err := bounds(len(v.Value))
+		if err != nil {
+			return nil, err
+		}
+
+		return MK_BYTES(append([]byte{}, v.Value[start:end]...)), nil
+	}
+
+	return nil, fmt.Errorf("cannot slice %v", TypeName(value))
+}
+
+// Resolves the start and end of a slice against the length of what is being sliced. Open
+// ends default to the start or end, and negative indices count back from the end.
+func slice_bounds(slice ast.SliceExpr, length int, env Environment) (int, int, error) {
+
+	start, end := 0, length
+
+	bound := func(expr ast.Expression, fallback int) (int, error) {
+
+		if expr == nil {
+			return fallback, nil
+		}
+
+		value, err := Evaluate(expr, env)
+		if err != nil {
+			return 0, err
+		}
+
+		return slice_index(value, length)
+	}
+
+	start, err := bound(slice.Start, start)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end, err = bound(slice.End, end)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return start, end, check_slice_range(start, end, length)
+}
+
+// Resolves a single bound of a slice, negative indices count back from the end.
+func slice_index(value RuntimeValue, length int) (int, error) {
+
+	index, ok := value.(NumberValue)
+	if !ok {
+		return 0, fmt.Errorf("slice index must be of type int")
+	}
+
+	if index.Value < 0 {
+		return index.Value + length, nil
+	}
+
+	return index.Value, nil
+}
+
+// Checks a slice lies within something of the given length.
+func check_slice_range(start int, end int, length int) error {
+
+	if start < 0 || end > length || start > end {
+		return fmt.Errorf("slice bounds out of range [%v:%v] with length %v", start, end, length)
+	}
+
+	return nil
+}
+
+// Evaluates an array used as a value, e.g. the rhs of 'arr[1:3] = [4, 5];'.
+func eval_array_literal(arr ast.ArrayLiteral, env Environment) (RuntimeValue, error) {
+
+	values := make([]RuntimeValue, 0)
+
+	for _, el := range arr.Elements {
+
+		v, err := Evaluate(el, env)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, v)
+	}
+
+	return MK_ARRAY(values), nil
+}
+
+// Evaluates a prefix operator, e.g. -1 or ~flags.
+func eval_unary_expression(u ast.UnaryExpr, env Environment) (RuntimeValue, error) {
+
+	arg, err := Evaluate(u.Argument, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return unary_operation(arg, u.Operator)
+}
+
+func unary_operation(arg RuntimeValue, operator string) (RuntimeValue, error) {
+
+	if !IsInteger(arg) {
+		return nil, fmt.Errorf("cannot use unary operator `%v` on %v", operator, TypeName(arg))
+	}
+
+	if operator == "~" {
+		// Bitwise complement.
+		return MK_INTEGER(new(big.Int).Not(ToBigInt(arg))), nil
+	}
+
+	return MK_INTEGER(new(big.Int).Neg(ToBigInt(arg))), nil
+}
+
 // Evaluates complex object assignments such as 'let foo = {x: 10};'
 func eval_object_expr(obj ast.ObjectLiteral, env Environment) (RuntimeValue, error) {
 
-	object := ObjectVal{Type: "Object", Properties: map[string]RuntimeValue{}}
+	object := ObjectVal{Type: "Object", Properties: map[string]RuntimeValue{}, Frozen: new(bool)}
 
 	for _, prop := range obj.Properties {
 
@@ -294,7 +565,7 @@ func eval_ternary_expression(t ast.TernaryCondition, env Environment) (RuntimeVa
 
 	boolean, isBoolean := cond.(BooleanValue)
 	if !isBoolean {
-		return nil, fmt.Errorf("ternary expressions should evaluate to a boolean value, got %v", cond)
+		return nil, fmt.Errorf("ternary expressions should evaluate to a boolean value, got %v", TypeName(cond))
 	}
 
 	// Default to do nothing if this is just an empty function definition.
@@ -339,17 +610,9 @@ func eval_while_expression(w ast.WhileLoop, env Environment) (RuntimeValue, erro
 			return nil, err
 		}
 
-		lhs, ok1 := left.(NumberValue)
-		rhs, ok2 := right.(NumberValue)
-
-		if ok1 && ok2 {
-			b, err := eval_numeric_boolean_expression(lhs, rhs, binop.Operator)
-			isConditionTrue = b.Value
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			return nil, fmt.Errorf("conditions must be of same type, got %v %v", lhs, rhs)
+		isConditionTrue, err = while_condition(left, right, binop.Operator)
+		if err != nil {
+			return nil, err
 		}
 	} else if isBool {
 		isConditionTrue = boolean.Value
@@ -360,17 +623,17 @@ func eval_while_expression(w ast.WhileLoop, env Environment) (RuntimeValue, erro
 			return nil, err
 		}
 
-		boolean, ok := b.(BooleanValue)
-		if !ok {
-			return nil, fmt.Errorf("if statement expressions must evaluate to a bool value, got %v", b)
+		isConditionTrue, err = bool_condition(b)
+		if err != nil {
+			return nil, err
 		}
-
-		isConditionTrue = boolean.Value
 	}
 
 	// Do we evaluate the conditional body or not?
 	if isConditionTrue {
 
+		env.Scheduler.schedule()
+
 		for _, stmt := range w.Body {
 
 			_, err := Evaluate(stmt, env)
@@ -388,15 +651,49 @@ func eval_while_expression(w ast.WhileLoop, env Environment) (RuntimeValue, erro
 	return MK_NULL(), nil
 }
 
+// Decides a binary while condition from its already evaluated operands. Ints are compared
+// by the operator, anything else can only be compared by value.
+func while_condition(left RuntimeValue, right RuntimeValue, operator string) (bool, error) {
+
+	if IsInteger(left) && IsInteger(right) {
+		b, err := eval_numeric_boolean_expression(left, right, operator)
+		return b.Value, err
+	}
+
+	if operator == "==" || operator == "!=" {
+		b, err := eval_value_boolean_expression(left, right, operator)
+		return b.Value, err
+	}
+
+	return false, fmt.Errorf("conditions must be of same type, got %v %v", TypeName(left), TypeName(right))
+}
+
+// Decides a condition written as a lone variable, which must hold a bool.
+func bool_condition(value RuntimeValue) (bool, error) {
+
+	boolean, ok := value.(BooleanValue)
+	if !ok {
+		return false, fmt.Errorf("if statement expressions must evaluate to a bool value, got %v", TypeName(value))
+	}
+
+	return boolean.Value, nil
+}
+
 // Evaluates a standard for loop.
 func eval_for_expression(f ast.ForLoop, env Environment) (RuntimeValue, error) {
 
 	// Scope of for loop.
 	newScope := Environment{
-		Stdout:    env.Stdout,
-		Parent:    &env,
-		Constants: map[string]bool{},
-		Variables: map[string]RuntimeValue{},
+		Stdout:        env.Stdout,
+		Stdin:         env.Stdin,
+		EntryLocation: env.EntryLocation,
+		Audit:         env.Audit,
+		Stack:         env.Stack,
+		Modules:       env.Modules,
+		Scheduler:     env.Scheduler,
+		Parent:        &env,
+		Constants:     map[string]bool{},
+		Variables:     map[string]RuntimeValue{},
 	}
 
 	_, err := eval_var_decleration(f.Assignment, newScope)
@@ -415,8 +712,6 @@ func eval_for_expression(f ast.ForLoop, env Environment) (RuntimeValue, error) {
 // Evaluates the provided body of a for loop.
 func eval_for_body(binop ast.BinaryExpr, body []ast.Expression, sho ast.ShorthandOperator, env Environment) (RuntimeValue, error) {
 
-	var isConditionTrue bool
-
 	left, err := Evaluate(binop.Left, env)
 	if err != nil {
 		return nil, err
@@ -427,27 +722,24 @@ func eval_for_body(binop ast.BinaryExpr, body []ast.Expression, sho ast.Shorthan
 		return nil, err
 	}
 
-	lhs, ok1 := left.(NumberValue)
-	rhs, ok2 := right.(NumberValue)
-
-	if ok1 && ok2 {
-		b, err := eval_numeric_boolean_expression(lhs, rhs, binop.Operator)
-		isConditionTrue = b.Value
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	// Is the for condition still true?
-	if isConditionTrue {
+	if for_condition(left, right, binop.Operator) {
+
+		env.Scheduler.schedule()
 
 		// Scope of for loop.
 		// Each iteration of the loop is distinct from all previous iterations.
 		iterationSpecificEnv := Environment{
-			Stdout:    env.Stdout,
-			Parent:    &env,
-			Constants: map[string]bool{},
-			Variables: map[string]RuntimeValue{},
+			Stdout:        env.Stdout,
+			Stdin:         env.Stdin,
+			EntryLocation: env.EntryLocation,
+			Audit:         env.Audit,
+			Stack:         env.Stack,
+			Modules:       env.Modules,
+			Scheduler:     env.Scheduler,
+			Parent:        &env,
+			Constants:     map[string]bool{},
+			Variables:     map[string]RuntimeValue{},
 		}
 
 		for _, stmt := range body {
@@ -474,131 +766,388 @@ func eval_for_body(binop ast.BinaryExpr, body []ast.Expression, sho ast.Shorthan
 	return MK_NULL(), nil
 }
 
-func eval_shorthand_operator_expression(sho ast.ShorthandOperator, env Environment) (RuntimeValue, error) {
+// Decides a for condition from its already evaluated operands. Only ints are compared, the
+// loop ends on anything else.
+func for_condition(left RuntimeValue, right RuntimeValue, operator string) bool {
 
-	left, err := env.Lookup(sho.Left)
-	if err != nil {
-		return nil, err
+	if IsInteger(left) && IsInteger(right) {
+		b, _ := eval_numeric_boolean_expression(left, right, operator)
+		return b.Value
 	}
 
-	lhs, lok := left.(NumberValue)
+	return false
+}
 
-	if lok {
+// Evaluates a for ... in loop, i.e. for (x in arr) { ... }
+func eval_for_in_expression(f ast.ForInLoop, env Environment) (RuntimeValue, error) {
 
-		currentValue := lhs.Value
+	iterable, err := Evaluate(f.Iterable, env)
+	if err != nil {
+		return nil, err
+	}
 
-		if sho.Operator == "++" || sho.Operator == "--" {
-			// Simple Shorthand (x++;)
+	it, err := ToIterator(iterable)
+	if err != nil {
+		return nil, err
+	}
 
-			if sho.Operator == "++" {
-				currentValue++
-			} else {
-				currentValue--
-			}
+	// Generators left part way through, i.e. by a 'return', are stopped.
+	defer it.Close()
 
-		} else {
-			// Complex Shorthand (x += 1;)
+	for {
 
-			right, err := Evaluate(sho.Right, env)
-			if err != nil {
-				return nil, err
-			}
+		item, ok, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
 
-			rhs, rok := right.(NumberValue)
-			if rok {
-
-				if sho.Operator == "*=" {
-					currentValue *= rhs.Value
-				} else if sho.Operator == "/=" {
-					currentValue /= rhs.Value
-				} else if sho.Operator == "%=" {
-					currentValue %= rhs.Value
-				} else if sho.Operator == "+=" {
-					currentValue += rhs.Value
-				} else if sho.Operator == "-=" {
-					currentValue -= rhs.Value
-				}
+		if !ok {
+			break
+		}
 
-			} else {
-				return nil, fmt.Errorf("invalid type used for operator %v", sho.Operator)
-			}
+		env.Scheduler.schedule()
+
+		// Scope of for loop.
+		// Each iteration of the loop is distinct from all previous iterations.
+		iterationSpecificEnv := Environment{
+			Stdout:        env.Stdout,
+			Stdin:         env.Stdin,
+			EntryLocation: env.EntryLocation,
+			Audit:         env.Audit,
+			Stack:         env.Stack,
+			Modules:       env.Modules,
+			Scheduler:     env.Scheduler,
+			Parent:        &env,
+			Constants:     map[string]bool{},
+			Variables:     map[string]RuntimeValue{},
 		}
 
-		// Update the value of the initial variable.
-		newValue, err := env.Update(sho.Left, MK_NUMBER(currentValue))
+		err = bind_element(f.Binding, item, iterationSpecificEnv, false)
 		if err != nil {
 			return nil, err
 		}
 
-		return newValue, nil
+		for _, stmt := range f.Body {
 
+			_, err := Evaluate(stmt, iterationSpecificEnv)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	return nil, fmt.Errorf("invalid operator %v", sho.Operator)
+	return MK_NULL(), nil
 }
 
-// Evaluates an if condition, i.e. if (10 > 5) { ... }
-func eval_if_condition_expression(iif ast.IfCondition, env Environment) (RuntimeValue, error) {
+// Returns the items a for ... in loop visits: the elements of an array, the keys of a map,
+// or the characters of a string.
+func iteration_values(iterable RuntimeValue) ([]RuntimeValue, error) {
 
-	// We need to determine the type of the if's condition.
-	binop, isBinop := iif.Condition.(ast.BinaryExpr)
-	boolean, isBool := iif.Condition.(ast.BooleanLiteral)
-	iden, isIden := iif.Condition.(ast.Identifier)
+	items := make([]RuntimeValue, 0)
 
-	var isConditionTrue bool
+	if arr, ok := iterable.(ArrayValue); ok {
 
-	if isBinop {
+		// Copied, so pushing to the array inside the loop doesn't extend it.
+		items = append(items, *arr.Value...)
 
-		left, err := Evaluate(binop.Left, env)
-		if err != nil {
-			return nil, err
-		}
+	} else if m, ok := iterable.(MapValue); ok {
 
-		right, err := Evaluate(binop.Right, env)
-		if err != nil {
-			return nil, err
+		for _, entry := range m.Value.Entries() {
+			items = append(items, entry.Key)
 		}
 
-		lhi, ok1i := left.(NumberValue)
-		rhi, ok2i := right.(NumberValue)
+	} else if str, ok := iterable.(StringValue); ok {
 
-		lhs, ok1s := left.(StringValue)
-		rhs, ok2s := right.(StringValue)
+		for _, r := range str.Value {
+			items = append(items, MK_STRING(string(r)))
+		}
 
-		// Int comparision.
-		if ok1i && ok2i {
-			b, err := eval_numeric_boolean_expression(lhi, rhi, binop.Operator)
-			isConditionTrue = b.Value
-			if err != nil {
-				return nil, err
-			}
-		} else if ok1s && ok2s {
-			// String comparision.
+	} else if set, ok := iterable.(SetValue); ok {
 
-			b, err := eval_string_boolean_expression(lhs, rhs, binop.Operator)
-			isConditionTrue = b.Value
-			if err != nil {
-				return nil, err
-			}
+		items = append(items, SortedElements(set)...)
 
-		} else {
-			return nil, fmt.Errorf("conditions must be of same type, got %v %v", lhs, rhs)
+	} else if b, ok := iterable.(BytesValue); ok {
+
+		for _, c := range b.Value {
+			items = append(items, MK_NUMBER(int(c)))
 		}
-	} else if isBool {
-		isConditionTrue = boolean.Value
-	} else if isIden {
 
-		b, err := env.Lookup(iden.Symbol)
-		if err != nil {
+	} else {
+		return nil, fmt.Errorf("cannot iterate over %v", TypeName(iterable))
+	}
+
+	return items, nil
+}
+
+// Evaluates a destructuring decleration, i.e. let [a, b] = arr;
+func eval_destructuring_decleration(dec ast.DestructuringDecleration, env Environment) (RuntimeValue, error) {
+
+	value, err := Evaluate(dec.Value, env)
+	if err != nil {
+		return nil, err
+	}
+
+	err = destructure(dec.Pattern, value, env, dec.Constant)
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// Declares the names bound by a pattern and evaluates any default values needed along the
+// way, so the same destructuring works however the names are stored.
+type binder struct {
+	declare  func(name string, value RuntimeValue) error
+	evaluate func(expr ast.Expression) (RuntimeValue, error)
+}
+
+// Binds names into a scope, evaluating defaults in that same scope.
+func scope_binder(env Environment, isConst bool) binder {
+
+	return binder{
+		declare: func(name string, value RuntimeValue) error {
+			_, err := env.Declare(name, value, isConst)
+			return err
+		},
+		evaluate: func(expr ast.Expression) (RuntimeValue, error) {
+			return Evaluate(expr, env)
+		},
+	}
+}
+
+// Binds a value to a single pattern element, destructuring it further if it is a nested pattern.
+func bind_element(element ast.PatternElement, value RuntimeValue, env Environment, isConst bool) error {
+	return bind_element_with(element, value, scope_binder(env, isConst))
+}
+
+// Declares each of the names in a destructuring pattern, taken from an array or a map/object.
+func destructure(pattern ast.Pattern, value RuntimeValue, env Environment, isConst bool) error {
+	return destructure_with(pattern, value, scope_binder(env, isConst))
+}
+
+func bind_element_with(element ast.PatternElement, value RuntimeValue, b binder) error {
+
+	if element.Pattern != nil {
+		return destructure_with(*element.Pattern, value, b)
+	}
+
+	return b.declare(element.Name, value)
+}
+
+func destructure_with(pattern ast.Pattern, value RuntimeValue, b binder) error {
+
+	if pattern.Kind == ast.ArrayPatternNode {
+
+		arr, isArr := value.(ArrayValue)
+		if !isArr {
+			return fmt.Errorf("cannot destructure %v with array pattern %v", TypeName(value), pattern)
+		}
+
+		elements := *arr.Value
+
+		for i, element := range pattern.Elements {
+
+			// Everything left over goes into the rest element.
+			if element.Rest {
+
+				rest := make([]RuntimeValue, 0)
+				if i < len(elements) {
+					rest = append(rest, elements[i:]...)
+				}
+
+				return bind_element_with(element, MK_ARRAY(rest), b)
+			}
+
+			var v RuntimeValue
+
+			if i < len(elements) {
+				v = elements[i]
+			} else if element.Default != nil {
+
+				def, err := b.evaluate(element.Default)
+				if err != nil {
+					return err
+				}
+
+				v = def
+			} else {
+				return fmt.Errorf("missing value for '%v' in array pattern %v, array has %v elements", element, pattern, len(elements))
+			}
+
+			err := bind_element_with(element, v, b)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(elements) > len(pattern.Elements) {
+			return fmt.Errorf("array pattern %v expects %v elements, array has %v", pattern, len(pattern.Elements), len(elements))
+		}
+
+		return nil
+	}
+
+	// Map patterns work on both maps with string keys and objects.
+	entries := make(map[string]RuntimeValue)
+	order := []string{}
+
+	if m, isMap := value.(MapValue); isMap {
+
+		for _, entry := range m.Value.Entries() {
+			if key, isStr := entry.Key.(StringValue); isStr {
+				entries[key.Value] = entry.Value
+				order = append(order, key.Value)
+			}
+		}
+
+	} else if obj, isObj := value.(ObjectVal); isObj {
+
+		for k, v := range obj.Properties {
+			entries[k] = v
+			order = append(order, k)
+		}
+
+		// Objects have no insertion order, so keep the rest element stable.
+		sort.Strings(order)
+
+	} else {
+		return fmt.Errorf("cannot destructure %v with map pattern %v", TypeName(value), pattern)
+	}
+
+	for _, element := range pattern.Elements {
+
+		// Everything not already picked out goes into the rest element.
+		if element.Rest {
+
+			rest := NewHashMap()
+			for _, k := range order {
+				if v, exists := entries[k]; exists {
+					rest.Set(MK_STRING(k), v)
+				}
+			}
+
+			return bind_element_with(element, MK_MAP(rest), b)
+		}
+
+		v, exists := entries[element.Name]
+		delete(entries, element.Name)
+
+		if !exists {
+
+			if element.Default == nil {
+				return fmt.Errorf("key `%v` does not exist for map pattern %v", element.Name, pattern)
+			}
+
+			def, err := b.evaluate(element.Default)
+			if err != nil {
+				return err
+			}
+
+			v = def
+		}
+
+		err := bind_element_with(element, v, b)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func eval_shorthand_operator_expression(sho ast.ShorthandOperator, env Environment) (RuntimeValue, error) {
+
+	left, err := env.Lookup(sho.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsInteger(left) {
+		return nil, fmt.Errorf("invalid operator %v", sho.Operator)
+	}
+
+	// Simple Shorthand (x++;) has nothing on the right.
+	var right RuntimeValue = MK_NUMBER(1)
+
+	if sho.Operator != "++" && sho.Operator != "--" {
+
+		// Complex Shorthand (x += 1;)
+		right, err = Evaluate(sho.Right, env)
+		if err != nil {
 			return nil, err
 		}
+	}
 
-		boolean, ok := b.(BooleanValue)
-		if !ok {
-			return nil, fmt.Errorf("if statement expressions must evaluate to a bool value, got %v", b)
+	currentValue, err := shorthand_operation(left, sho.Operator, right)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the value of the initial variable, which may live in an outer scope.
+	newValue, err := env.Assign(sho.Left, currentValue)
+	if err != nil {
+		return nil, err
+	}
+
+	return newValue, nil
+}
+
+// Works out the new value of a variable a shorthand operator is used on, from its current
+// int value and the value on the right.
+func shorthand_operation(left RuntimeValue, operator string, right RuntimeValue) (RuntimeValue, error) {
+
+	if operator == "++" || operator == "--" {
+		return eval_numeric_expression(left, right, operator[:1])
+	}
+
+	if !IsInteger(right) {
+		return nil, fmt.Errorf("invalid type used for operator %v", operator)
+	}
+
+	// Compound assignments share the binary operator, i.e. 'x <<= 1' is 'x = x << 1'.
+	return eval_numeric_expression(left, right, strings.TrimSuffix(operator, "="))
+}
+
+// Evaluates an if condition, i.e. if (10 > 5) { ... }
+func eval_if_condition_expression(iif ast.IfCondition, env Environment) (RuntimeValue, error) {
+
+	// We need to determine the type of the if's condition.
+	binop, isBinop := iif.Condition.(ast.BinaryExpr)
+	boolean, isBool := iif.Condition.(ast.BooleanLiteral)
+	iden, isIden := iif.Condition.(ast.Identifier)
+
+	var isConditionTrue bool
+
+	if isBinop {
+
+		left, err := Evaluate(binop.Left, env)
+		if err != nil {
+			return nil, err
 		}
 
+		right, err := Evaluate(binop.Right, env)
+		if err != nil {
+			return nil, err
+		}
+
+		isConditionTrue, err = if_condition(left, right, binop.Operator)
+		if err != nil {
+			return nil, err
+		}
+	} else if isBool {
 		isConditionTrue = boolean.Value
+	} else if isIden {
+
+		b, err := env.Lookup(iden.Symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		isConditionTrue, err = bool_condition(b)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Default to do nothing if this is just an empty function definition.
@@ -640,16 +1189,98 @@ func eval_if_condition_expression(iif ast.IfCondition, env Environment) (Runtime
 	return result, nil
 }
 
-// Evaluates a new 'using' directive. Attempts to import the specified module.
+// Decides a binary if condition from its already evaluated operands. Ints are compared by
+// the operator, strings only for equality and anything else by value.
+func if_condition(left RuntimeValue, right RuntimeValue, operator string) (bool, error) {
+
+	lhs, ok1s := left.(StringValue)
+	rhs, ok2s := right.(StringValue)
+
+	// Int comparision.
+	if IsInteger(left) && IsInteger(right) {
+		b, err := eval_numeric_boolean_expression(left, right, operator)
+		return b.Value, err
+	}
+
+	// String comparision.
+	if ok1s && ok2s {
+		b, err := eval_string_boolean_expression(lhs, rhs, operator)
+		return b.Value, err
+	}
+
+	if operator == "==" || operator == "!=" {
+		b, err := eval_value_boolean_expression(left, right, operator)
+		return b.Value, err
+	}
+
+	return false, fmt.Errorf("conditions must be of same type, got %v %v", TypeName(left), TypeName(right))
+}
+
+// Evaluates a new 'using' directive. Namespaces are added to the global scope of the module
+// it appears in, while names listed in braces are brought into the current scope.
 func eval_namespace_decleration(ns ast.NamespaceDecleration, env Environment) (RuntimeValue, error) {
 
-	moduleName := ns.Name
-	err := env.AddNamespace(moduleName)
+	global := env.Global()
+
+	// Modules are found relative to the global scope, but loaded by this task, for this run.
+	loader := global
+	loader.Stack, loader.Modules = env.Stack, env.Modules
+
+	for _, imp := range ns.Imports {
+
+		namespace, err := loader.ResolveNamespace(imp.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		// i.e. using { split, join } from "strings";
+		if imp.Members != nil {
+
+			for _, member := range imp.Members {
+
+				value, err := global.LookupMember(namespace, member)
+				if err != nil {
+					return nil, err
+				}
+
+				err = env.Import(member, value, namespace)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			continue
+		}
+
+		alias := imp.Alias
+		if alias == "" {
+			alias = namespace.Name
+		}
+
+		err = global.AddNamespace(alias, namespace)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// Evaluates an 'export' statement, declaring as normal then recording the name so programs
+// using this one as a module can reach it.
+func eval_export_statement(s ast.ExportStatement, env Environment) (RuntimeValue, error) {
+
+	value, err := Evaluate(s.Decleration, env)
 	if err != nil {
 		return nil, err
 	}
 
-	return nil, nil
+	// Programs run directly have no one to export to, so a module can still be run on its own.
+	if env.Exports != nil {
+		*env.Exports = append(*env.Exports, s.Name)
+	}
+
+	return value, nil
 }
 
 // Evaluates a string expression.
@@ -664,13 +1295,13 @@ func eval_member_expression(mem ast.MemberExpr, env Environment) (RuntimeValue,
 	// Get object (caller).
 	obj, ok := mem.Object.(ast.Identifier)
 	if !ok {
-		return nil, fmt.Errorf("object identifier invalid: %v", mem.Object)
+		return nil, fmt.Errorf("object identifier invalid: %v", ast.Describe(mem.Object))
 	}
 
 	// Get property (method).
 	prop, ok := mem.Property.(ast.Identifier)
 	if !ok {
-		return nil, fmt.Errorf("object property invalid: %v", mem.Property)
+		return nil, fmt.Errorf("object property invalid: %v", ast.Describe(mem.Property))
 	}
 
 	// Our function placeholder.
@@ -697,7 +1328,7 @@ func eval_member_expression(mem ast.MemberExpr, env Environment) (RuntimeValue,
 		if stdlibNamespace != nil {
 
 			// Namespace exists, but does the object property?
-			stdlibFn, err := env.LookupNativeFunction(*stdlibNamespace, prop.Symbol)
+			stdlibFn, err := env.LookupMember(*stdlibNamespace, prop.Symbol)
 			if err != nil {
 				return nil, err
 			}
@@ -707,7 +1338,7 @@ func eval_member_expression(mem ast.MemberExpr, env Environment) (RuntimeValue,
 
 		// No, so return error.
 		if fn == nil {
-			return nil, fmt.Errorf("undefined member call: %v", mem)
+			return nil, fmt.Errorf("undefined member call: %v.%v", obj.Symbol, prop.Symbol)
 		}
 	}
 
@@ -717,10 +1348,44 @@ func eval_member_expression(mem ast.MemberExpr, env Environment) (RuntimeValue,
 // Evaluates complex object assignments such as 'let foo = {x: 10};'
 func eval_call_expr(expr ast.CallExpr, env Environment) (RuntimeValue, error) {
 
+	args, err := eval_call_args(expr.Args, env)
+	if err != nil {
+		return nil, err
+	}
+
+	// (Probably) evaluates a member call, if so, use defined or part of stdlib?
+	fn, err := Evaluate(expr.Caller, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return call_function(fn, args, expr.Span, env)
+}
+
+// Evaluates the args passed into a function call.
+func eval_call_args(exprs []ast.Expression, env Environment) ([]RuntimeValue, error) {
+
 	args := make([]RuntimeValue, 0)
 
 	// For all args passed in, evaluate and store.
-	for _, arg := range expr.Args {
+	for _, arg := range exprs {
+
+		// Spread args, i.e. foo(...arr), are expanded into individual args.
+		if spread, isSpread := arg.(ast.SpreadExpr); isSpread {
+
+			val, err := Evaluate(spread.Argument, env)
+			if err != nil {
+				return nil, err
+			}
+
+			arr, isArr := val.(ArrayValue)
+			if !isArr {
+				return nil, fmt.Errorf("spread argument must be an array, got %v", TypeName(val))
+			}
+
+			args = append(args, *arr.Value...)
+			continue
+		}
 
 		val, err := Evaluate(arg, env)
 		if err != nil {
@@ -730,11 +1395,12 @@ func eval_call_expr(expr ast.CallExpr, env Environment) (RuntimeValue, error) {
 		args = append(args, val)
 	}
 
-	// (Probably) evaluates a member call, if so, use defined or part of stdlib?
-	fn, err := Evaluate(expr.Caller, env)
-	if err != nil {
-		return nil, err
-	}
+	return args, nil
+}
+
+// Calls either a native or user-defined function with already evaluated args, site is where
+// the call was made from.
+func call_function(fn RuntimeValue, args []RuntimeValue, site ast.Span, env Environment) (RuntimeValue, error) {
 
 	// User calling built-in funciton.
 	nativeFunc, isFn := fn.(NativeFunction)
@@ -753,45 +1419,261 @@ func eval_call_expr(expr ast.CallExpr, env Environment) (RuntimeValue, error) {
 	userFunc, isFn := fn.(UserFunction)
 	if isFn {
 
-		newScope := Environment{
-			Stdout:    env.Stdout, // Atm same stdout as main scope, however we would change to bytes.Buffer to give each new scope its own output buffer.
-			Parent:    &userFunc.DecEnv,
-			Constants: map[string]bool{},
-			Variables: map[string]RuntimeValue{},
+		pop, err := env.pushFrame(Frame{Function: userFunc.Name, CallSite: site, Source: env.Audit[site.Start.Line]})
+		if err != nil {
+			return nil, err
 		}
+		defer pop()
 
-		// Does number of provided params match the expected params?
-		providedParamCount := len(expr.Args)
-		expectingParamCount := len(userFunc.Params)
-		if expectingParamCount != providedParamCount {
-			return nil, fmt.Errorf("incorrect number of params specified for fn %v, got %v want %v", userFunc.Name, providedParamCount, expectingParamCount)
+		newScope := Environment{
+			Stdout:        env.Stdout, // Atm same stdout as main scope, however we would change to bytes.Buffer to give each new scope its own output buffer.
+			Stdin:         env.Stdin,
+			EntryLocation: env.EntryLocation,
+			Audit:         userFunc.DecEnv.Audit,
+			Stack:         env.Stack,
+			Modules:       env.Modules,
+			Scheduler:     env.Scheduler,
+			Parent:        &userFunc.DecEnv,
+			Constants:     map[string]bool{},
+			Variables:     map[string]RuntimeValue{},
+			Deferred:      &[]DeferredCall{},
 		}
 
 		// Make vars for params list.
-		for i := 0; i < expectingParamCount; i++ {
-			varName := userFunc.Params[i]
-			newScope.Declare(varName, args[i], false)
+		err = bind_params(userFunc, args, newScope)
+		if err != nil {
+			return nil, err
+		}
+
+		// Generators don't run their body until iterated over.
+		if userFunc.Generator {
+			return new_generator(userFunc, newScope), nil
+		}
+
+		result, err := eval_function_body(userFunc.Body, newScope)
+
+		// Deferred calls run regardless of how the function body exited.
+		deferErr := run_deferred_calls(newScope)
+
+		// An error from the body takes priority over one raised by a deferred call.
+		if err != nil {
+			return nil, err
 		}
 
-		// Default to do nothing if this is just an empty function definition.
-		var result RuntimeValue = MK_NULL()
+		if deferErr != nil {
+			return nil, deferErr
+		}
 
-		for _, stmt := range userFunc.Body {
+		return result, nil
+	}
 
-			r, err := Evaluate(stmt, newScope)
+	if compiled, isFn := fn.(CompiledFunction); isFn {
+		return call_compiled(compiled, args, site, env)
+	}
+
+	return nil, fmt.Errorf("unexpected value in place of function: %v", TypeName(fn))
+}
+
+// Declares the params of a user-defined function in its call scope. Missing args fall back
+// to the param's default value and a rest param collects any remaining args into an array.
+func bind_params(userFunc UserFunction, args []RuntimeValue, scope Environment) error {
+	return bind_params_with(userFunc.Name, userFunc.Params, args, scope_binder(scope, false))
+}
+
+func bind_params_with(name string, params []ast.Parameter, args []RuntimeValue, b binder) error {
+
+	providedParamCount := len(args)
+	expectingParamCount := len(params)
+
+	for i, param := range params {
+
+		var value RuntimeValue
+
+		if param.Rest {
+
+			rest := make([]RuntimeValue, 0)
+			if i < providedParamCount {
+				rest = append(rest, args[i:]...)
+			}
+
+			value = MK_ARRAY(rest)
+
+		} else if i < providedParamCount {
+			value = args[i]
+		} else if param.Default != nil {
+
+			// Defaults are evaluated in the call scope, so may refer to earlier params.
+			def, err := b.evaluate(param.Default)
 			if err != nil {
-				return nil, err
+				return err
 			}
 
-			// TO-DO: Currently this will make it so functions return values regardless of if there is a 'return' statement at the end.
-			// We may want to change this in the future.
-			result = r
+			value = def
+		} else {
+			return fmt.Errorf("missing param '%v' for fn %v, got %v want %v", param.Name, name, providedParamCount, expectingParamCount)
 		}
 
-		return result, nil
+		// Annotated params are validated on every call, rest params per element.
+		if param.Type != nil {
+
+			toCheck := []RuntimeValue{value}
+			if param.Rest {
+				toCheck = *value.(ArrayValue).Value
+			}
+
+			for _, v := range toCheck {
+				if !MatchesType(v, *param.Type) {
+					return fmt.Errorf("param '%v' of fn %v expects %v, got %v", param.Name, name, *param.Type, TypeName(v))
+				}
+			}
+		}
+
+		// Destructured params bind each of their names instead.
+		if param.Pattern != nil {
+
+			err := destructure_with(*param.Pattern, value, b)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err := b.declare(param.Name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Too many args only matters when there is no rest param to collect them.
+	hasRest := expectingParamCount > 0 && params[expectingParamCount-1].Rest
+	if !hasRest && providedParamCount > expectingParamCount {
+		return fmt.Errorf("incorrect number of params specified for fn %v, got %v want %v", name, providedParamCount, expectingParamCount)
+	}
+
+	return nil
+}
+
+// Evaluates the statements of a user-defined function, stopping early on a 'return'.
+func eval_function_body(body []ast.Expression, env Environment) (RuntimeValue, error) {
+
+	// Default to do nothing if this is just an empty function definition.
+	var result RuntimeValue = MK_NULL()
+
+	for _, stmt := range body {
+
+		r, err := Evaluate(stmt, env)
+		if err != nil {
+
+			// A 'return' somewhere in the body, hand its value back to the caller.
+			if ret, isReturn := err.(returnSignal); isReturn {
+				return ret.Value, nil
+			}
+
+			return nil, err
+		}
+
+		// TO-DO: Currently this will make it so functions return values regardless of if there is a 'return' statement at the end.
+		// We may want to change this in the future.
+		result = r
 	}
 
-	return nil, fmt.Errorf("unexpected value in place of function: %v", fn)
+	return result, nil
+}
+
+// Runs the deferred calls of a function scope in LIFO order. Every call is run even if an
+// earlier one fails, the first error encountered is returned.
+func run_deferred_calls(env Environment) error {
+	return run_deferred(*env.Deferred, env)
+}
+
+func run_deferred(deferred []DeferredCall, env Environment) error {
+
+	var firstErr error
+
+	for i := len(deferred) - 1; i >= 0; i-- {
+
+		_, err := call_function(deferred[i].Fn, deferred[i].Args, deferred[i].Site, env)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Evaluates a 'defer' statement. The function and its args are evaluated now, but the call
+// itself only happens once the enclosing function returns.
+func eval_defer_statement(d ast.DeferStatement, env Environment) (RuntimeValue, error) {
+
+	stack, err := env.ResolveDeferred()
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := eval_call_args(d.Call.Args, env)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, err := Evaluate(d.Call.Caller, env)
+	if err != nil {
+		return nil, err
+	}
+
+	*stack = append(*stack, DeferredCall{Fn: fn, Args: args, Site: d.Call.Span})
+
+	return MK_NULL(), nil
+}
+
+// Used to unwind out of a function body when a 'return' statement is hit.
+type returnSignal struct {
+	Value RuntimeValue
+}
+
+func (r returnSignal) Error() string {
+	return "return can only be used inside a function"
+}
+
+// Evaluates a 'return' statement, unwinding back to the enclosing function call.
+func eval_return_statement(r ast.ReturnStatement, env Environment) (RuntimeValue, error) {
+
+	var value RuntimeValue = MK_NULL()
+
+	if r.Value != nil {
+
+		v, err := Evaluate(r.Value, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if v != nil {
+			value = v
+		}
+	}
+
+	return nil, returnSignal{Value: value}
+}
+
+// Evaluates a 'yield' statement, handing the value to whoever is iterating over the generator
+// and waiting until the next value is asked for.
+func eval_yield_statement(y ast.YieldStatement, env Environment) (RuntimeValue, error) {
+
+	yield, err := env.ResolveYield()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := Evaluate(y.Value, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yield(value); err != nil {
+		return nil, err
+	}
+
+	return MK_NULL(), nil
 }
 
 // Evaluates a function call.
@@ -803,6 +1685,8 @@ func eval_function_decleration(f ast.FunctionDecleration, env Environment) (Runt
 		Params: f.Params,
 		DecEnv: env,
 		Body:   f.Body,
+
+		Generator: f.Generator,
 	}
 
 	val, err := env.Declare(f.Name, fn, true)
@@ -816,6 +1700,11 @@ func eval_function_decleration(f ast.FunctionDecleration, env Environment) (Runt
 // Evaluates either a 'let' or 'const' decleration statement.
 func eval_var_decleration(dec ast.VariableDecleration, env Environment) (RuntimeValue, error) {
 
+	// 'let x;' has no value to evaluate, it starts off as null.
+	if _, isEmpty := dec.Value.(ast.Expr); isEmpty {
+		return env.Declare(dec.Identifier, MK_NULL(), dec.Constant)
+	}
+
 	value, err := Evaluate(dec.Value, env)
 	if err != nil {
 		return nil, err
@@ -860,23 +1749,25 @@ func eval_arr_decleration(arr ast.ArrayDecleration, env Environment) (RuntimeVal
 // Evaluates a map decleration.
 func eval_map_decleration(map_ ast.MapDecleration, env Environment) (RuntimeValue, error) {
 
-	mapValues := make(map[RuntimeValue]RuntimeValue, 0)
+	mapValues := NewHashMap()
 
-	for k, v := range map_.Value {
+	for _, entry := range map_.Value {
 
 		// Evaluate the provided key.
-		key, err := Evaluate(k, env)
+		key, err := Evaluate(entry.Key, env)
 		if err != nil {
 			return nil, err
 		}
 
 		// Evaluate the provided value.
-		value, err := Evaluate(v, env)
+		value, err := Evaluate(entry.Value, env)
 		if err != nil {
 			return nil, err
 		}
 
-		mapValues[key] = value
+		if err := mapValues.Set(key, value); err != nil {
+			return nil, err
+		}
 	}
 
 	decleration, err := env.DeclareMap(map_.Identifier, mapValues, map_.Constant)
@@ -900,73 +1791,146 @@ func eval_binary_expression(binop ast.BinaryExpr, env Environment) (RuntimeValue
 		return nil, err
 	}
 
-	lhs, ok1 := left.(NumberValue)
-	rhs, ok2 := right.(NumberValue)
+	return binary_operation(left, right, binop.Operator)
+}
 
-	if ok1 && ok2 {
+// Applies a binary operator to two already evaluated operands.
+func binary_operation(left RuntimeValue, right RuntimeValue, operator string) (RuntimeValue, error) {
 
-		// Is this a mathemetical expression?
-		if binop.Operator == "+" || binop.Operator == "-" ||
-			binop.Operator == "/" || binop.Operator == "*" ||
-			binop.Operator == "%" {
-			return eval_numeric_expression(NumberValue{Type: "Number", Value: lhs.Value}, NumberValue{Type: "Number", Value: rhs.Value}, binop.Operator)
+	if IsInteger(left) && IsInteger(right) {
 
+		switch operator {
+		case "+", "-", "*", "/", "~/", "%", "**", "&", "|", "^", "<<", ">>":
+			// Is this a mathemetical expression?
+			return eval_numeric_expression(left, right, operator)
+		case ">", "<", ">=", "<=", "==", "!=":
 			// Or is this a boolean (logical) expression?
-		} else if binop.Operator == ">" || binop.Operator == "<" || binop.Operator == ">=" || binop.Operator == "<=" || binop.Operator == "==" || binop.Operator == "!=" {
-			return eval_numeric_boolean_expression(NumberValue{Type: "Number", Value: lhs.Value}, NumberValue{Type: "Number", Value: rhs.Value}, binop.Operator)
+			return eval_numeric_boolean_expression(left, right, operator)
 		}
 
 	}
 
+	// Anything else can still be compared by value.
+	if operator == "==" || operator == "!=" {
+		return eval_value_boolean_expression(left, right, operator)
+	}
+
 	// Encountered a null value.
 	return MK_NULL(), nil
 }
 
-// Evaluates a numeric expression.
-func eval_numeric_expression(lhs NumberValue, rhs NumberValue, opp string) (NumberValue, error) {
+// Evaluates a numeric expression. Results that overflow an int are promoted to a big int.
+func eval_numeric_expression(lhs RuntimeValue, rhs RuntimeValue, opp string) (RuntimeValue, error) {
+
+	l, ok1 := lhs.(NumberValue)
+	r, ok2 := rhs.(NumberValue)
+
+	// Either side has already been promoted, or the operator can grow past an int quickly.
+	if !ok1 || !ok2 || opp == "**" || opp == "<<" {
+		return eval_big_expression(ToBigInt(lhs), ToBigInt(rhs), opp)
+	}
 
+	a, b := l.Value, r.Value
 	result := 0
 
-	if opp == "+" {
-		result = lhs.Value + rhs.Value
-	} else if opp == "-" {
-		result = lhs.Value - rhs.Value
-	} else if opp == "*" {
-		result = lhs.Value * rhs.Value
-	} else if opp == "/" {
+	switch opp {
+	case "+":
+		result = a + b
 
-		if rhs.Value != 0 {
-			result = lhs.Value / rhs.Value
+		// Both sides have the same sign, but the result doesn't.
+		if (a >= 0) == (b >= 0) && (result >= 0) != (a >= 0) {
+			return eval_big_expression(ToBigInt(lhs), ToBigInt(rhs), opp)
+		}
+	case "-":
+		result = a - b
+
+		// The sides have different signs, and the result doesn't match the left's.
+		if (a >= 0) != (b >= 0) && (result >= 0) != (a >= 0) {
+			return eval_big_expression(ToBigInt(lhs), ToBigInt(rhs), opp)
+		}
+	case "*":
+		result = a * b
+
+		if a != 0 && (result/a != b || (a == -1 && b == math.MinInt)) {
+			return eval_big_expression(ToBigInt(lhs), ToBigInt(rhs), opp)
+		}
+	case "/", "~/", "%":
+
+		if b == 0 {
+			if opp == "%" {
+				return nil, fmt.Errorf("modulo by zero")
+			}
+			return nil, fmt.Errorf("division by zero")
+		}
+
+		// The only division that overflows.
+		if a == math.MinInt && b == -1 && opp != "%" {
+			return eval_big_expression(ToBigInt(lhs), ToBigInt(rhs), opp)
+		}
+
+		if opp == "/" {
+			result = a / b
+		} else if opp == "%" {
+			result = a % b
 		} else {
-			result = 0
+			// Floor division rounds towards negative infinity, rather than towards zero.
+			result = a / b
+			if (a%b != 0) && ((a < 0) != (b < 0)) {
+				result--
+			}
+		}
+	case "&":
+		result = a & b
+	case "|":
+		result = a | b
+	case "^":
+		result = a ^ b
+	case ">>":
+
+		if b < 0 {
+			return nil, fmt.Errorf("negative shift count %v", b)
 		}
 
-	} else if opp == "%" {
-		result = lhs.Value % rhs.Value
-	} else {
-		return NumberValue{}, fmt.Errorf("invalid binop provided: %v", opp)
+		result = a >> b
+	default:
+		return nil, fmt.Errorf("invalid binop provided: %v", opp)
 	}
 
 	return MK_NUMBER(result), nil
 }
 
 // Returns a boolean evaluiation of a numeric expression. E.g. 10 < 100 === true.
-func eval_numeric_boolean_expression(lhs NumberValue, rhs NumberValue, opp string) (BooleanValue, error) {
+func eval_numeric_boolean_expression(lhs RuntimeValue, rhs RuntimeValue, opp string) (BooleanValue, error) {
 
 	var b bool = false
 
+	// Compare as big ints, unless both sides are small.
+	cmp := 0
+	l, ok1 := lhs.(NumberValue)
+	r, ok2 := rhs.(NumberValue)
+
+	if ok1 && ok2 {
+		if l.Value < r.Value {
+			cmp = -1
+		} else if l.Value > r.Value {
+			cmp = 1
+		}
+	} else {
+		cmp = ToBigInt(lhs).Cmp(ToBigInt(rhs))
+	}
+
 	if opp == ">" {
-		b = lhs.Value > rhs.Value
+		b = cmp > 0
 	} else if opp == "<" {
-		b = lhs.Value < rhs.Value
+		b = cmp < 0
 	} else if opp == ">=" {
-		b = lhs.Value >= rhs.Value
+		b = cmp >= 0
 	} else if opp == "<=" {
-		b = lhs.Value <= rhs.Value
+		b = cmp <= 0
 	} else if opp == "==" {
-		b = lhs.Value == rhs.Value
+		b = cmp == 0
 	} else if opp == "!=" {
-		b = lhs.Value != rhs.Value
+		b = cmp != 0
 	}
 
 	return BooleanValue{
@@ -998,25 +1962,109 @@ func eval_string_boolean_expression(lhs StringValue, rhs StringValue, opp string
 
 }
 
+// Returns a boolean evaluation of `==` or `!=` on any two values, compared by value. E.g. [1, 2] == [1, 2]
+func eval_value_boolean_expression(lhs RuntimeValue, rhs RuntimeValue, opp string) (BooleanValue, error) {
+
+	b, err := ValuesEqual(lhs, rhs)
+	if err != nil {
+		return BooleanValue{}, err
+	}
+
+	if opp == "!=" {
+		b = !b
+	}
+
+	return BooleanValue{
+		Type:  Boolean,
+		Value: b,
+	}, nil
+}
+
 // Evaluates an assignment expression, e.g. x = 10
 func eval_assignment_expression(node ast.AssignmentExpr, env Environment) (RuntimeValue, error) {
 
-	// Checking that node isnt an Identifier.
-	iden, identifier := node.Assigne.(ast.Identifier)
+	eval, err := Evaluate(node.Value, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch assigne := node.Assigne.(type) {
+	case ast.Identifier:
+
+		assign, err := env.Assign(assigne.Symbol, eval)
+		if err != nil {
+			return nil, err
+		}
+		return assign, nil
+	case ast.ArrayOrMapIdentifier:
+
+		// Element assignment, e.g. arr[0] = 10
+		index, err := Evaluate(assigne.Index, env)
+		if err != nil {
+			return nil, err
+		}
 
-	if !identifier {
+		return env.ArrayOrMapAssign(assigne.Symbol, index, eval)
+	case ast.SliceExpr:
 
-		return nil, fmt.Errorf("invalid lhs in expression: %v", node.Assigne)
+		// Slice assignment, e.g. arr[1:3] = [4, 5]
+		return eval_slice_assignment(assigne, eval, env)
 	}
 
-	eval, err := Evaluate(node.Value, env)
+	return nil, fmt.Errorf("invalid lhs in expression: %v", ast.Describe(node.Assigne))
+}
+
+// Replaces the elements within a slice of an array with the elements of another array.
+// The array is updated in place, so the replacement can be longer or shorter than the slice.
+func eval_slice_assignment(slice ast.SliceExpr, value RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	target, err := env.Lookup(slice.Symbol)
 	if err != nil {
 		return nil, err
 	}
 
-	assign, err := env.Assign(iden.Symbol, eval)
+	return assign_slice(slice.Symbol, target, value, func(length int) (int, int, error) {
+		return slice_bounds(slice, length, env)
+	})
+}
+
+// Replaces a slice of the array held by the variable var_, resolving the bounds once the
+// array is known to be one that can be changed.
+func assign_slice(var_ string, target RuntimeValue, value RuntimeValue, bounds func(length int) (int, int, error)) (RuntimeValue, error) {
+
+	if _, isString := target.(StringValue); isString {
+		return nil, fmt.Errorf("cannot assign to slice of '%v', strings are immutable", var_)
+	} else if _, isBytes := target.(BytesValue); isBytes {
+		return nil, fmt.Errorf("cannot assign to slice of '%v', bytes are immutable", var_)
+	}
+
+	arr, ok := target.(ArrayValue)
+	if !ok {
+		return nil, fmt.Errorf("cannot assign to slice of %v", TypeName(target))
+	}
+
+	if IsFrozen(arr) {
+		return nil, fmt.Errorf("cannot assign to slice of '%v', array is frozen", var_)
+	}
+
+	replacement, ok := value.(ArrayValue)
+	if !ok {
+		return nil, fmt.Errorf("cannot assign %v to slice of '%v', expected array", TypeName(value), var_)
+	}
+
+	start, end, err := bounds(len(*arr.Value))
 	if err != nil {
 		return nil, err
 	}
-	return assign, nil
+
+	current := *arr.Value
+
+	elements := make([]RuntimeValue, 0, len(current)-(end-start)+len(*replacement.Value))
+	elements = append(elements, current[:start]...)
+	elements = append(elements, *replacement.Value...)
+	elements = append(elements, current[end:]...)
+
+	*arr.Value = elements
+
+	return value, nil
 }
@@ -3,6 +3,7 @@ package runtime
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -17,8 +18,6 @@ TODO:
 	- contains a reference to the specified file
 	- value that specifies the mode of the file (read, write, append)
 	- when opening files, file locations should be relative to the main.gob file, not the Goblin interpreter.
-- Add new type: byte
-	- Similar to string, but is a collection of bytes
 */
 
 var IO = Namespace{
@@ -58,12 +57,16 @@ var IO = Namespace{
 		},
 		"close": {
 			Type: "NativeFn",
-			Call: close,
+			Call: closeFile,
 		},
 		"write": {
 			Type: "NativeFn",
 			Call: write,
 		},
+		"read": {
+			Type: "NativeFn",
+			Call: read,
+		},
 	},
 }
 
@@ -160,7 +163,13 @@ var input FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValu
 	reader := bufio.NewReader(env.Stdin)
 	utils.Stdout(msg.Value, env.Stdout)
 
-	input, err := reader.ReadString('\n') // Read until EOF (Ctrl+D)
+	// Other tasks carry on while waiting for the line.
+	var input string
+	var err error
+	env.Scheduler.blocking(func() {
+		input, err = reader.ReadString('\n') // Read until EOF (Ctrl+D)
+	})
+
 	if err != nil {
 		return nil, err
 	}
@@ -183,12 +192,12 @@ var open FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue
 
 	fp, isStr := args[0].(StringValue)
 	if !isStr {
-		return nil, fmt.Errorf("file path: string expected, got %v", fp)
+		return nil, fmt.Errorf("file path: string expected, got %v", TypeName(args[0]))
 	}
 
 	m, isStr := args[1].(StringValue)
 	if !isStr {
-		return nil, fmt.Errorf("file mode: string expected, got %v", m)
+		return nil, fmt.Errorf("file mode: string expected, got %v", TypeName(args[1]))
 	}
 
 	mode := fileOpenFlags(m.Value)
@@ -211,7 +220,7 @@ var open FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue
 
 // close - closes the specified file object.
 // io.close(fileObject *fileObj)
-var close FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+var closeFile FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
 
 	numArgs := len(args)
 	if numArgs != 1 {
@@ -256,9 +265,14 @@ var readline FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeV
 	}
 
 	// Only allow this to work if file opened in appropriate mode.
-	if fileObj.IsOpen && (fileObj.Mode == os.O_RDONLY || fileObj.Mode == os.O_RDWR) {
+	if fileObj.IsOpen && isReadable(fileObj.Mode) {
+
+		var val string
+		var err error
+		env.Scheduler.blocking(func() {
+			val, err = fileReader(fileObj, line.Value)
+		})
 
-		val, err := fileReader(fileObj, line.Value)
 		if err != nil {
 			return nil, err
 		}
@@ -287,7 +301,7 @@ var readlines FunctionCall = func(args []RuntimeValue, env Environment) (Runtime
 	}
 
 	// Only allow this to work if file opened in appropriate mode.
-	if fileObj.IsOpen && (fileObj.Mode == os.O_RDONLY || fileObj.Mode == os.O_RDWR) {
+	if fileObj.IsOpen && isReadable(fileObj.Mode) {
 
 		val, err := fileReader(fileObj, *fileObj.CursorPointer)
 		if err != nil {
@@ -302,11 +316,81 @@ var readlines FunctionCall = func(args []RuntimeValue, env Environment) (Runtime
 	return nil, nil
 }
 
-// write - writes the contents of the buffer to the specified fileObject.
-// io.write(fileObject *fileObj, buffer []byte)
+// write - writes the contents of the buffer to the specified fileObject, returns the number of bytes written.
+// io.write(fileObject *fileObj, buffer bytes) int
 var write FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
 
-	return nil, nil
+	numArgs := len(args)
+	if numArgs != 2 {
+		return nil, fmt.Errorf("unexpected number of args for io.write, expected 2 got %v", numArgs)
+	}
+
+	fileObj, isFileObj := args[0].(FileObjectValue)
+	if !isFileObj {
+		return nil, fmt.Errorf("io.write expectes arg1 to be of type fileObject, %v given", TypeName(args[0]))
+	}
+
+	buffer, isBytes := args[1].(BytesValue)
+	if !isBytes {
+		return nil, fmt.Errorf("io.write expectes arg2 to be of type bytes, %v given", TypeName(args[1]))
+	}
+
+	// Only allow this to work if file opened in appropriate mode.
+	if !fileObj.IsOpen || !isWritable(fileObj.Mode) {
+		return nil, fmt.Errorf("file: %v not opened in a valid write-mode", fileObj.Path)
+	}
+
+	n, err := fileObj.File.Write(buffer.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return MK_NUMBER(n), nil
+}
+
+// read - reads up to `size` bytes from the specified fileObject, or the rest of the file when
+// no size is given. Returns empty bytes once the end of the file is reached.
+// io.read(fileObject *fileObj, size int) bytes
+var read FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 1 && numArgs != 2 {
+		return nil, fmt.Errorf("unexpected number of args for io.read, expected 1 or 2 got %v", numArgs)
+	}
+
+	fileObj, isFileObj := args[0].(FileObjectValue)
+	if !isFileObj {
+		return nil, fmt.Errorf("io.read expectes arg1 to be of type fileObject, %v given", TypeName(args[0]))
+	}
+
+	// Only allow this to work if file opened in appropriate mode.
+	if !fileObj.IsOpen || !isReadable(fileObj.Mode) {
+		return nil, fmt.Errorf("file: %v not opened in a valid read-mode", fileObj.Path)
+	}
+
+	if numArgs == 1 {
+
+		contents, err := io.ReadAll(fileObj.File)
+		if err != nil {
+			return nil, err
+		}
+
+		return MK_BYTES(contents), nil
+	}
+
+	size, isInt := args[1].(NumberValue)
+	if !isInt || size.Value < 0 {
+		return nil, fmt.Errorf("io.read expectes arg2 to be a positive int, %v given", printHelper(args[1]))
+	}
+
+	buffer := make([]byte, size.Value)
+
+	n, err := io.ReadFull(fileObj.File, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	return MK_BYTES(buffer[:n]), nil
 }
 
 func fileReader(file FileObjectValue, lineNumber int) (string, error) {
@@ -331,22 +415,38 @@ func fileReader(file FileObjectValue, lineNumber int) (string, error) {
 	return "\r\n", fmt.Errorf("line number %v not found in %v", lineNumber, file.Path)
 }
 
-// Helper function for open
+// Helper function for open. 'w' creates the file if needed and empties it first, 'a'
+// creates the file if needed and appends to the end, '+' allows reads and writes.
 func fileOpenFlags(mode string) int {
 
-	var flags int
+	access := os.O_RDONLY
+	extra := 0
 
-	if strings.Contains(mode, "r") {
-		flags = os.O_RDONLY
-	}
 	if strings.Contains(mode, "w") {
-		flags = os.O_WRONLY
+		access = os.O_WRONLY
+		extra = os.O_CREATE | os.O_TRUNC
+	}
+	if strings.Contains(mode, "a") {
+		access = os.O_WRONLY
+		extra = os.O_CREATE | os.O_APPEND
 	}
 	if strings.Contains(mode, "+") {
-		flags = os.O_RDWR
+		access = os.O_RDWR
 	}
 
-	return flags
+	return access | extra
+}
+
+// Can a file opened with these flags be read from?
+func isReadable(flags int) bool {
+	access := flags & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	return access == os.O_RDONLY || access == os.O_RDWR
+}
+
+// Can a file opened with these flags be written to?
+func isWritable(flags int) bool {
+	access := flags & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	return access == os.O_WRONLY || access == os.O_RDWR
 }
 
 // Helper function for printf, sprintf.
@@ -357,7 +457,7 @@ func printerFormatter(args []RuntimeValue) (string, error) {
 	builder := ""
 
 	if !isStr {
-		return "", fmt.Errorf("string type required for formatted string, got %v", formattedString)
+		return "", fmt.Errorf("string type required for formatted string, got %v", TypeName(args[0]))
 	}
 	for i := 0; i < len(formattedString.Value); i++ {
 		// If we encounter a '%' character
@@ -367,6 +467,13 @@ func printerFormatter(args []RuntimeValue) (string, error) {
 				// Switch on the format specifier
 				switch formattedString.Value[i+1] {
 				case 'd': // Integer
+					if IsInteger(arguments[0]) {
+						builder += ToBigInt(arguments[0]).String()
+						arguments = arguments[1:]
+						i++
+						break
+					}
+
 					iVal, err := utils.ToNumber(printHelper(arguments[0]))
 					if err != nil {
 						return "", err
@@ -411,6 +518,36 @@ func printer(args []RuntimeValue) (string, error) {
 	return builder, nil
 }
 
+// Helper function, renders bytes the way they'd be written as a literal. Printable ascii is
+// kept as is, everything else is shown as a hex escape.
+func FormatBytes(b []byte) string {
+
+	builder := strings.Builder{}
+	builder.WriteString("b\"")
+
+	for _, c := range b {
+
+		switch {
+		case c == '"' || c == '\\':
+			builder.WriteByte('\\')
+			builder.WriteByte(c)
+		case c == '\n':
+			builder.WriteString("\\n")
+		case c == '\t':
+			builder.WriteString("\\t")
+		case c == '\r':
+			builder.WriteString("\\r")
+		case c >= 0x20 && c < 0x7f:
+			builder.WriteByte(c)
+		default:
+			builder.WriteString(fmt.Sprintf("\\x%02x", c))
+		}
+	}
+
+	builder.WriteString("\"")
+	return builder.String()
+}
+
 // Helper function, resolves types to strings.
 func printHelper(arg RuntimeValue) string {
 
@@ -420,6 +557,14 @@ func printHelper(arg RuntimeValue) string {
 
 		builder = fmt.Sprintf("%v", num.Value)
 
+	} else if b, ok := arg.(BytesValue); ok {
+
+		builder = FormatBytes(b.Value)
+
+	} else if bigInt, ok := arg.(BigIntValue); ok {
+
+		builder = bigInt.Value.String()
+
 	} else if boolean, ok := arg.(BooleanValue); ok {
 
 		builder = fmt.Sprintf("%v", boolean.Value)
@@ -449,13 +594,13 @@ func printHelper(arg RuntimeValue) string {
 		builder = "{"
 		counter := 0
 
-		for key, value := range *map_.Value {
+		for _, entry := range map_.Value.Entries() {
 
-			s := fmt.Sprintf("%v : %v", printHelper(key), printHelper(value))
+			s := fmt.Sprintf("%v : %v", printHelper(entry.Key), printHelper(entry.Value))
 
 			builder += s
 
-			if counter < (len(*map_.Value) - 1) {
+			if counter < (map_.Value.Len() - 1) {
 				builder += ", "
 			}
 
@@ -464,6 +609,15 @@ func printHelper(arg RuntimeValue) string {
 
 		builder += "}"
 
+	} else if set, ok := arg.(SetValue); ok {
+
+		elements := make([]string, 0)
+		for _, el := range SortedElements(set) {
+			elements = append(elements, printHelper(el))
+		}
+
+		builder = "set{" + strings.Join(elements, ", ") + "}"
+
 	} else if null, ok := arg.(NullValue); ok {
 
 		builder = fmt.Sprintf("%v", null.Value)
@@ -485,6 +639,18 @@ func printHelper(arg RuntimeValue) string {
 	} else if fileObj, ok := arg.(FileObjectValue); ok {
 
 		builder += fileObj.Path
+	} else if _, ok := arg.(IteratorValue); ok {
+
+		builder = "iterator"
+	} else if _, ok := arg.(ChannelValue); ok {
+
+		builder = "chan"
+	} else if _, ok := arg.(WaitGroupValue); ok {
+
+		builder = "waitgroup"
+	} else if _, ok := arg.(MutexValue); ok {
+
+		builder = "mutex"
 	}
 
 	return builder
@@ -0,0 +1,264 @@
+package runtime
+
+import (
+	"fmt"
+
+	"goblin.org/main/frontend/ast"
+)
+
+var Iter = Namespace{
+	Name: "iter",
+	Functions: map[string]NativeFunction{
+		"map": {
+			Type: "NativeFn",
+			Call: iterMap,
+		},
+		"filter": {
+			Type: "NativeFn",
+			Call: iterFilter,
+		},
+		"take": {
+			Type: "NativeFn",
+			Call: iterTake,
+		},
+		"zip": {
+			Type: "NativeFn",
+			Call: iterZip,
+		},
+		"enumerate": {
+			Type: "NativeFn",
+			Call: iterEnumerate,
+		},
+		"collect": {
+			Type: "NativeFn",
+			Call: iterCollect,
+		},
+	},
+}
+
+// Registered on init, as the adapters call back into the interpreter, which reads the register.
+func init() {
+	register["iter"] = Iter
+}
+
+// map, returns an iterator over the results of calling fn on each value
+// iter.map(it any, fn fn) iterator
+var iterMap FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	src, fn, err := iteratorAndFn("iter.map", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return MK_ITERATOR(func() (RuntimeValue, bool, error) {
+
+		value, ok, err := src.Next()
+		if !ok || err != nil {
+			return nil, false, err
+		}
+
+		result, err := call_function(fn, []RuntimeValue{value}, ast.Span{}, env)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return result, true, nil
+	}, src.Close), nil
+}
+
+// filter, returns an iterator over the values fn returns true for
+// iter.filter(it any, fn fn) iterator
+var iterFilter FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	src, fn, err := iteratorAndFn("iter.filter", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return MK_ITERATOR(func() (RuntimeValue, bool, error) {
+
+		for {
+
+			value, ok, err := src.Next()
+			if !ok || err != nil {
+				return nil, false, err
+			}
+
+			result, err := call_function(fn, []RuntimeValue{value}, ast.Span{}, env)
+			if err != nil {
+				return nil, false, err
+			}
+
+			keep, isBool := result.(BooleanValue)
+			if !isBool {
+				return nil, false, fmt.Errorf("iter.filter expects fn to return bool, %v given", TypeName(result))
+			}
+
+			if keep.Value {
+				return value, true, nil
+			}
+		}
+	}, src.Close), nil
+}
+
+// take, returns an iterator over at most the first n values
+// iter.take(it any, n int) iterator
+var iterTake FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 2 {
+		return nil, fmt.Errorf("unexpected number of args for iter.take, expected 2 got %v", numArgs)
+	}
+
+	n, isInt := args[1].(NumberValue)
+	if !isInt || n.Value < 0 {
+		return nil, fmt.Errorf("iter.take expectes arg2 to be a positive int, %v given", printHelper(args[1]))
+	}
+
+	src, err := ToIterator(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	taken := 0
+
+	return MK_ITERATOR(func() (RuntimeValue, bool, error) {
+
+		// Stop the source as soon as we're done with it, it may never end.
+		if taken >= n.Value {
+			src.Close()
+			return nil, false, nil
+		}
+
+		taken++
+		return src.Next()
+	}, src.Close), nil
+}
+
+// zip, returns an iterator over [a, b] pairs, stopping when either runs out
+// iter.zip(a any, b any) iterator
+var iterZip FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 2 {
+		return nil, fmt.Errorf("unexpected number of args for iter.zip, expected 2 got %v", numArgs)
+	}
+
+	a, err := ToIterator(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ToIterator(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	closeBoth := func() {
+		a.Close()
+		b.Close()
+	}
+
+	return MK_ITERATOR(func() (RuntimeValue, bool, error) {
+
+		left, ok, err := a.Next()
+		if !ok || err != nil {
+			closeBoth()
+			return nil, false, err
+		}
+
+		right, ok, err := b.Next()
+		if !ok || err != nil {
+			closeBoth()
+			return nil, false, err
+		}
+
+		return MK_ARRAY([]RuntimeValue{left, right}), true, nil
+	}, closeBoth), nil
+}
+
+// enumerate, returns an iterator over [index, value] pairs, counting from 0
+// iter.enumerate(it any) iterator
+var iterEnumerate FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 1 {
+		return nil, fmt.Errorf("unexpected number of args for iter.enumerate, expected 1 got %v", numArgs)
+	}
+
+	src, err := ToIterator(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	index := 0
+
+	return MK_ITERATOR(func() (RuntimeValue, bool, error) {
+
+		value, ok, err := src.Next()
+		if !ok || err != nil {
+			return nil, false, err
+		}
+
+		index++
+		return MK_ARRAY([]RuntimeValue{MK_NUMBER(index - 1), value}), true, nil
+	}, src.Close), nil
+}
+
+// collect, runs an iterator to the end, returning its values as an array
+// iter.collect(it any) array
+var iterCollect FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 1 {
+		return nil, fmt.Errorf("unexpected number of args for iter.collect, expected 1 got %v", numArgs)
+	}
+
+	src, err := ToIterator(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	defer src.Close()
+
+	elements := []RuntimeValue{}
+
+	for {
+
+		value, ok, err := src.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		elements = append(elements, value)
+	}
+
+	return MK_ARRAY(elements), nil
+}
+
+// Checks the args of an adapter taking an iterable and a function.
+func iteratorAndFn(name string, args []RuntimeValue) (IteratorValue, RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 2 {
+		return IteratorValue{}, nil, fmt.Errorf("unexpected number of args for %v, expected 2 got %v", name, numArgs)
+	}
+
+	fn := args[1]
+	switch fn.(type) {
+	case NativeFunction, UserFunction, CompiledFunction:
+	default:
+		return IteratorValue{}, nil, fmt.Errorf("%v expectes arg2 to be of type fn, %v given", name, TypeName(fn))
+	}
+
+	src, err := ToIterator(args[0])
+	if err != nil {
+		return IteratorValue{}, nil, err
+	}
+
+	return src, fn, nil
+}
@@ -0,0 +1,224 @@
+package runtime
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A lazy, single pass sequence of values, made by generator functions, the iter namespace
+// and file objects. Once exhausted, Next keeps reporting that there is nothing left.
+type IteratorValue struct {
+	Type ValueType
+	// Returns the next value, or false once there are no values left.
+	Next func() (RuntimeValue, bool, error)
+	// Stops the iterator early, releasing anything it holds. Safe to call more than once.
+	Close func()
+}
+
+func (i IteratorValue) runtime() {}
+
+func MK_ITERATOR(next func() (RuntimeValue, bool, error), close func()) IteratorValue {
+
+	if close == nil {
+		close = func() {}
+	}
+
+	return IteratorValue{
+		Type:  Iterator,
+		Next:  next,
+		Close: close,
+	}
+}
+
+// Returns an iterator over any value a for ... in loop accepts. Iterators are returned as
+// is, file objects iterate over their lines, channels over the values received until they
+// are closed and everything else over its iteration values.
+func ToIterator(value RuntimeValue) (IteratorValue, error) {
+
+	switch v := value.(type) {
+	case IteratorValue:
+		return v, nil
+	case FileObjectValue:
+		return fileLines(v)
+	case ChannelValue:
+		return channelValues(v), nil
+	}
+
+	items, err := iteration_values(value)
+	if err != nil {
+		return IteratorValue{}, err
+	}
+
+	pos := 0
+	return MK_ITERATOR(func() (RuntimeValue, bool, error) {
+
+		if pos >= len(items) {
+			return nil, false, nil
+		}
+
+		pos++
+		return items[pos-1], true, nil
+	}, nil), nil
+}
+
+// Returns an iterator over the lines of a file, read one at a time from the current position
+// of the file. Line endings are not included.
+func fileLines(file FileObjectValue) (IteratorValue, error) {
+
+	if !file.IsOpen || !isReadable(file.Mode) {
+		return IteratorValue{}, fmt.Errorf("file: %v not opened in a valid read-mode", file.Path)
+	}
+
+	reader := bufio.NewReader(file.File)
+	done := false
+
+	return MK_ITERATOR(func() (RuntimeValue, bool, error) {
+
+		if done {
+			return nil, false, nil
+		}
+
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+
+			done = true
+
+			// The last line may not end in a newline.
+			if line == "" {
+				return nil, false, nil
+			}
+		} else if err != nil {
+			done = true
+			return nil, false, err
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		line = strings.TrimSuffix(line, "\r")
+
+		return MK_STRING(line), true, nil
+	}, nil), nil
+}
+
+// What a generator hands back each time it is resumed.
+type generatorResult struct {
+	value RuntimeValue
+	done  bool
+	err   error
+}
+
+// Used to unwind out of a generator body when its iterator is closed early.
+type generatorStop struct{}
+
+func (g generatorStop) Error() string {
+	return "generator closed"
+}
+
+// A generator part way through, its body waiting on its own goroutine to be resumed.
+type liveGenerator struct {
+	close func()
+}
+
+// Makes the iterator for a call to a generator function. The body runs on its own goroutine,
+// but only ever while the caller waits on it, so the two never run at the same time. The
+// generator belongs to the task calling the function, which stops it when it finishes if
+// the generator was left part way through, so its goroutine isn't left waiting forever.
+func new_generator(fn UserFunction, scope Environment) IteratorValue {
+
+	resume := make(chan bool)
+	results := make(chan generatorResult)
+
+	started := false
+	finished := false
+
+	live := &liveGenerator{}
+	task := scope.Stack
+
+	// Hands a value back to the caller, then waits to be resumed or closed.
+	scope.Yield = func(value RuntimeValue) error {
+
+		results <- generatorResult{value: value}
+
+		if !<-resume {
+			return generatorStop{}
+		}
+
+		return nil
+	}
+
+	run := func() {
+
+		_, err := eval_function_body(fn.Body, scope)
+
+		// Deferred calls run regardless of how the generator exited.
+		deferErr := run_deferred_calls(scope)
+
+		if _, stopped := err.(generatorStop); stopped {
+			err = nil
+		}
+
+		if err == nil {
+			err = deferErr
+		}
+
+		results <- generatorResult{done: true, err: err}
+	}
+
+	// Waits for the body to either yield or finish.
+	receive := func() (RuntimeValue, bool, error) {
+
+		result := <-results
+		if result.done {
+			finished = true
+			delete(scope.Scheduler.generators[task], live)
+			return nil, false, result.err
+		}
+
+		return result.value, true, nil
+	}
+
+	next := func() (RuntimeValue, bool, error) {
+
+		if finished {
+			return nil, false, nil
+		}
+
+		if !started {
+
+			started = true
+
+			if scope.Scheduler.generators[task] == nil {
+				scope.Scheduler.generators[task] = map[*liveGenerator]bool{}
+			}
+			scope.Scheduler.generators[task][live] = true
+
+			go run()
+		} else {
+			resume <- true
+		}
+
+		return receive()
+	}
+
+	close := func() {
+
+		if finished {
+			return
+		}
+
+		// Never started, so there is nothing to unwind.
+		if !started {
+			finished = true
+			return
+		}
+
+		// The body is waiting in a yield, tell it to unwind.
+		resume <- false
+		receive()
+	}
+
+	live.close = close
+
+	return MK_ITERATOR(next, close)
+}
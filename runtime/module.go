@@ -0,0 +1,204 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"goblin.org/main/frontend/ast"
+)
+
+// Lexes, parses and type checks the source of a module. Set by the program package, which
+// owns those stages, so modules are turned into programs the same way the entry file is.
+var Compile func(source string) (ast.Program, map[int]string, error)
+
+// The modules used by a program run, shared by every scope and task of it. Each module is
+// only evaluated once per run, however many times it is used, and every use shares its
+// exports. A fresh set is made each time a program is run, so runs don't see each other's
+// modules.
+type Modules struct {
+	loads   map[string]*moduleLoad // By absolute path, including those still loading.
+	loading []*moduleLoad          // Those part way through loading, outermost first.
+}
+
+func NewModules() *Modules {
+	return &Modules{loads: map[string]*moduleLoad{}}
+}
+
+// A module being loaded, or already loaded.
+type moduleLoad struct {
+	name   string   // As written in the using directive, for error messages.
+	task   *[]Frame // The call stack of the task loading it, to tell tasks apart.
+	done   bool
+	module Namespace
+	err    error
+}
+
+// Raised when a module ends up using itself, listing each module along the way.
+type importCycleError struct {
+	chain []string
+}
+
+func (e importCycleError) Error() string {
+	return fmt.Sprintf("import cycle: %v", strings.Join(e.chain, " -> "))
+}
+
+// Raised when a module fails to lex, parse, type check or resolve. The errors are already
+// formatted against the module's own source, so they are reported as they are, rather than
+// against the using directive that loaded it.
+type ModuleCompileError struct {
+	Path string // As written in the using directive.
+	Err  error
+}
+
+func (e ModuleCompileError) Error() string {
+	return fmt.Sprintf("in module %v:\n%v", e.Path, e.Err)
+}
+
+// Returns true if a using directive names a .gob file, rather than a stdlib namespace.
+func IsModulePath(name string) bool {
+	return strings.HasSuffix(name, ".gob")
+}
+
+// Loads the module at path, resolved relative to the entry location of this environment.
+// The module runs in its own global scope, with its own entry location, so the paths it
+// uses are relative to itself. Returns a namespace holding whatever it exported, named
+// after the file.
+func (e Environment) LoadModule(path string) (Namespace, error) {
+
+	abs, err := filepath.Abs(filepath.Join(e.EntryLocation, path))
+	if err != nil {
+		return Namespace{}, err
+	}
+
+	modules := e.Modules
+	if modules == nil {
+		modules = NewModules()
+	}
+
+	if load, exists := modules.loads[abs]; exists {
+
+		if load.done {
+			return load.module, load.err
+		}
+
+		// Using a module this task is still loading would see it half evaluated.
+		if load.task == e.Stack {
+			return Namespace{}, modules.cycle(load, path)
+		}
+
+		// Another task got there first, so wait for it to finish.
+		if err := e.Scheduler.wait(func() bool { return load.done }); err != nil {
+			return Namespace{}, err
+		}
+
+		return load.module, load.err
+	}
+
+	source, err := os.ReadFile(abs)
+	if err != nil {
+		return Namespace{}, fmt.Errorf("unable to load module: %v", path)
+	}
+
+	program, audit, err := Compile(string(source))
+	if err != nil {
+		return Namespace{}, ModuleCompileError{Path: path, Err: err}
+	}
+
+	load := &moduleLoad{name: path, task: e.Stack}
+
+	modules.loads[abs] = load
+	modules.loading = append(modules.loading, load)
+
+	load.module, load.err = e.evaluate_module(program, audit, abs, path, modules)
+
+	for i, l := range modules.loading {
+		if l == load {
+			modules.loading = append(modules.loading[:i], modules.loading[i+1:]...)
+			break
+		}
+	}
+
+	// A module that failed is loaded afresh by the next using directive naming it.
+	if load.err != nil {
+		delete(modules.loads, abs)
+	}
+
+	load.done = true
+	e.Scheduler.notify()
+
+	return load.module, load.err
+}
+
+// Returns the import cycle reached by using path, a module the current task is still
+// loading, listing each module this task is loading along the way.
+func (m *Modules) cycle(load *moduleLoad, path string) error {
+
+	chain := make([]string, 0)
+	for _, l := range m.loading {
+		if l.task == load.task && (len(chain) > 0 || l == load) {
+			chain = append(chain, l.name)
+		}
+	}
+
+	chain = append(chain, path)
+
+	return importCycleError{chain: chain}
+}
+
+// Evaluates the program of a module in a global scope of its own, returning what it exported.
+func (e Environment) evaluate_module(program ast.Program, audit map[int]string, abs string, path string, modules *Modules) (Namespace, error) {
+
+	exported := []string{}
+
+	moduleEnv := Environment{
+		Stdout:        e.Stdout,
+		Stdin:         e.Stdin,
+		EntryLocation: filepath.Dir(abs),
+		Audit:         audit,
+		Stack:         e.Stack,
+		Variables:     map[string]RuntimeValue{},
+		Constants:     map[string]bool{},
+		Namespaces:    map[string]Namespace{},
+		Exports:       &exported,
+		Modules:       modules,
+	}
+
+	moduleEnv.Setup()
+
+	// Modules run as part of the program using them.
+	moduleEnv.Scheduler = e.Scheduler
+
+	// Already holding the lock of the scheduler, so evaluated directly rather than with Interpret.
+	_, err := Evaluate(program, moduleEnv)
+	if err != nil {
+
+		// The chain already says which modules were involved.
+		var cycle importCycleError
+		if errors.As(err, &cycle) {
+			return Namespace{}, cycle
+		}
+
+		// As does the error of a module that didn't compile.
+		var compile ModuleCompileError
+		if errors.As(err, &compile) {
+			return Namespace{}, compile
+		}
+
+		return Namespace{}, fmt.Errorf("in module %v: %w", path, err)
+	}
+
+	module := Namespace{
+		Name:    strings.TrimSuffix(filepath.Base(abs), ".gob"),
+		Path:    abs,
+		Exports: map[string]RuntimeValue{},
+	}
+
+	for _, name := range exported {
+		module.Exports[name] = moduleEnv.Variables[name]
+	}
+
+	return module, nil
+}
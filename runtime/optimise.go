@@ -0,0 +1,392 @@
+package runtime
+
+import (
+	"math/big"
+
+	"goblin.org/main/frontend/ast"
+)
+
+// Rewrites a checked program so it does less work when run, without changing what it does.
+//
+// Operators applied to literals are folded into the literal they evaluate to, using the same
+// operations the interpreter does. Those that would raise an error, i.e. a division by zero,
+// are left for the program to raise when it runs. If statements with a condition known up
+// front are replaced by the branch they take, and statements after a return are dropped.
+// Folded nodes keep the span of the expression they replace, so runtime errors point at the
+// same place either way.
+func Optimise(program ast.Program) ast.Program {
+
+	program.Body = optimise_body(program.Body)
+
+	return program
+}
+
+// Optimises each statement of a body, inlining the branches of if statements decided up
+// front and stopping at the first return.
+func optimise_body(body []ast.Expression) []ast.Expression {
+
+	if body == nil {
+		return nil
+	}
+
+	optimised := make([]ast.Expression, 0, len(body))
+
+	for i, stmt := range body {
+
+		stmt = optimise(stmt)
+
+		// An if statement shares the scope it is in, so the branch it takes can take its
+		// place. Unless it is the last statement, whose value the body evaluates to.
+		if branch, decided := decided_branch(stmt); decided && i < len(body)-1 {
+			optimised = append(optimised, branch...)
+		} else {
+			optimised = append(optimised, stmt)
+		}
+
+		if n := len(optimised); n > 0 {
+			if _, returns := optimised[n-1].(ast.ReturnStatement); returns {
+				break
+			}
+		}
+	}
+
+	return optimised
+}
+
+// Returns the statements an if statement runs, if that's known up front.
+func decided_branch(stmt ast.Expression) ([]ast.Expression, bool) {
+
+	iif, isIf := stmt.(ast.IfCondition)
+	if !isIf {
+		return nil, false
+	}
+
+	condition, isBool := iif.Condition.(ast.BooleanLiteral)
+	if !isBool {
+		return nil, false
+	}
+
+	if condition.Value {
+		return iif.Body, true
+	}
+
+	if iif.ElseCatch {
+		return iif.ElseBody, true
+	}
+
+	return nil, true
+}
+
+// Optimises a single node and everything under it.
+func optimise(node ast.Expression) ast.Expression {
+
+	switch n := node.(type) {
+	case ast.BinaryExpr:
+
+		n.Left = optimise(n.Left)
+		n.Right = optimise(n.Right)
+
+		left, isLeft := literal_value(n.Left)
+		right, isRight := literal_value(n.Right)
+
+		if isLeft && isRight {
+
+			value, err := binary_operation(left, right, n.Operator)
+			if err == nil {
+				if folded, ok := value_literal(value, n.Span); ok {
+					return folded
+				}
+			}
+		}
+
+		return n
+	case ast.UnaryExpr:
+
+		n.Argument = optimise(n.Argument)
+
+		if arg, isLiteral := literal_value(n.Argument); isLiteral {
+
+			value, err := unary_operation(arg, n.Operator)
+			if err == nil {
+				if folded, ok := value_literal(value, n.Span); ok {
+					return folded
+				}
+			}
+		}
+
+		return n
+	case ast.TernaryCondition:
+
+		n.Condition = optimise(n.Condition)
+		n.Left = optimise(n.Left)
+		n.Right = optimise(n.Right)
+
+		if condition, isBool := n.Condition.(ast.BooleanLiteral); isBool {
+
+			if condition.Value {
+				return n.Left
+			}
+
+			return n.Right
+		}
+
+		return n
+	case ast.IfCondition:
+
+		n.Condition = optimise_condition(n.Condition)
+		n.Body = optimise_body(n.Body)
+		n.ElseBody = optimise_body(n.ElseBody)
+
+		condition, isBool := n.Condition.(ast.BooleanLiteral)
+		if !isBool {
+			return n
+		}
+
+		// Only the branch taken is kept, as the body of an if statement that always runs.
+		if !condition.Value && n.ElseCatch {
+			n.Body = n.ElseBody
+			n.Condition = ast.BooleanLiteral{Kind: ast.BooleanLiteralNode, Span: condition.Span, Value: true}
+		} else if !condition.Value {
+			n.Body = nil
+		}
+
+		n.ElseCatch = false
+		n.ElseBody = nil
+
+		return n
+	case ast.WhileLoop:
+
+		// Conditions are compared differently to other expressions, only their sides are folded.
+		if binop, isBinop := n.Condition.(ast.BinaryExpr); isBinop {
+			n.Condition = optimise_operands(binop)
+		}
+
+		n.Body = optimise_body(n.Body)
+
+		return n
+	case ast.ForLoop:
+
+		n.Assignment = optimise(n.Assignment).(ast.VariableDecleration)
+		n.Condition = optimise_operands(n.Condition)
+		n.Iterator = optimise(n.Iterator).(ast.ShorthandOperator)
+		n.Body = optimise_body(n.Body)
+
+		return n
+	case ast.ForInLoop:
+
+		n.Iterable = optimise(n.Iterable)
+		n.Binding = optimise_element(n.Binding)
+		n.Body = optimise_body(n.Body)
+
+		return n
+	case ast.VariableDecleration:
+		n.Value = optimise(n.Value)
+		return n
+	case ast.ArrayDecleration:
+		n.Value = optimise_list(n.Value)
+		return n
+	case ast.MapDecleration:
+
+		entries := make([]ast.MapEntry, 0, len(n.Value))
+		for _, entry := range n.Value {
+			entries = append(entries, ast.MapEntry{Key: optimise(entry.Key), Value: optimise(entry.Value)})
+		}
+
+		n.Value = entries
+
+		return n
+	case ast.DestructuringDecleration:
+
+		n.Value = optimise(n.Value)
+		n.Pattern = optimise_pattern(n.Pattern)
+
+		return n
+	case ast.FunctionDecleration:
+
+		params := make([]ast.Parameter, 0, len(n.Params))
+		for _, param := range n.Params {
+
+			param.Default = optimise(param.Default)
+
+			if param.Pattern != nil {
+				pattern := optimise_pattern(*param.Pattern)
+				param.Pattern = &pattern
+			}
+
+			params = append(params, param)
+		}
+
+		n.Params = params
+		n.Body = optimise_body(n.Body)
+
+		return n
+	case ast.ExportStatement:
+		n.Decleration = optimise(n.Decleration)
+		return n
+	case ast.ReturnStatement:
+		n.Value = optimise(n.Value)
+		return n
+	case ast.YieldStatement:
+		n.Value = optimise(n.Value)
+		return n
+	case ast.DeferStatement:
+		n.Call = optimise(n.Call).(ast.CallExpr)
+		return n
+	case ast.SpawnStatement:
+		n.Call = optimise(n.Call).(ast.CallExpr)
+		return n
+	case ast.AssignmentExpr:
+
+		n.Value = optimise(n.Value)
+		n.Assigne = optimise(n.Assigne)
+
+		return n
+	case ast.ShorthandOperator:
+		n.Right = optimise(n.Right)
+		return n
+	case ast.ArrayOrMapIdentifier:
+		n.Index = optimise(n.Index)
+		return n
+	case ast.SliceExpr:
+
+		n.Start = optimise(n.Start)
+		n.End = optimise(n.End)
+
+		return n
+	case ast.ArrayLiteral:
+		n.Elements = optimise_list(n.Elements)
+		return n
+	case ast.ObjectLiteral:
+
+		props := make([]ast.Property, 0, len(n.Properties))
+		for _, prop := range n.Properties {
+
+			if prop.Value != nil {
+				value := optimise(*prop.Value)
+				prop.Value = &value
+			}
+
+			props = append(props, prop)
+		}
+
+		n.Properties = props
+
+		return n
+	case ast.CallExpr:
+
+		n.Args = optimise_list(n.Args)
+		n.Caller = optimise(n.Caller)
+
+		return n
+	case ast.SpreadExpr:
+		n.Argument = optimise(n.Argument)
+		return n
+	}
+
+	return node
+}
+
+// Optimises the condition of an if statement. Comparisons of literals are decided up front,
+// the same way the interpreter would, other conditions are left as they are.
+func optimise_condition(condition ast.Expression) ast.Expression {
+
+	binop, isBinop := condition.(ast.BinaryExpr)
+	if !isBinop {
+		return condition
+	}
+
+	binop = optimise_operands(binop)
+
+	left, isLeft := literal_value(binop.Left)
+	right, isRight := literal_value(binop.Right)
+
+	if isLeft && isRight {
+
+		holds, err := if_condition(left, right, binop.Operator)
+		if err == nil {
+			return ast.BooleanLiteral{Kind: ast.BooleanLiteralNode, Span: binop.Span, Value: holds}
+		}
+	}
+
+	return binop
+}
+
+func optimise_operands(binop ast.BinaryExpr) ast.BinaryExpr {
+
+	binop.Left = optimise(binop.Left)
+	binop.Right = optimise(binop.Right)
+
+	return binop
+}
+
+func optimise_list(nodes []ast.Expression) []ast.Expression {
+
+	if nodes == nil {
+		return nil
+	}
+
+	optimised := make([]ast.Expression, 0, len(nodes))
+	for _, node := range nodes {
+		optimised = append(optimised, optimise(node))
+	}
+
+	return optimised
+}
+
+func optimise_pattern(pattern ast.Pattern) ast.Pattern {
+
+	elements := make([]ast.PatternElement, 0, len(pattern.Elements))
+	for _, el := range pattern.Elements {
+		elements = append(elements, optimise_element(el))
+	}
+
+	pattern.Elements = elements
+
+	return pattern
+}
+
+func optimise_element(element ast.PatternElement) ast.PatternElement {
+
+	element.Default = optimise(element.Default)
+
+	if element.Pattern != nil {
+		pattern := optimise_pattern(*element.Pattern)
+		element.Pattern = &pattern
+	}
+
+	return element
+}
+
+// Returns the value of a literal the optimiser can fold.
+func literal_value(node ast.Expression) (RuntimeValue, bool) {
+
+	switch n := node.(type) {
+	case ast.NumericLiteral:
+		return MK_NUMBER(n.Value), true
+	case ast.BigIntLiteral:
+		return MK_INTEGER(new(big.Int).Set(n.Value)), true
+	case ast.StringLiteral:
+		return MK_STRING(n.Value), true
+	case ast.BooleanLiteral:
+		return MK_BOOL(n.Value), true
+	}
+
+	return nil, false
+}
+
+// Returns the literal evaluating to value, if there is one.
+func value_literal(value RuntimeValue, span ast.Span) (ast.Expression, bool) {
+
+	switch v := value.(type) {
+	case NumberValue:
+		return ast.NumericLiteral{Kind: ast.NumericLiteralNode, Span: span, Value: v.Value}, true
+	case BigIntValue:
+		return ast.BigIntLiteral{Kind: ast.BigIntLiteralNode, Span: span, Value: new(big.Int).Set(v.Value)}, true
+	case StringValue:
+		return ast.StringLiteral{Kind: ast.StringLiteralNode, Span: span, Value: v.Value}, true
+	case BooleanValue:
+		return ast.BooleanLiteral{Kind: ast.BooleanLiteralNode, Span: span, Value: v.Value}, true
+	}
+
+	return nil, false
+}
@@ -3,15 +3,24 @@ package runtime
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
 var Strings = Namespace{
-	Name: "data",
+	Name: "strings",
 	Functions: map[string]NativeFunction{
 		"split": {
 			Type: "NativeFn",
 			Call: split,
 		},
+		"encode": {
+			Type: "NativeFn",
+			Call: encode,
+		},
+		"decode": {
+			Type: "NativeFn",
+			Call: decode,
+		},
 	},
 }
 
@@ -41,8 +50,135 @@ var split FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValu
 	sRuntime := make([]RuntimeValue, 0)
 	for _, st := range splits {
 
-		sRuntime = append(sRuntime, StringValue{Value: st, Type: "StringValue"})
+		sRuntime = append(sRuntime, MK_STRING(st))
 	}
 
 	return MK_ARRAY(sRuntime), nil
 }
+
+// encode, converts string `s` into bytes using the given encoding, one of utf-8, ascii or latin-1.
+// strings.encode(s str, encoding str) bytes
+var encode FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 2 {
+		return nil, fmt.Errorf("unexpected number of args for strings.encode, expected 2 got %v", numArgs)
+	}
+
+	str, isStr := args[0].(StringValue)
+	if !isStr {
+		return nil, fmt.Errorf("strings.encode must be used on string type, %v type given", TypeName(args[0]))
+	}
+
+	enc, isStr := args[1].(StringValue)
+	if !isStr {
+		return nil, fmt.Errorf("strings.encode encoding must be string type, %v type given", TypeName(args[1]))
+	}
+
+	encoding, err := normaliseEncoding(enc.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if encoding == "utf-8" {
+		return MK_BYTES([]byte(str.Value)), nil
+	}
+
+	// Single byte encodings, each character must fit within the encoding's range.
+	limit := rune(0x7f)
+	if encoding == "latin-1" {
+		limit = 0xff
+	}
+
+	encoded := make([]byte, 0, len(str.Value))
+	for _, r := range str.Value {
+
+		if r > limit {
+			return nil, fmt.Errorf("cannot encode '%c' as %v", r, encoding)
+		}
+
+		encoded = append(encoded, byte(r))
+	}
+
+	return MK_BYTES(encoded), nil
+}
+
+// decode, converts bytes `b` into a string using the given encoding, one of utf-8, ascii or latin-1.
+// strings.decode(b bytes, encoding str) str
+var decode FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 2 {
+		return nil, fmt.Errorf("unexpected number of args for strings.decode, expected 2 got %v", numArgs)
+	}
+
+	b, isBytes := args[0].(BytesValue)
+	if !isBytes {
+		return nil, fmt.Errorf("strings.decode must be used on bytes type, %v type given", TypeName(args[0]))
+	}
+
+	enc, isStr := args[1].(StringValue)
+	if !isStr {
+		return nil, fmt.Errorf("strings.decode encoding must be string type, %v type given", TypeName(args[1]))
+	}
+
+	encoding, err := normaliseEncoding(enc.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch encoding {
+	case "utf-8":
+
+		if !utf8.Valid(b.Value) {
+
+			// Find where the invalid sequence starts, to point the user at it.
+			offset := 0
+			for offset < len(b.Value) {
+
+				r, size := utf8.DecodeRune(b.Value[offset:])
+				if r == utf8.RuneError && size <= 1 {
+					break
+				}
+
+				offset += size
+			}
+
+			return nil, fmt.Errorf("invalid utf-8 byte 0x%02x at offset %v", b.Value[offset], offset)
+		}
+
+		return MK_STRING(string(b.Value)), nil
+	case "ascii":
+
+		for i, c := range b.Value {
+			if c > 0x7f {
+				return nil, fmt.Errorf("invalid ascii byte 0x%02x at offset %v", c, i)
+			}
+		}
+
+		return MK_STRING(string(b.Value)), nil
+	}
+
+	// Latin-1 maps each byte straight onto the first 256 code points.
+	runes := make([]rune, 0, len(b.Value))
+	for _, c := range b.Value {
+		runes = append(runes, rune(c))
+	}
+
+	return MK_STRING(string(runes)), nil
+}
+
+// Resolves the accepted spellings of an encoding name to a single one.
+func normaliseEncoding(name string) (string, error) {
+
+	switch strings.ToLower(name) {
+	case "utf-8", "utf8":
+		return "utf-8", nil
+	case "ascii", "us-ascii":
+		return "ascii", nil
+	case "latin-1", "latin1", "iso-8859-1":
+		return "latin-1", nil
+	}
+
+	return "", fmt.Errorf("unknown encoding '%v', expected one of utf-8, ascii or latin-1", name)
+}
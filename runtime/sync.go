@@ -0,0 +1,221 @@
+package runtime
+
+import (
+	"fmt"
+)
+
+var Sync = Namespace{
+	Name: "sync",
+	Functions: map[string]NativeFunction{
+		"waitgroup": {
+			Type: "NativeFn",
+			Call: waitgroup,
+		},
+		"add": {
+			Type: "NativeFn",
+			Call: syncAdd,
+		},
+		"done": {
+			Type: "NativeFn",
+			Call: syncDone,
+		},
+		"wait": {
+			Type: "NativeFn",
+			Call: syncWait,
+		},
+		"mutex": {
+			Type: "NativeFn",
+			Call: mutex,
+		},
+		"lock": {
+			Type: "NativeFn",
+			Call: lock,
+		},
+		"unlock": {
+			Type: "NativeFn",
+			Call: unlock,
+		},
+	},
+}
+
+// Waits for a number of tasks to finish, see sync.waitgroup.
+type WaitGroupValue struct {
+	Type  ValueType
+	Value *waitGroupState
+}
+
+func (w WaitGroupValue) runtime() {}
+
+// Only read and written while holding the lock of the scheduler, which tasks wait on for it.
+type waitGroupState struct {
+	count int
+}
+
+// Lets one task at a time into a section of code, see sync.mutex.
+type MutexValue struct {
+	Type  ValueType
+	Value *mutexState
+}
+
+func (m MutexValue) runtime() {}
+
+// As with waitGroupState, tasks wait on the scheduler for it.
+type mutexState struct {
+	locked bool
+}
+
+// waitgroup, returns a new wait group with a counter of 0
+// sync.waitgroup() waitgroup
+var waitgroup FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 0 {
+		return nil, fmt.Errorf("unexpected number of args for sync.waitgroup, expected 0 got %v", numArgs)
+	}
+
+	return WaitGroupValue{Type: WaitGroup, Value: &waitGroupState{}}, nil
+}
+
+// add, adds n to the wait group counter
+// sync.add(wg waitgroup, n int)
+var syncAdd FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	wg, err := waitGroupArg("sync.add", args, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	n, isInt := args[1].(NumberValue)
+	if !isInt {
+		return nil, fmt.Errorf("sync.add expectes arg2 to be of type int, %v given", TypeName(args[1]))
+	}
+
+	if wg.count+n.Value < 0 {
+		return nil, fmt.Errorf("sync.add would make the wait group counter negative")
+	}
+
+	wg.count += n.Value
+	if wg.count == 0 {
+		env.Scheduler.notify()
+	}
+
+	return MK_NULL(), nil
+}
+
+// done, takes one from the wait group counter
+// sync.done(wg waitgroup)
+var syncDone FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	wg, err := waitGroupArg("sync.done", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if wg.count == 0 {
+		return nil, fmt.Errorf("sync.done called more times than sync.add")
+	}
+
+	wg.count--
+	if wg.count == 0 {
+		env.Scheduler.notify()
+	}
+
+	return MK_NULL(), nil
+}
+
+// wait, waits until the wait group counter is back to 0
+// sync.wait(wg waitgroup)
+var syncWait FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	wg, err := waitGroupArg("sync.wait", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := env.Scheduler.wait(func() bool { return wg.count == 0 }); err != nil {
+		return nil, err
+	}
+
+	return MK_NULL(), nil
+}
+
+// mutex, returns a new, unlocked mutex
+// sync.mutex() mutex
+var mutex FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	numArgs := len(args)
+	if numArgs != 0 {
+		return nil, fmt.Errorf("unexpected number of args for sync.mutex, expected 0 got %v", numArgs)
+	}
+
+	return MutexValue{Type: Mutex, Value: &mutexState{}}, nil
+}
+
+// lock, waits until the mutex is unlocked, then locks it
+// sync.lock(m mutex)
+var lock FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	m, err := mutexArg("sync.lock", args)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := env.Scheduler.wait(func() bool { return !m.locked }); err != nil {
+		return nil, err
+	}
+
+	m.locked = true
+
+	return MK_NULL(), nil
+}
+
+// unlock, unlocks the mutex, letting the next waiting task lock it
+// sync.unlock(m mutex)
+var unlock FunctionCall = func(args []RuntimeValue, env Environment) (RuntimeValue, error) {
+
+	m, err := mutexArg("sync.unlock", args)
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.locked {
+		return nil, fmt.Errorf("sync.unlock of unlocked mutex")
+	}
+
+	m.locked = false
+	env.Scheduler.notify()
+
+	return MK_NULL(), nil
+}
+
+// Checks the args of a wait group function, returning the wait group they start with.
+func waitGroupArg(name string, args []RuntimeValue, expected int) (*waitGroupState, error) {
+
+	numArgs := len(args)
+	if numArgs != expected {
+		return nil, fmt.Errorf("unexpected number of args for %v, expected %v got %v", name, expected, numArgs)
+	}
+
+	wg, isWaitGroup := args[0].(WaitGroupValue)
+	if !isWaitGroup {
+		return nil, fmt.Errorf("%v expectes arg1 to be of type waitgroup, %v given", name, TypeName(args[0]))
+	}
+
+	return wg.Value, nil
+}
+
+// Checks the args of a mutex function, returning the mutex.
+func mutexArg(name string, args []RuntimeValue) (*mutexState, error) {
+
+	numArgs := len(args)
+	if numArgs != 1 {
+		return nil, fmt.Errorf("unexpected number of args for %v, expected 1 got %v", name, numArgs)
+	}
+
+	m, isMutex := args[0].(MutexValue)
+	if !isMutex {
+		return nil, fmt.Errorf("%v expectes arg1 to be of type mutex, %v given", name, TypeName(args[0]))
+	}
+
+	return m.Value, nil
+}
@@ -0,0 +1,214 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	goruntime "runtime"
+	"sync"
+
+	"goblin.org/main/frontend/ast"
+)
+
+// How many loop iterations run before a task gives others a turn.
+const schedulePeriod = 64
+
+// Runs the tasks of a program, see Environment.Setup. Only the task holding its lock
+// evaluates anything, so environments and values are never read and written by two tasks at
+// once. A task hands the lock over while it waits on a channel, wait group, mutex or module,
+// while it blocks on IO and every so often while looping.
+type Scheduler struct {
+	lock      sync.Mutex
+	wake      *sync.Cond    // Signalled whenever something a task may be waiting on changes.
+	ticks     int           // Loop iterations run, see schedule.
+	tasks     int           // Tasks started and not yet finished.
+	main      int           // Programs being interpreted, each is a task of its own.
+	waiting   int           // Tasks waiting since wake was last signalled.
+	deadlocks int           // How many times every task has ended up waiting.
+	deadlock  deadlockError // The last of those.
+
+	// The generators each task has started and not yet finished, by call stack. Those left
+	// part way through are stopped when the task finishes, see new_generator.
+	generators map[*[]Frame]map[*liveGenerator]bool
+}
+
+func NewScheduler() *Scheduler {
+
+	s := &Scheduler{generators: map[*[]Frame]map[*liveGenerator]bool{}}
+	s.wake = sync.NewCond(&s.lock)
+
+	return s
+}
+
+// Raised in every task that was waiting once none of them can ever be woken.
+type deadlockError struct {
+	main bool // Whether the program itself was waiting, rather than just tasks it spawned.
+}
+
+func (e deadlockError) Error() string {
+	return "deadlock: every task is waiting"
+}
+
+// Evaluates a whole program, holding the lock of its scheduler while it runs. Tasks it spawns
+// carry on in the background once it returns. Modules are loaded afresh for each program.
+func Interpret(program ast.Program, env Environment) (RuntimeValue, error) {
+
+	env = env.start()
+	defer env.Scheduler.finish(env.Stack, true)
+
+	return Evaluate(program, env)
+}
+
+// Takes the lock of the scheduler as the main task of a program run, making the scheduler,
+// call stack and modules of the run if need be.
+func (e Environment) start() Environment {
+
+	if e.Scheduler == nil {
+		e.Scheduler = NewScheduler()
+	}
+
+	if e.Stack == nil {
+		e.Stack = &[]Frame{}
+	}
+
+	e.Modules = NewModules()
+
+	e.Scheduler.lock.Lock()
+	e.Scheduler.tasks++
+	e.Scheduler.main++
+
+	return e
+}
+
+// Ends a task, handing over the lock for good. Generators it left part way through are
+// stopped first. Tasks left waiting might now be deadlocked, so they are woken to check.
+func (s *Scheduler) finish(task *[]Frame, main bool) {
+
+	// Stopping one may start or stop others, so it is taken from the top each time.
+	for len(s.generators[task]) > 0 {
+		for g := range s.generators[task] {
+			g.close()
+			break
+		}
+	}
+
+	delete(s.generators, task)
+
+	s.tasks--
+	if main {
+		s.main--
+	}
+
+	s.notify()
+	s.lock.Unlock()
+}
+
+// Called once per loop iteration, so a busy loop can't keep every other task waiting.
+func (s *Scheduler) schedule() {
+
+	s.ticks++
+	if s.ticks%schedulePeriod == 0 {
+		s.blocking(goruntime.Gosched)
+	}
+}
+
+// Runs an operation that may block without holding the lock, so other tasks can run in the
+// meantime. Only for operations that don't wait on other tasks, like reading stdin, those
+// use wait instead.
+func (s *Scheduler) blocking(op func()) {
+
+	s.lock.Unlock()
+	defer s.lock.Lock()
+
+	op()
+}
+
+// Waits until ready returns true, handing over the lock in the meantime. Ready is checked
+// again each time another task calls notify. Once every task is waiting, none of them ever
+// will be notified, so each of them returns a deadlock error instead.
+func (s *Scheduler) wait(ready func() bool) error {
+
+	for !ready() {
+
+		s.waiting++
+		if s.waiting >= s.tasks {
+			s.deadlocks++
+			s.deadlock = deadlockError{main: s.main > 0}
+			s.notify()
+			return s.deadlock
+		}
+
+		deadlocks := s.deadlocks
+		s.wake.Wait()
+
+		if s.deadlocks != deadlocks {
+			return s.deadlock
+		}
+	}
+
+	return nil
+}
+
+// Wakes every waiting task to check whether what it is waiting on is ready, called after
+// changing a channel, wait group, mutex or module.
+func (s *Scheduler) notify() {
+
+	// They count themselves again if they go back to waiting.
+	s.waiting = 0
+	s.wake.Broadcast()
+}
+
+// Evaluates a 'spawn' statement. The function and its args are evaluated now, the call
+// itself runs as a new task. Errors raised by the task are written to stdout.
+func eval_spawn_statement(s ast.SpawnStatement, env Environment) (RuntimeValue, error) {
+
+	args, err := eval_call_args(s.Call.Args, env)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, err := Evaluate(s.Call.Caller, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch fn.(type) {
+	case NativeFunction, UserFunction, CompiledFunction:
+	default:
+		return nil, fmt.Errorf("spawn requires a function, %v given", TypeName(fn))
+	}
+
+	spawn_task(fn, args, s.Call.Span, env)
+
+	return MK_NULL(), nil
+}
+
+// Calls fn as a new task, which runs once the current one hands over the lock. A deadlock
+// the program itself is part of is left for the program to report.
+func spawn_task(fn RuntimeValue, args []RuntimeValue, site ast.Span, env Environment) {
+
+	// Each task has a call stack of its own.
+	task := env
+	task.Stack = &[]Frame{}
+
+	// Counted straight away, the task is runnable before it gets the lock.
+	scheduler := env.Scheduler
+	scheduler.tasks++
+
+	go func() {
+
+		scheduler.lock.Lock()
+		defer scheduler.finish(task.Stack, false)
+
+		_, err := call_function(fn, args, site, task)
+		if err == nil {
+			return
+		}
+
+		var deadlock deadlockError
+		if errors.As(err, &deadlock) && deadlock.main {
+			return
+		}
+
+		fmt.Fprint(task.Stdout, FormatError("task error: ", err)+"\n"+FormatStackTrace(StackTrace(err)))
+	}()
+}
@@ -0,0 +1,115 @@
+package runtime
+
+import "goblin.org/main/frontend/ast"
+
+// Returns the name of a runtime value's type, as it would be written in a type annotation.
+func TypeName(value RuntimeValue) string {
+
+	switch value.(type) {
+	case NumberValue, BigIntValue:
+		return "int"
+	case StringValue:
+		return "string"
+	case BytesValue:
+		return "bytes"
+	case BooleanValue:
+		return "bool"
+	case ArrayValue:
+		return "array"
+	case MapValue:
+		return "map"
+	case SetValue:
+		return "set"
+	case ObjectVal:
+		return "object"
+	case NullValue:
+		return "null"
+	case NativeFunction, UserFunction, CompiledFunction:
+		return "fn"
+	case FileObjectValue:
+		return "fileObject"
+	case IteratorValue:
+		return "iterator"
+	case ChannelValue:
+		return "chan"
+	case WaitGroupValue:
+		return "waitgroup"
+	case MutexValue:
+		return "mutex"
+	}
+
+	return "unknown"
+}
+
+// Checks a runtime value satisfies a type annotation, including the contents of arrays and maps.
+func MatchesType(value RuntimeValue, t ast.TypeAnnotation) bool {
+
+	switch t.Name {
+	case "any":
+		return true
+	case "int", "float":
+		return IsInteger(value)
+	case "string":
+		_, ok := value.(StringValue)
+		return ok
+	case "bool":
+		_, ok := value.(BooleanValue)
+		return ok
+	case "bytes":
+		_, ok := value.(BytesValue)
+		return ok
+	case "object":
+		_, ok := value.(ObjectVal)
+		return ok
+	case "iterator":
+		_, ok := value.(IteratorValue)
+		return ok
+	case "chan":
+		_, ok := value.(ChannelValue)
+		return ok
+	case "array":
+
+		arr, ok := value.(ArrayValue)
+		if !ok {
+			return false
+		}
+
+		for _, elem := range *arr.Value {
+			if !MatchesType(elem, t.Params[0]) {
+				return false
+			}
+		}
+
+		return true
+	case "set":
+
+		set, ok := value.(SetValue)
+		if !ok {
+			return false
+		}
+
+		for _, elem := range *set.Value {
+			if !MatchesType(elem, t.Params[0]) {
+				return false
+			}
+		}
+
+		return true
+	case "map":
+
+		m, ok := value.(MapValue)
+		if !ok {
+			return false
+		}
+
+		for _, entry := range m.Value.Entries() {
+			if !MatchesType(entry.Key, t.Params[0]) || !MatchesType(entry.Value, t.Params[1]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
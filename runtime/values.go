@@ -2,7 +2,10 @@ package runtime
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"os"
+	"reflect"
 
 	"goblin.org/main/frontend/ast"
 )
@@ -12,16 +15,24 @@ type ValueType string
 const (
 	// Types
 	Number     ValueType = "Number"
+	BigInt     ValueType = "BigInt"
 	Array      ValueType = "Array"
 	Map        ValueType = "Map"
+	Set        ValueType = "Set"
 	Null       ValueType = "Null"
 	Boolean    ValueType = "Boolean"
 	String     ValueType = "String"
+	Bytes      ValueType = "Bytes"
 	Object     ValueType = "Object"
 	FileObject ValueType = "FileObject"
+	Iterator   ValueType = "Iterator"
+	Chan       ValueType = "Chan"
+	WaitGroup  ValueType = "WaitGroup"
+	Mutex      ValueType = "Mutex"
 
 	NativeFn    ValueType = "NativeFn"
 	UserFn      ValueType = "UserFn"
+	CompiledFn  ValueType = "CompiledFn"
 	Conditional ValueType = "Contitional"
 )
 
@@ -52,24 +63,46 @@ func (n NumberValue) runtime() {
 	fmt.Printf("%v\n", n.Value)
 }
 
-type ArrayValue struct {
+// An int too large to fit in a NumberValue. Arithmetic promotes to a BigIntValue on
+// overflow, and results that fit are turned back into a NumberValue.
+type BigIntValue struct {
 	Type  ValueType
-	Value *[]RuntimeValue
+	Value *big.Int
+}
+
+func (b BigIntValue) runtime() {}
+
+type ArrayValue struct {
+	Type   ValueType
+	Value  *[]RuntimeValue
+	Frozen *bool // Shared by every copy of the value, see Freeze.
 }
 
 func (a ArrayValue) runtime() {
 	fmt.Printf("%v\n", a.Value)
 }
 
+// Keys are compared by value, see HashKey.
 type MapValue struct {
-	Type  ValueType
-	Value *map[RuntimeValue]RuntimeValue
+	Type   ValueType
+	Value  *HashMap
+	Frozen *bool
 }
 
 func (m MapValue) runtime() {
 	fmt.Printf("%v\n", m.Value)
 }
 
+// An unordered collection of unique values. Elements are stored by their hash key, so
+// equal values are only ever held once.
+type SetValue struct {
+	Type   ValueType
+	Value  *map[string]RuntimeValue
+	Frozen *bool
+}
+
+func (s SetValue) runtime() {}
+
 type StringValue struct {
 	Type  ValueType
 	Value string
@@ -77,6 +110,14 @@ type StringValue struct {
 
 func (s StringValue) runtime() {}
 
+// An immutable sequence of bytes, i.e. b"\x00data". Indexing yields ints.
+type BytesValue struct {
+	Type  ValueType
+	Value []byte
+}
+
+func (b BytesValue) runtime() {}
+
 type BooleanValue struct {
 	Type  ValueType
 	Value bool
@@ -87,6 +128,7 @@ func (b BooleanValue) runtime() {}
 type ObjectVal struct {
 	Type       ValueType
 	Properties map[string]RuntimeValue
+	Frozen     *bool
 }
 
 func (o ObjectVal) runtime() {}
@@ -101,11 +143,12 @@ type NativeFunction struct {
 func (n NativeFunction) runtime() {}
 
 type UserFunction struct {
-	Type   ValueType
-	Name   string
-	Params []string
-	DecEnv Environment
-	Body   []ast.Expression
+	Type      ValueType
+	Name      string
+	Params    []ast.Parameter
+	DecEnv    Environment
+	Body      []ast.Expression
+	Generator bool // Calls return an iterator over the values the body yields.
 }
 
 func (f UserFunction) runtime() {}
@@ -147,19 +190,43 @@ func MK_NUMBER(n int) NumberValue {
 	}
 }
 
+// Makes an int from a big.Int, only keeping it as a BigIntValue when it doesn't fit in a NumberValue.
+func MK_INTEGER(n *big.Int) RuntimeValue {
+
+	if n.IsInt64() && n.Int64() >= math.MinInt && n.Int64() <= math.MaxInt {
+		return MK_NUMBER(int(n.Int64()))
+	}
+
+	return BigIntValue{
+		Type:  BigInt,
+		Value: n,
+	}
+}
+
 func MK_ARRAY(elements []RuntimeValue) ArrayValue {
 
 	return ArrayValue{
-		Type:  "Array",
-		Value: &elements,
+		Type:   "Array",
+		Value:  &elements,
+		Frozen: new(bool),
 	}
 }
 
-func MK_MAP(elements map[RuntimeValue]RuntimeValue) MapValue {
+func MK_MAP(elements *HashMap) MapValue {
 
 	return MapValue{
-		Type:  "Map",
-		Value: &elements,
+		Type:   "Map",
+		Value:  elements,
+		Frozen: new(bool),
+	}
+}
+
+func MK_SET(elements map[string]RuntimeValue) SetValue {
+
+	return SetValue{
+		Type:   Set,
+		Value:  &elements,
+		Frozen: new(bool),
 	}
 }
 
@@ -171,6 +238,14 @@ func MK_STRING(s string) StringValue {
 	}
 }
 
+func MK_BYTES(b []byte) BytesValue {
+
+	return BytesValue{
+		Type:  Bytes,
+		Value: b,
+	}
+}
+
 func MK_NULL() NullValue {
 
 	return NullValue{
@@ -197,7 +272,22 @@ func MK_NATIVE_FN(call FunctionCall) NativeFunction {
 
 type Namespace struct {
 	Name      string
+	Path      string // Where a .gob module was loaded from, empty for the stdlib.
 	Functions map[string]NativeFunction
+	Exports   map[string]RuntimeValue // What a .gob module exported, nil for the stdlib.
 }
 
 func (n Namespace) runtime() {}
+
+// Returns true if both are the same stdlib namespace or module, whatever name they're used under.
+// A module is the same as itself loaded again by a later run, i.e. by the next REPL line.
+func (n Namespace) Same(other Namespace) bool {
+
+	if n.Path != "" || other.Path != "" {
+		return n.Path == other.Path
+	}
+
+	return n.Name == other.Name &&
+		reflect.ValueOf(n.Functions).UnsafePointer() == reflect.ValueOf(other.Functions).UnsafePointer() &&
+		reflect.ValueOf(n.Exports).UnsafePointer() == reflect.ValueOf(other.Exports).UnsafePointer()
+}
@@ -0,0 +1,730 @@
+package runtime
+
+import (
+	"fmt"
+	"math/big"
+
+	"goblin.org/main/frontend/ast"
+)
+
+// A function compiled to bytecode, closed over the variables of the functions it was
+// declared in.
+type CompiledFunction struct {
+	Type     ValueType
+	Code     *Bytecode
+	Upvalues []*upvalue
+	Globals  Environment // The global scope of the program it was declared in.
+}
+
+func (f CompiledFunction) runtime() {}
+
+// A variable held in a slot of a call. Slots start off undeclared, so reads fall through to
+// the global of the same name until the declaration runs, the same as the tree-walker.
+type local struct {
+	value    RuntimeValue
+	declared bool
+	constant bool
+}
+
+// A variable captured by a closure. It points at the slot while the scope declaring it is
+// live, then at a copy of its own once that scope is left, so closures keep what they saw.
+type upvalue struct {
+	local *local
+}
+
+type openUpvalue struct {
+	slot    int
+	upvalue *upvalue
+}
+
+// A call being run.
+type frame struct {
+	fn       CompiledFunction
+	code     *Bytecode
+	env      Environment // The caller's, with the audit of this function. Natives only need its IO and stack.
+	locals   []local
+	open     []openUpvalue
+	stack    []RuntimeValue
+	iters    []IteratorValue // The for ... in loops being run, innermost last.
+	deferred []DeferredCall
+	args     []RuntimeValue
+}
+
+// Runs a program compiled by CompileBytecode, holding the lock of its scheduler while it runs.
+// Tasks it spawns carry on in the background once it returns. Modules are loaded afresh for
+// each program.
+func Execute(code *Bytecode, env Environment) (RuntimeValue, error) {
+
+	env = env.start()
+	defer env.Scheduler.finish(env.Stack, true)
+
+	f := new_frame(CompiledFunction{Type: CompiledFn, Code: code, Globals: env}, env, nil)
+	defer f.stop_iterations()
+
+	return f.run(0)
+}
+
+func new_frame(fn CompiledFunction, env Environment, args []RuntimeValue) *frame {
+
+	env.Audit = fn.Code.Audit
+
+	return &frame{
+		fn:     fn,
+		code:   fn.Code,
+		env:    env,
+		locals: make([]local, fn.Code.Slots),
+		stack:  make([]RuntimeValue, 0, 16),
+		args:   args,
+	}
+}
+
+// Calls a compiled function with already evaluated args, site is where the call was made from.
+func call_compiled(fn CompiledFunction, args []RuntimeValue, site ast.Span, env Environment) (RuntimeValue, error) {
+
+	pop, err := env.pushFrame(Frame{Function: fn.Code.Name, CallSite: site, Source: env.Audit[site.Start.Line]})
+	if err != nil {
+		return nil, err
+	}
+	defer pop()
+
+	f := new_frame(fn, env, args)
+
+	result, err := f.run(0)
+	f.stop_iterations()
+
+	// Deferred calls run regardless of how the function body exited.
+	deferErr := run_deferred(f.deferred, f.env)
+
+	// An error from the body takes priority over one raised by a deferred call.
+	if err != nil {
+		return nil, err
+	}
+
+	if deferErr != nil {
+		return nil, deferErr
+	}
+
+	return result, nil
+}
+
+// Stops the iterations left part way through, i.e. by a 'return' or an error.
+func (f *frame) stop_iterations() {
+
+	for i := len(f.iters) - 1; i >= 0; i-- {
+		f.iters[i].Close()
+	}
+
+	f.iters = nil
+}
+
+func (f *frame) push(value RuntimeValue) {
+	f.stack = append(f.stack, value)
+}
+
+func (f *frame) pop() RuntimeValue {
+
+	value := f.stack[len(f.stack)-1]
+	f.stack = f.stack[:len(f.stack)-1]
+
+	return value
+}
+
+func (f *frame) peek() RuntimeValue {
+	return f.stack[len(f.stack)-1]
+}
+
+// Pops the top n values, in the order they were pushed.
+func (f *frame) pop_n(n int) []RuntimeValue {
+
+	values := make([]RuntimeValue, n)
+	copy(values, f.stack[len(f.stack)-n:])
+	f.stack = f.stack[:len(f.stack)-n]
+
+	return values
+}
+
+// Runs instructions from pc until the function returns, or the default value started at pc
+// is handed back.
+func (f *frame) run(pc int) (RuntimeValue, error) {
+
+	code := f.code.Code
+
+	for {
+
+		start := pc
+		op := Opcode(code[pc])
+		pc++
+
+		var err error
+
+		switch op {
+		case OpConstant:
+			f.push(f.code.Constants[code[pc]])
+			pc++
+		case OpCopyConstant:
+
+			// Bytes and big ints can be modified in place, so each evaluation needs its own.
+			switch v := f.code.Constants[code[pc]].(type) {
+			case BigIntValue:
+				f.push(MK_INTEGER(new(big.Int).Set(v.Value)))
+			case BytesValue:
+				f.push(MK_BYTES(append([]byte{}, v.Value...)))
+			default:
+				f.push(v)
+			}
+			pc++
+		case OpNull:
+			f.push(MK_NULL())
+		case OpNil:
+			f.push(nil)
+		case OpPop:
+			f.pop()
+		case OpOrNull:
+
+			if f.peek() == nil {
+				f.stack[len(f.stack)-1] = MK_NULL()
+			}
+		case OpGetLocal:
+
+			slot := code[pc]
+			if l := &f.locals[slot]; l.declared {
+				f.push(l.value)
+			} else {
+				err = f.get_global(f.code.SlotNames[slot], int(code[pc+1]))
+			}
+			pc += 2
+		case OpGetUpvalue:
+
+			uv := f.fn.Upvalues[code[pc]]
+			if uv.local.declared {
+				f.push(uv.local.value)
+			} else {
+				err = f.get_global(f.code.Upvalues[code[pc]].Name, int(code[pc+1]))
+			}
+			pc += 2
+		case OpGetGlobal:
+			err = f.get_global(f.code.Names[code[pc]], int(code[pc+1]))
+			pc += 2
+		case OpSetLocal:
+			err = f.set(&f.locals[code[pc]], f.code.SlotNames[code[pc]])
+			pc++
+		case OpSetUpvalue:
+			err = f.set(f.fn.Upvalues[code[pc]].local, f.code.Upvalues[code[pc]].Name)
+			pc++
+		case OpSetGlobal:
+			_, err = f.fn.Globals.Assign(f.code.Names[code[pc]], f.peek())
+			pc++
+		case OpDeclareLocal:
+			err = f.declare_local(int(code[pc]), f.peek(), code[pc+1] == 1)
+			pc += 2
+		case OpDeclareGlobal:
+			_, err = f.fn.Globals.Declare(f.code.Names[code[pc]], f.peek(), code[pc+1] == 1)
+			pc += 2
+		case OpBind:
+			err = bind_element_with(f.code.Bindings[code[pc]].Element, f.peek(), f.binder(f.code.Bindings[code[pc]]))
+			pc++
+		case OpExport:
+
+			// Programs run directly have no one to export to, so a module can still be run on its own.
+			if f.fn.Globals.Exports != nil {
+				*f.fn.Globals.Exports = append(*f.fn.Globals.Exports, f.code.Names[code[pc]])
+			}
+			pc++
+		case OpArray:
+			f.push(MK_ARRAY(f.pop_n(int(code[pc]))))
+			pc++
+		case OpMap:
+
+			values := NewHashMap()
+			pairs := f.pop_n(2 * int(code[pc]))
+			for i := 0; i < len(pairs) && err == nil; i += 2 {
+				err = values.Set(pairs[i], pairs[i+1])
+			}
+			f.push(MK_MAP(values))
+			pc++
+		case OpObject:
+
+			object := ObjectVal{Type: "Object", Properties: map[string]RuntimeValue{}, Frozen: new(bool)}
+			pairs := f.pop_n(2 * int(code[pc]))
+			for i := 0; i < len(pairs); i += 2 {
+				object.Properties[pairs[i].(StringValue).Value] = pairs[i+1]
+			}
+			f.push(object)
+			pc++
+		case OpIndex:
+
+			datastructure := f.pop()
+			index := f.pop()
+
+			var value RuntimeValue
+			value, err = index_value(f.code.Names[code[pc]], datastructure, index)
+			f.push(value)
+			pc++
+		case OpSetIndex:
+
+			datastructure := f.pop()
+			index := f.pop()
+			value := f.pop()
+
+			value, err = assign_index(f.code.Names[code[pc]], datastructure, index, value)
+			f.push(value)
+			pc++
+		case OpSlice:
+
+			bounds := f.slice_bounds(int(code[pc+1]))
+			target := f.pop()
+
+			var value RuntimeValue
+			value, err = slice_value(target, bounds)
+			f.push(value)
+			pc += 2
+		case OpSetSlice:
+
+			bounds := f.slice_bounds(int(code[pc+1]))
+			target := f.pop()
+			value := f.pop()
+
+			value, err = assign_slice(f.code.Names[code[pc]], target, value, bounds)
+			f.push(value)
+			pc += 2
+		case OpBinary:
+
+			right := f.pop()
+			left := f.pop()
+
+			var value RuntimeValue
+			value, err = binary_operation(left, right, f.code.Names[code[pc]])
+			f.push(value)
+			pc++
+		case OpUnary:
+
+			var value RuntimeValue
+			value, err = unary_operation(f.pop(), f.code.Names[code[pc]])
+			f.push(value)
+			pc++
+		case OpCheckShorthand:
+
+			if !IsInteger(f.peek()) {
+				err = fmt.Errorf("invalid operator %v", f.code.Names[code[pc]])
+			}
+			pc++
+		case OpShorthand:
+
+			right := f.pop()
+			left := f.pop()
+
+			var value RuntimeValue
+			value, err = shorthand_operation(left, f.code.Names[code[pc]], right)
+			f.push(value)
+			pc++
+		case OpJump:
+			pc = int(code[pc])
+		case OpJumpUnlessIf, OpJumpUnlessWhile, OpJumpUnlessFor:
+
+			right := f.pop()
+			left := f.pop()
+			operator := f.code.Names[code[pc]]
+
+			holds := false
+			switch op {
+			case OpJumpUnlessIf:
+				holds, err = if_condition(left, right, operator)
+			case OpJumpUnlessWhile:
+				holds, err = while_condition(left, right, operator)
+			default:
+				holds = for_condition(left, right, operator)
+			}
+
+			if holds {
+				pc += 2
+			} else {
+				pc = int(code[pc+1])
+			}
+		case OpJumpUnlessBool:
+
+			var holds bool
+			holds, err = bool_condition(f.pop())
+
+			if holds {
+				pc++
+			} else {
+				pc = int(code[pc])
+			}
+		case OpJumpUnlessTernary:
+
+			cond := f.pop()
+			boolean, isBoolean := cond.(BooleanValue)
+
+			if !isBoolean {
+				err = fmt.Errorf("ternary expressions should evaluate to a boolean value, got %v", TypeName(cond))
+			} else if boolean.Value {
+				pc++
+			} else {
+				pc = int(code[pc])
+			}
+		case OpAppend:
+
+			value := f.pop()
+			arr := f.peek().(ArrayValue)
+			*arr.Value = append(*arr.Value, value)
+		case OpAppendSpread:
+
+			value := f.pop()
+			spread, isArr := value.(ArrayValue)
+			if !isArr {
+				err = fmt.Errorf("spread argument must be an array, got %v", TypeName(value))
+				break
+			}
+
+			arr := f.peek().(ArrayValue)
+			*arr.Value = append(*arr.Value, *spread.Value...)
+		case OpCall:
+
+			fn, args := f.call_args(int(code[pc]))
+
+			var value RuntimeValue
+			value, err = call_function(fn, args, f.code.Sites[code[pc+1]], f.env)
+			f.push(value)
+			pc += 2
+		case OpDefer:
+
+			fn, args := f.call_args(int(code[pc]))
+			f.deferred = append(f.deferred, DeferredCall{Fn: fn, Args: args, Site: f.code.Sites[code[pc+1]]})
+			pc += 2
+		case OpSpawn:
+
+			fn, args := f.call_args(int(code[pc]))
+
+			switch fn.(type) {
+			case NativeFunction, UserFunction, CompiledFunction:
+				spawn_task(fn, args, f.code.Sites[code[pc+1]], f.env)
+			default:
+				err = fmt.Errorf("spawn requires a function, %v given", TypeName(fn))
+			}
+			pc += 2
+		case OpMember:
+
+			var value RuntimeValue
+			value, err = f.member(f.pop(), f.code.Names[code[pc]], f.code.Names[code[pc+1]], f.code.Errors[code[pc+2]])
+			f.push(value)
+			pc += 3
+		case OpClosure:
+			f.push(f.closure(f.code.Functions[code[pc]]))
+			pc++
+		case OpBindParams:
+			err = bind_params_with(f.code.Name, f.code.Params, f.args, f.binder(f.code.Bindings[code[pc]]))
+			pc++
+		case OpReturn, OpEndThunk:
+			return f.pop(), nil
+		case OpUnwind:
+			return nil, returnSignal{Value: f.pop()}
+		case OpNamespace:
+
+			// Found relative to the global scope, but loaded by this task, for this run.
+			loader := f.fn.Globals
+			loader.Stack, loader.Modules = f.env.Stack, f.env.Modules
+
+			var namespace Namespace
+			namespace, err = loader.ResolveNamespace(f.code.Names[code[pc]])
+			f.push(namespace)
+			pc++
+		case OpAddNamespace:
+
+			namespace := f.pop().(Namespace)
+
+			alias := f.code.Names[code[pc]]
+			if alias == "" {
+				alias = namespace.Name
+			}
+
+			err = f.fn.Globals.AddNamespace(alias, namespace)
+			pc++
+		case OpImportLocal:
+			err = f.import_local(f.peek().(Namespace), f.code.Names[code[pc]], int(code[pc+1]))
+			pc += 2
+		case OpImportGlobal:
+
+			namespace := f.peek().(Namespace)
+			member := f.code.Names[code[pc]]
+
+			var value RuntimeValue
+			value, err = f.fn.Globals.LookupMember(namespace, member)
+			if err == nil {
+				err = f.fn.Globals.Import(member, value, namespace)
+			}
+			pc++
+		case OpSchedule:
+			f.env.Scheduler.schedule()
+		case OpResetScope:
+			f.reset_scope(int(code[pc]), int(code[pc+1]))
+			pc += 2
+		case OpIterStart:
+
+			var it IteratorValue
+			it, err = ToIterator(f.pop())
+			if err == nil {
+				f.iters = append(f.iters, it)
+			}
+		case OpIterNext:
+
+			item, ok, nextErr := f.iters[len(f.iters)-1].Next()
+
+			if nextErr != nil {
+				err = nextErr
+			} else if ok {
+				f.push(item)
+				pc++
+			} else {
+				pc = int(code[pc])
+			}
+		case OpIterEnd:
+
+			it := f.iters[len(f.iters)-1]
+			f.iters = f.iters[:len(f.iters)-1]
+			it.Close()
+		case OpFail:
+			err = f.code.Errors[code[pc]]
+			pc++
+		default:
+			err = fmt.Errorf("unrecognised opcode %v", op)
+		}
+
+		if err != nil {
+			return nil, locate_span(err, f.code.spanAt(start), f.env)
+		}
+	}
+}
+
+// Pushes a global, read the same way the tree-walker would.
+func (f *frame) get_global(name string, mode int) error {
+
+	var value RuntimeValue
+	var err error
+
+	if mode == modeLookup {
+		value, err = f.fn.Globals.Lookup(name)
+	} else {
+		value, err = identifier_value(name, f.fn.Globals)
+	}
+
+	f.push(value)
+
+	return err
+}
+
+// Assigns the top of the stack to a local, or to the global of the same name if the local
+// hasn't been declared yet.
+func (f *frame) set(l *local, name string) error {
+
+	if !l.declared {
+		_, err := f.fn.Globals.Assign(name, f.peek())
+		return err
+	}
+
+	if l.constant {
+		// Cannot assign to a constant.
+		return fmt.Errorf("cannot reassign const value '%v'", name)
+	}
+
+	l.value = f.peek()
+
+	return nil
+}
+
+func (f *frame) declare_local(slot int, value RuntimeValue, isConst bool) error {
+
+	l := &f.locals[slot]
+
+	if l.declared {
+		return fmt.Errorf("'%v' already defined", f.code.SlotNames[slot])
+	}
+
+	// Constant collections can't be modified either.
+	if isConst {
+		Freeze(value)
+	}
+
+	*l = local{value: value, declared: true, constant: isConst}
+
+	return nil
+}
+
+// Brings a single member of a namespace into a local, as a constant. Unlike a const
+// decleration, the value isn't frozen, as it still belongs to the namespace.
+func (f *frame) import_local(namespace Namespace, member string, slot int) error {
+
+	value, err := f.fn.Globals.LookupMember(namespace, member)
+	if err != nil {
+		return err
+	}
+
+	l := &f.locals[slot]
+	if l.declared {
+		return fmt.Errorf("import of '%v' from %v conflicts with existing variable '%v'", member, namespace.Name, member)
+	}
+
+	*l = local{value: value, declared: true, constant: true}
+
+	return nil
+}
+
+// Binds names into the slots or globals the compiler resolved them to, running the code
+// compiled for a default when one is needed.
+func (f *frame) binder(b binding) binder {
+
+	return binder{
+		declare: func(name string, value RuntimeValue) error {
+
+			t := b.Targets[name]
+			if t.kind == targetLocal {
+				return f.declare_local(t.index, value, b.Constant)
+			}
+
+			_, err := f.fn.Globals.Declare(name, value, b.Constant)
+			return err
+		},
+		evaluate: func(expr ast.Expression) (RuntimeValue, error) {
+
+			start, exists := b.Defaults[expr.Location()]
+			if !exists {
+				return nil, fmt.Errorf("unrecognised node in source: %v", ast.Describe(expr))
+			}
+
+			return f.run(start)
+		},
+	}
+}
+
+// Pops the bounds of a slice, returning how to work them out against what is sliced.
+func (f *frame) slice_bounds(flags int) func(length int) (int, int, error) {
+
+	var start, end RuntimeValue
+
+	if flags&sliceEnd != 0 {
+		end = f.pop()
+	}
+
+	if flags&sliceStart != 0 {
+		start = f.pop()
+	}
+
+	return func(length int) (int, int, error) {
+
+		from, to := 0, length
+		var err error
+
+		if start != nil {
+			if from, err = slice_index(start, length); err != nil {
+				return 0, 0, err
+			}
+		}
+
+		if end != nil {
+			if to, err = slice_index(end, length); err != nil {
+				return 0, 0, err
+			}
+		}
+
+		if err := check_slice_range(from, to, length); err != nil {
+			return 0, 0, err
+		}
+
+		return from, to, nil
+	}
+}
+
+// Pops the function being called and its args.
+func (f *frame) call_args(argc int) (RuntimeValue, []RuntimeValue) {
+
+	fn := f.pop()
+
+	// Spread args were collected into an array.
+	if argc == spreadArgs {
+		return fn, *f.pop().(ArrayValue).Value
+	}
+
+	return fn, f.pop_n(argc)
+}
+
+// Resolves a member call, i.e. strings.split, to the variable or namespace member it names.
+func (f *frame) member(value RuntimeValue, obj string, prop string, undefined error) (RuntimeValue, error) {
+
+	// Potentially a user func.
+	if value != nil {
+		return value, nil
+	}
+
+	// Perhaps its a stdlib func.
+	namespace, err := f.fn.Globals.LookupNamespace(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, err := f.fn.Globals.LookupMember(*namespace, prop)
+	if err != nil {
+		return nil, err
+	}
+
+	if fn == nil {
+		return nil, undefined
+	}
+
+	return fn, nil
+}
+
+// Makes a closure over the variables the function captures.
+func (f *frame) closure(code *Bytecode) CompiledFunction {
+
+	upvalues := make([]*upvalue, len(code.Upvalues))
+
+	for i, ref := range code.Upvalues {
+
+		if !ref.Local {
+			upvalues[i] = f.fn.Upvalues[ref.Index]
+			continue
+		}
+
+		upvalues[i] = f.capture(ref.Index)
+	}
+
+	return CompiledFunction{Type: CompiledFn, Code: code, Upvalues: upvalues, Globals: f.fn.Globals}
+}
+
+// Returns the upvalue pointing at a slot, closures made in the same scope share one.
+func (f *frame) capture(slot int) *upvalue {
+
+	for _, open := range f.open {
+		if open.slot == slot {
+			return open.upvalue
+		}
+	}
+
+	uv := &upvalue{local: &f.locals[slot]}
+	f.open = append(f.open, openUpvalue{slot: slot, upvalue: uv})
+
+	return uv
+}
+
+// Clears the slots of a scope about to be entered again. Closures over the previous entry
+// keep the values they captured.
+func (f *frame) reset_scope(base int, count int) {
+
+	open := f.open[:0]
+
+	for _, o := range f.open {
+
+		if o.slot < base || o.slot >= base+count {
+			open = append(open, o)
+			continue
+		}
+
+		closed := *o.upvalue.local
+		o.upvalue.local = &closed
+	}
+
+	f.open = open
+
+	for slot := base; slot < base+count; slot++ {
+		f.locals[slot] = local{}
+	}
+}
@@ -22,7 +22,7 @@ func TestSimpleArray(t *testing.T) {
 		io.println(arr[2]);`, "3\n", false},
 		{`using "io";
 		let arrr = [1, 2, 3, 4, 5];
-		io.println(arrr[6]);`, "interpreter error: index out of bounds for index 6", true},
+		io.println(arrr[6]);`, "interpreter error: io.println(arrr[6]);\n                   ~~~~~~~~~~~^~~~~~~~~~\nindex out of bounds for index 6 on line 3 col 11", true},
 	}
 
 	for _, tt := range tests {
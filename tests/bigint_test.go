@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"goblin.org/main/program"
+)
+
+func TestBigInts(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`using "io";
+		fn factorial(n) {
+			let result = 1;
+			for (let f = 1; f <= n; f++;) {
+				result *= f;
+			}
+			return result;
+		}
+		io.println(factorial(20));
+		io.println(factorial(25));
+		io.printf("%d", factorial(30));`, "2432902008176640000\n15511210043330985984000000\n265252859812191058636308480000000"},
+		{`using "io";
+		let maxInt = 9223372036854775807;
+		io.println(maxInt + 1);
+		io.println(maxInt + 1 - 1 == maxInt);
+		io.println(maxInt * maxInt);`, "9223372036854775808\ntrue\n85070591730234615847396907784232501249\n"},
+		{`using "io";
+		let minInt = -9223372036854775807 - 1;
+		io.println(minInt - 1);
+		io.println(minInt / -1);
+		io.println(-minInt);`, "-9223372036854775809\n9223372036854775808\n9223372036854775808\n"},
+		{`using "io";
+		let huge = 123456789012345678901234567890;
+		io.println(huge);
+		io.println(huge > 9223372036854775807);
+		io.println(huge ~/ -7);
+		io.println(huge % 7);`, "123456789012345678901234567890\ntrue\n-17636684144620811271604938270\n0\n"},
+		{`using "io";
+		io.println(2 ** 100);
+		io.println(1 << 70);
+		io.println((1 << 70) >> 70);`, "1267650600228229401496703205376\n1180591620717411303424\n1\n"},
+		{`using "io";
+		let counter = 2 ** 64;
+		counter++;
+		io.println(counter);
+		counter -= 2 ** 64;
+		io.println(counter);`, "18446744073709551617\n1\n"},
+		{`using "io";
+		let int typedBig = 2 ** 70;
+		io.println(typedBig);`, "1180591620717411303424\n"},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+			if err != nil {
+				t.Errorf(err.Error())
+			}
+
+			if output.String() != tt.want {
+				t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+			}
+
+			FlushBuffer()
+		})
+	}
+}
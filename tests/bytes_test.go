@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"goblin.org/main/program"
+)
+
+func TestBytes(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		let header = b"GOB\x00\xff\n";
+		io.println(header);
+		io.println(header[0]);
+		io.println(header[-2]);
+		io.println(header[0:3]);`, "b\"GOB\\x00\\xff\\n\"\n71\n255\nb\"GOB\"\n", false},
+		{`using "io";
+		using "data";
+		let sized = b"\x01\x02\x03";
+		io.println(data.size(sized));
+		for (octet in sized) {
+			io.print(octet);
+		}`, "3\n123", false},
+		{`using "io";
+		using "strings";
+		let utf = strings.encode("héllo", "utf-8");
+		io.println(utf);
+		io.println(strings.decode(utf, "utf-8"));`, "b\"h\\xc3\\xa9llo\"\nhéllo\n", false},
+		{`using "io";
+		using "strings";
+		let latin = strings.encode("héllo", "latin-1");
+		io.println(latin);
+		io.println(strings.decode(latin, "latin-1"));`, "b\"h\\xe9llo\"\nhéllo\n", false},
+		{`using "strings";
+		strings.encode("é", "ascii");`, "interpreter error: strings.encode(é, ascii);\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~~\ncannot encode 'é' as ascii on line 2 col 0", true},
+		{`using "strings";
+		strings.decode(b"ab\xff", "utf-8");`, "interpreter error: strings.decode(ab\\xff, utf-8);\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\ninvalid utf-8 byte 0xff at offset 2 on line 2 col 0", true},
+		{`using "strings";
+		strings.decode(b"a", "ebcdic");`, "interpreter error: strings.decode(a, ebcdic);\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~~~\nunknown encoding 'ebcdic', expected one of utf-8, ascii or latin-1 on line 2 col 0", true},
+		{`let frozen = b"abc";
+		frozen[0] = 1;`, "interpreter error: frozen[0] = 1;\n                   ^~~~~~~~~~~~~~~\ncannot assign to index of 'frozen', bytes are immutable on line 2 col 0", true},
+		{`let badEscape = b"\xzz";`, "parse error: let badEscape = \\xzz;\n             ~~~~~~~~~~~~~~~~~~~~^~\ninvalid escape sequence `\\xzz` in bytes literal on line 1 col 20", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestBinaryFiles(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	// Scratch file written to by the tests below.
+	t.Cleanup(func() {
+		os.Remove("../source/scratch.bin")
+	})
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		let out = io.open("scratch.bin", "w");
+		io.println(io.write(out, b"\x01\x02hello"));
+		io.close(out);
+		let input = io.open("scratch.bin", "r");
+		io.println(io.read(input, 2));
+		io.println(io.read(input));
+		io.println(io.read(input));
+		io.close(input);`, "7\nb\"\\x01\\x02\"\nb\"hello\"\nb\"\"\n", false},
+		{`using "io";
+		let appended = io.open("scratch.bin", "a");
+		io.write(appended, b"!");
+		io.close(appended);
+		let whole = io.open("scratch.bin", "r");
+		io.println(io.read(whole));`, "b\"\\x01\\x02hello!\"\n", false},
+
+		// Writing over a longer file replaces it, rather than just its start.
+		{`using "io";
+		let longer = io.open("scratch.bin", "w");
+		io.write(longer, b"HELLO WORLD");
+		io.close(longer);
+		let shorter = io.open("scratch.bin", "w");
+		io.write(shorter, b"hi");
+		io.close(shorter);
+		let rewritten = io.open("scratch.bin", "r");
+		io.println(io.read(rewritten));`, "b\"hi\"\n", false},
+		{`using "io";
+		let readOnly = io.open("test.txt", "r");
+		io.write(readOnly, b"x");`, "interpreter error: io.write(readOnly, x);\n                   ^~~~~~~~~~~~~~~~~~~~~~~\nfile: ../source/test.txt not opened in a valid write-mode on line 3 col 0", true},
+		{`using "io";
+		let notBytes = io.open("scratch.bin", "w");
+		io.write(notBytes, "x");`, "interpreter error: io.write(notBytes, x);\n                   ^~~~~~~~~~~~~~~~~~~~~~~\nio.write expectes arg2 to be of type bytes, string given on line 3 col 0", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
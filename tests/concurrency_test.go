@@ -0,0 +1,266 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"goblin.org/main/program"
+)
+
+// These programs only ever produce one output, however their tasks are scheduled. Run
+// with -race to check the interpreter itself is safe to use from several tasks.
+func TestSpawn(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		using "sync";
+		let total = 0;
+		let guard = sync.mutex();
+		let group = sync.waitgroup();
+		fn work(int n) {
+			let i = 0;
+			while (i < 100) {
+				sync.lock(guard);
+				total += n;
+				sync.unlock(guard);
+				i += 1;
+			}
+			sync.done(group);
+		}
+		sync.add(group, 4);
+		for (let k = 1; k < 5; k++;) {
+			spawn work(k);
+		}
+		sync.wait(group);
+		io.println(total);`, "1000\n", false},
+		{`using "io";
+		using "data";
+		using "sync";
+		let shared = [];
+		let finished = sync.waitgroup();
+		fn fill() {
+			for (let f = 0; f < 50; f++;) {
+				data.push(shared, f);
+			}
+			sync.done(finished);
+		}
+		sync.add(finished, 2);
+		spawn fill();
+		spawn fill();
+		sync.wait(finished);
+		io.println(data.size(shared));`, "100\n", false},
+		{`using "io";
+		let signal = chan(1);
+		fn broken() {
+			let arr = [1];
+			let missing = arr[4];
+		}
+		fn brokenThenSignal() {
+			defer send(signal, "after");
+			broken();
+		}
+		spawn brokenThenSignal();
+		io.println(receive(signal));`, "task error: let missing = arr[4];\n            ~~~~~~~~~~~~~~^~~~~~~~\nindex out of bounds for index 4 on line 5 col 14\nstack trace:\n    broken, called on line 9 col 0: broken();\n    brokenThenSignal, called on line 11 col 6: spawn brokenThenSignal();\nafter\n", false},
+		{`let notfn = 5;
+		spawn notfn();`, "interpreter error: spawn notfn();\n                   ^~~~~~~~~~~~~~~\nspawn requires a function, int given on line 2 col 0", true},
+		{`spawn 5;`, "parse error: spawn 5;\n             ~~~~~~~^~\nspawn requires a function call on line 1 col 7", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err == nil || err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err)
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestChannels(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		let squares = chan();
+		fn produce(int count) {
+			for (let p = 0; p < count; p++;) {
+				send(squares, p * p);
+			}
+			close(squares);
+		}
+		spawn produce(4);
+		for (sq in squares) {
+			io.println(sq);
+		}`, "0\n1\n4\n9\n", false},
+		{`using "io";
+		let fast = chan(1);
+		let slow = chan(1);
+		send(slow, "s");
+		io.println(select([fast, slow]));
+		io.println(select([fast, slow], false));`, "[1, s]\n[-1, null]\n", false},
+		{`using "io";
+		let finished = chan();
+		close(finished);
+		io.println(receive(finished));`, "null\n", false},
+		{`let twice = chan();
+		close(twice);
+		close(twice);`, "interpreter error: close(twice);\n                   ^~~~~~~~~~~~~~\nclose of closed channel on line 3 col 0", true},
+		{`let shut = chan(1);
+		close(shut);
+		send(shut, 1);`, "interpreter error: send(shut, 1);\n                   ^~~~~~~~~~~~~~~\nsend on closed channel on line 3 col 0", true},
+		{`using "sync";
+		let lk = sync.mutex();
+		sync.unlock(lk);`, "interpreter error: sync.unlock(lk);\n                   ^~~~~~~~~~~~~~~~~\nsync.unlock of unlocked mutex on line 3 col 0", true},
+		{`using "sync";
+		let wg = sync.waitgroup();
+		sync.done(wg);`, "interpreter error: sync.done(wg);\n                   ^~~~~~~~~~~~~~~\nsync.done called more times than sync.add on line 3 col 0", true},
+
+		// Once every task is waiting, none of them can ever be woken.
+		{`receive(chan());`, "interpreter error: receive(chan());\n                   ^~~~~~~~~~~~~~~~~\ndeadlock: every task is waiting on line 1 col 0", true},
+		{`using "sync";
+		let held = sync.mutex();
+		sync.lock(held);
+		sync.lock(held);`, "interpreter error: sync.lock(held);\n                   ^~~~~~~~~~~~~~~~~\ndeadlock: every task is waiting on line 4 col 0", true},
+		{`using "sync";
+		let never = chan();
+		let stuck = sync.waitgroup();
+		fn listen() {
+			receive(never);
+			sync.done(stuck);
+		}
+		sync.add(stuck, 2);
+		spawn listen();
+		spawn listen();
+		sync.wait(stuck);`, "interpreter error: sync.wait(stuck);\n                   ^~~~~~~~~~~~~~~~~~\ndeadlock: every task is waiting on line 11 col 0", true},
+		{`select([]);`, "interpreter error: select([]);\n                   ^~~~~~~~~~~~\ndeadlock: every task is waiting on line 1 col 0", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err == nil || err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err)
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+// Collects what a program writes, so it can be checked while the program is still running.
+type lockedWriter struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buffer.Write(p)
+}
+
+func (w *lockedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buffer.String()
+}
+
+// Other tasks keep running while one waits on stdin.
+func TestBlockingInput(t *testing.T) {
+
+	// Setup the program env, reading from a pipe this test writes to.
+	HarnessSetup()
+
+	stdin, typed := io.Pipe()
+	stdout := &lockedWriter{}
+
+	env.Stdin = stdin
+	env.Stdout = stdout
+
+	source := `using "io";
+	fn greet() {
+		io.println("task ran");
+	}
+	spawn greet();
+	io.println(io.input("name? "));`
+
+	finished := make(chan error)
+	go func() {
+		_, err := program.Run(source, env)
+		finished <- err
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); !strings.Contains(stdout.String(), "task ran"); {
+		if time.Now().After(deadline) {
+			t.Fatalf("task didn't run while waiting on stdin, received `%v`", stdout.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	typed.Write([]byte("goblin\n"))
+
+	if err := <-finished; err != nil {
+		t.Errorf(err.Error())
+	}
+
+	want := "name? task ran\ngoblin\n"
+	if stdout.String() != want {
+		t.Errorf("expected `%v`, received `%v`", want, stdout.String())
+	}
+}
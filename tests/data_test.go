@@ -36,12 +36,12 @@ func TestPush(t *testing.T) {
 		using "io";
 		let aa = [];
 		data.push(aa, 5, 2);
-		io.print(aa);`, "interpreter error: unexpected number of args for data.push, expected 2 got 3", true},
+		io.print(aa);`, "interpreter error: data.push(aa, 5, 2);\n                   ^~~~~~~~~~~~~~~~~~~~~\nunexpected number of args for data.push, expected 2 got 3 on line 4 col 0", true},
 		{`using "data";
 		using "io";
 		let aaa = [];
 		data.push(aaa, 5, 2, 3);
-		io.print(aaa);`, "interpreter error: unexpected number of args for data.push, expected 2 got 4", true},
+		io.print(aaa);`, "interpreter error: data.push(aaa, 5, 2, 3);\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~\nunexpected number of args for data.push, expected 2 got 4 on line 4 col 0", true},
 	}
 
 	for _, tt := range tests {
@@ -108,14 +108,14 @@ func TestPut(t *testing.T) {
 			"one": 1,
 		};
 		data.put(mm, "two");
-		io.print(mm);`, "interpreter error: unexpected number of args for data.put, expected 3 got 2", true},
+		io.print(mm);`, "interpreter error: data.put(mm, two);\n                   ^~~~~~~~~~~~~~~~~~~\nunexpected number of args for data.put, expected 3 got 2 on line 6 col 0", true},
 		{`using "data";
 		using "io";
 		let mmm = {
 			"one": 1,
 		};
 		data.put(mmm, "two", 1, 2);
-		io.print(mmm);`, "interpreter error: unexpected number of args for data.put, expected 3 got 4", true},
+		io.print(mmm);`, "interpreter error: data.put(mmm, two, 1, 2);\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~~\nunexpected number of args for data.put, expected 3 got 4 on line 6 col 0", true},
 	}
 
 	for _, tt := range tests {
@@ -173,7 +173,7 @@ func TestPop(t *testing.T) {
 		let lastt = data.pop(arrr);
 		
 		io.println(lastt);
-		io.println(arrr);`, "interpreter error: cannot pop an empty array", true},
+		io.println(arrr);`, "interpreter error: let lastt = data.pop(arrr);\n                   ~~~~~~~~~~~~^~~~~~~~~~~~~~~~\ncannot pop an empty array on line 5 col 12", true},
 		{`using "data";
 		using "io";
 
@@ -181,7 +181,7 @@ func TestPop(t *testing.T) {
 		let lasttt = data.pop(arrrr, 1);
 		
 		io.println(lasttt);
-		io.println(arrrr);`, "interpreter error: unexpected number of args for data.pop, expected 1 got 2", true},
+		io.println(arrrr);`, "interpreter error: let lasttt = data.pop(arrrr, 1);\n                   ~~~~~~~~~~~~~^~~~~~~~~~~~~~~~~~~~\nunexpected number of args for data.pop, expected 1 got 2 on line 5 col 13", true},
 		{`using "data";
 		using "io";
 
@@ -189,7 +189,7 @@ func TestPop(t *testing.T) {
 		let lastttt = data.pop();
 		
 		io.println(lastttt);
-		io.println(arrrrr);`, "interpreter error: unexpected number of args for data.pop, expected 1 got 0", true},
+		io.println(arrrrr);`, "interpreter error: let lastttt = data.pop();\n                   ~~~~~~~~~~~~~~^~~~~~~~~~~~\nunexpected number of args for data.pop, expected 1 got 0 on line 5 col 14", true},
 	}
 
 	for _, tt := range tests {
@@ -263,12 +263,12 @@ func TestSize(t *testing.T) {
 		using "io";
 		let aa = [1, 2, 3, 4, 5];
 		let sArr = data.size(aa, 1);
-		io.print(sArr);`, "interpreter error: unexpected number of args for data.size, expected 1 got 2", true},
+		io.print(sArr);`, "interpreter error: let sArr = data.size(aa, 1);\n                   ~~~~~~~~~~~^~~~~~~~~~~~~~~~~~\nunexpected number of args for data.size, expected 1 got 2 on line 4 col 11", true},
 		{`using "data";
 		using "io";
 		let aaaarr = [];
 		let sArr = data.size();
-		io.print(sArr);`, "interpreter error: unexpected number of args for data.size, expected 1 got 0", true},
+		io.print(sArr);`, "interpreter error: let sArr = data.size();\n                   ~~~~~~~~~~~^~~~~~~~~~~~~\nunexpected number of args for data.size, expected 1 got 0 on line 4 col 11", true},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"goblin.org/main/program"
+)
+
+func TestDefer(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		fn deferOrder() {
+			defer io.println("first");
+			defer io.println("second");
+			io.println("body");
+		}
+		deferOrder();`, "body\nsecond\nfirst\n", false},
+		{`using "io";
+		fn deferArgs() {
+			let x = 1;
+			defer io.println(x);
+			x = 2;
+			io.println(x);
+		}
+		deferArgs();`, "2\n1\n", false},
+		{`using "io";
+		fn deferLoop() {
+			for(let i = 0; i < 3; i++;){
+				defer io.println(i);
+			}
+			io.println("loop done");
+		}
+		deferLoop();`, "loop done\n2\n1\n0\n", false},
+		{`using "io";
+		fn deferReturn() {
+			defer io.println("deferred");
+			return 10;
+			io.println("unreachable");
+		}
+		let r = deferReturn();
+		io.println(r);`, "deferred\n10\n", false},
+		{`using "io";
+		fn deferUserFn(msg) {
+			io.println(msg);
+		}
+		fn deferCaller() {
+			defer deferUserFn("cleanup");
+			io.println("working");
+		}
+		deferCaller();`, "working\ncleanup\n", false},
+		{`using "io";
+		defer io.println("top level");`, "interpreter error: defer io.println(top level);\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~~~~~\ndefer can only be used inside a function on line 2 col 0", true},
+		{`using "io";
+		fn deferNonCall() {
+			defer 10;
+		}`, "parse error: defer 10;\n             ~~~~~~~~^~\ndefer requires a function call on line 3 col 8", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestDeferOnError(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source string
+		want   string
+		err    string
+	}{
+		{`using "io";
+		fn deferOnError() {
+			defer io.println("cleanup");
+			let arr = [];
+			io.println(arr[3]);
+		}
+		deferOnError();`, "cleanup\n", "interpreter error: io.println(arr[3]);\n                   ~~~~~~~~~~~^~~~~~~~~\nindex out of bounds for index 3 on line 5 col 11"},
+		{`using "io";
+		let f = io.open("test.txt", "r");
+		fn deferClose(file) {
+			defer io.close(file);
+			let broken = [];
+			io.println(broken[1]);
+		}
+		deferClose(f);`, "", "interpreter error: io.println(broken[1]);\n                   ~~~~~~~~~~~^~~~~~~~~~~~\nindex out of bounds for index 1 on line 6 col 11"},
+		{`using "io";
+		let fc = io.open("test.txt", "r");
+		fn deferClosed(file) {
+			defer io.close(file);
+		}
+		deferClosed(fc);
+		io.readline(fc, 1);`, "", "interpreter error: io.readline(fc, 1);\n                   ^~~~~~~~~~~~~~~~~~~~\nread ../source/test.txt: file already closed on line 7 col 0"},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("expected `%v`, received `%v`", tt.err, err)
+			}
+
+			// Deferred calls must have run before the error propagated.
+			if output.String() != tt.want {
+				t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+			}
+
+			FlushBuffer()
+		})
+	}
+}
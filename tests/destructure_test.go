@@ -0,0 +1,230 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"goblin.org/main/program"
+)
+
+func TestDestructuring(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		using "strings";
+		let parts = strings.split("localhost:8080:extra", ":");
+		let [host, port, ...others] = parts;
+		io.println(host);
+		io.println(port);
+		io.println(others);`, "localhost\n8080\n[extra]\n", false},
+		{`using "io";
+		let pair = [1];
+		let [first, second = 0] = pair;
+		io.println(first + second);`, "1\n", false},
+		{`using "io";
+		using "data";
+		let inner = [1, 2];
+		let nested = [0, 3];
+		data.push(nested, inner);
+		let [zero, three, [one, two]] = nested;
+		io.println(one + two + three);`, "6\n", false},
+		{`using "io";
+		let cfg;
+		cfg = {address: "example.com", number: 80};
+		let {address, number} = cfg;
+		io.println(address);
+		io.println(number);`, "example.com\n80\n", false},
+		{`using "io";
+		let settings = {
+			"name": "goblin",
+			"debug": true,
+		};
+		const {name, level = 3, ...remaining} = settings;
+		io.println(name);
+		io.println(level);
+		io.println(remaining);`, "goblin\n3\n{debug : true}\n", false},
+		{`using "io";
+		let short = [1];
+		let [sa, sb] = short;`, "interpreter error: let [sa, sb] = short;\n                   ^~~~~~~~~~~~~~~~~~~~~~\nmissing value for 'sb' in array pattern [sa, sb], array has 1 elements on line 3 col 0", true},
+		{`using "io";
+		let long = [1, 2, 3];
+		let [la, lb] = long;`, "interpreter error: let [la, lb] = long;\n                   ^~~~~~~~~~~~~~~~~~~~~\narray pattern [la, lb] expects 2 elements, array has 3 on line 3 col 0", true},
+		{`using "io";
+		let notArr = 5;
+		let [na] = notArr;`, "interpreter error: let [na] = notArr;\n                   ^~~~~~~~~~~~~~~~~~~\ncannot destructure int with array pattern [na] on line 3 col 0", true},
+		{`using "io";
+		let missing = {
+			"a": 1,
+		};
+		let {b} = missing;`, "interpreter error: let {b} = missing;\n                   ^~~~~~~~~~~~~~~~~~~\nkey `b` does not exist for map pattern {b} on line 5 col 0", true},
+		{`let [ra, ...rest, rb] = long;`, "parse error: let [ra, ...rest, rb] = long;\n             ~~~~~~~~~~~~~~~~^~~~~~~~~~~~~~\nrest element 'rest' must be the last element on line 1 col 16", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestDestructuredParams(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		fn describe({host, port = 80}) {
+			io.printf("%v:%v", host, port);
+		}
+		let conn;
+		conn = {host: "h"};
+		describe(conn);`, "h:80", false},
+		{`using "io";
+		fn sumPair([a, b]) {
+			return a + b;
+		}
+		let nums = [3, 4];
+		io.println(sumPair(nums));`, "7\n", false},
+		{`using "io";
+		fn firstOf([head, ...tail]) {
+			return head;
+		}
+		io.println(firstOf(5));`, "interpreter error: io.println(firstOf(5));\n                   ~~~~~~~~~~~^~~~~~~~~~~~~\ncannot destructure int with array pattern [head, ...tail] on line 5 col 11", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestForIn(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		let words = ["foo", "bar"];
+		for (word in words) {
+			io.println(word);
+		}`, "foo\nbar\n", false},
+		{`using "io";
+		for (ch in "abc") {
+			io.print(ch);
+		}`, "abc", false},
+		{`using "io";
+		let single = {
+			"key": 1,
+		};
+		for (k in single) {
+			io.println(k);
+		}`, "key\n", false},
+		{`using "io";
+		using "data";
+		let pairs = [];
+		let one = [1, 2];
+		let two = [3, 4];
+		data.push(pairs, one);
+		data.push(pairs, two);
+		for ([l, r] in pairs) {
+			io.println(l * r);
+		}`, "2\n12\n", false},
+		{`using "io";
+		for (x in 5) {
+			io.println(x);
+		}`, "interpreter error: for (x in 5) {\n                   ^~~~~~~~~~~~~~~\ncannot iterate over int on line 2 col 0", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"goblin.org/main/frontend/lexer"
+	goblinParser "goblin.org/main/frontend/parser"
+	"goblin.org/main/frontend/resolver"
+	"goblin.org/main/program"
+	"goblin.org/main/runtime"
+)
+
+// A program taken from the table of another test.
+type tableProgram struct {
+	name   string
+	source string
+}
+
+// Returns the source of every program in the test tables of this package, the first string
+// of each row.
+func tablePrograms(t testing.TB) []tableProgram {
+
+	files, err := filepath.Glob("*_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	programs := make([]tableProgram, 0)
+	fset := token.NewFileSet()
+
+	for _, file := range files {
+
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+
+			row, isRow := n.(*ast.CompositeLit)
+			if !isRow || len(row.Elts) == 0 {
+				return true
+			}
+
+			first := row.Elts[0]
+			if kv, isKeyValue := first.(*ast.KeyValueExpr); isKeyValue {
+				first = kv.Value
+			}
+
+			lit, isString := first.(*ast.BasicLit)
+			if !isString || lit.Kind != token.STRING {
+				return true
+			}
+
+			source, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return true
+			}
+
+			name := fmt.Sprintf("%v:%v", file, fset.Position(lit.Pos()).Line)
+			programs = append(programs, tableProgram{name: name, source: source})
+
+			return true
+		})
+	}
+
+	return programs
+}
+
+// Runs source in an environment of its own, returning everything it printed followed by
+// any error it raised and the stack trace of that error. Each run gets a fresh copy of the
+// source folder, so files written and modules loaded by one run aren't seen by the next.
+func runOn(t testing.TB, source string, options program.Options) string {
+
+	entry := copySourceFolder(t)
+
+	return strings.ReplaceAll(runIn(entry, source, options), entry, "../source")
+}
+
+func runIn(entry string, source string, options program.Options) string {
+
+	var out bytes.Buffer
+
+	env := runtime.Environment{
+		Stdout:        &out,
+		Stdin:         os.Stdin,
+		Variables:     map[string]runtime.RuntimeValue{},
+		Constants:     map[string]bool{},
+		Namespaces:    map[string]runtime.Namespace{},
+		EntryLocation: entry,
+	}
+
+	env.Setup()
+
+	_, err := program.RunWith(source, env, options)
+	if err != nil {
+		fmt.Fprintf(&out, "error: %v\n%v", err, runtime.FormatStackTrace(runtime.StackTrace(err)))
+	}
+
+	return out.String()
+}
+
+func copySourceFolder(t testing.TB) string {
+
+	dir := t.TempDir()
+
+	err := filepath.WalkDir("../source", func(path string, d fs.DirEntry, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel("../source", path)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dir, rel), 0o755)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(filepath.Join(dir, rel), content, 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+// Whether the bytecode compiler handles source, rather than leaving it to the tree-walker.
+func compiles(source string) bool {
+
+	tokens, audit, err := lexer.Tokenize(source)
+	if err != nil {
+		return false
+	}
+
+	prog, err := goblinParser.ProduceAST(tokens, audit)
+	if err != nil {
+		return false
+	}
+
+	env := runtime.Environment{Variables: map[string]runtime.RuntimeValue{}, Constants: map[string]bool{}}
+	env.Setup()
+
+	res, errs := resolver.Resolve(prog, audit, resolver.Globals{Variables: env.Constants})
+	if len(errs) != 0 {
+		return false
+	}
+
+	_, err = runtime.CompileBytecode(prog, audit, res)
+
+	return err == nil
+}
+
+// Every program in the test tables must print the same and fail the same on both engines,
+// with or without the optimiser, the unoptimised tree-walker being the reference.
+func TestEnginesAgree(t *testing.T) {
+
+	programs := tablePrograms(t)
+	compiled := 0
+
+	for _, p := range programs {
+
+		if compiles(p.source) {
+			compiled++
+		}
+
+		t.Run(p.name, func(t *testing.T) {
+
+			want := runOn(t, p.source, program.Options{Engine: program.TreeWalker})
+
+			for _, options := range []program.Options{
+				{Engine: program.Bytecode},
+				{Engine: program.TreeWalker, Optimise: true},
+				{Engine: program.Bytecode, Optimise: true},
+			} {
+				if got := runOn(t, p.source, options); got != want {
+					t.Errorf("%v\nreference: %q\n%+v: %q", p.source, want, options, got)
+				}
+			}
+		})
+	}
+
+	t.Logf("%v of %v programs compiled to bytecode", compiled, len(programs))
+}
+
+// Programs the bytecode compiler can't handle still run, but say that they fell back.
+func TestFallbackWarning(t *testing.T) {
+
+	var tests = []struct {
+		source  string
+		want    string
+		warning string
+	}{
+		{`using "io";
+		io.println(1);`, "1\n", ""},
+		{`using "io";
+		fn counting() {
+			yield 1;
+		}
+		for (n in counting()) {
+			io.println(n);
+		}`, "1\n", "warning: bytecode compiler does not support generators yet, on line 2 col 0, running on the tree-walker instead\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+
+			var warnings bytes.Buffer
+
+			got := runOn(t, tt.source, program.Options{Engine: program.Bytecode, Warnings: &warnings})
+			if got != tt.want {
+				t.Errorf("expected `%v`, received `%v`", tt.want, got)
+			}
+
+			if warnings.String() != tt.warning {
+				t.Errorf("expected warning `%v`, received `%v`", tt.warning, warnings.String())
+			}
+		})
+	}
+}
+
+const fibProgram = `
+fn fib(n) {
+	if (n < 2) {
+		return n;
+	}
+	return fib(n - 1) + fib(n - 2);
+}
+fib(20);`
+
+const loopProgram = `
+let total = 0;
+for (let i = 0; i < 100000; i++;) {
+	total += i % 7;
+}`
+
+const closureProgram = `
+fn counter() {
+	let count = 0;
+	fn next() {
+		count++;
+		return count;
+	}
+	return next;
+}
+let tick = counter();
+let arr = [];
+for (let i = 0; i < 20000; i++;) {
+	arr = [tick()];
+}`
+
+func benchmarkEngine(b *testing.B, source string, engine program.Engine) {
+
+	// A program that falls back would measure the tree-walker twice.
+	var warnings bytes.Buffer
+
+	for i := 0; i < b.N; i++ {
+		if out := runIn("../source", source, program.Options{Engine: engine, Warnings: &warnings}); out != "" || warnings.Len() > 0 {
+			b.Fatal(out + warnings.String())
+		}
+	}
+}
+
+func BenchmarkFibTreeWalker(b *testing.B)     { benchmarkEngine(b, fibProgram, program.TreeWalker) }
+func BenchmarkFibBytecode(b *testing.B)       { benchmarkEngine(b, fibProgram, program.Bytecode) }
+func BenchmarkLoopTreeWalker(b *testing.B)    { benchmarkEngine(b, loopProgram, program.TreeWalker) }
+func BenchmarkLoopBytecode(b *testing.B)      { benchmarkEngine(b, loopProgram, program.Bytecode) }
+func BenchmarkClosureTreeWalker(b *testing.B) { benchmarkEngine(b, closureProgram, program.TreeWalker) }
+func BenchmarkClosureBytecode(b *testing.B)   { benchmarkEngine(b, closureProgram, program.Bytecode) }
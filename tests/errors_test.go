@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"goblin.org/main/program"
+	"goblin.org/main/runtime"
+)
+
+func TestRuntimeErrorLocations(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		let first = 1;
+		let second = [1, 2];
+		io.println(second[first + 5]);`, "interpreter error: io.println(second[first + 5]);\n                   ~~~~~~~~~~~^~~~~~~~~~~~~~~~~~~~\nindex out of bounds for index 6 on line 4 col 11", true},
+		{`let bad = 1 + undefinedVar;`, "resolve error: let bad = 1 + undefinedVar;\n               ~~~~~~~~~~~~~~^~~~~~~~~~~~~~\nreference to undefined variable 'undefinedVar' on line 1 col 14", true},
+		{`using "io";
+		io.println(ünï + 1);`, "resolve error: io.println(ünï + 1);\n               ~~~~~~~~~~~^~~~~~~~~~\nreference to undefined variable 'ünï' on line 2 col 11", true},
+
+		// Blank lines still count towards the line number.
+		{`let spaced = 1;
+
+
+		let afterBlank = spaced / 0;`, "interpreter error: let afterBlank = spaced / 0;\n                   ~~~~~~~~~~~~~~~~~^~~~~~~~~~~~\ndivision by zero on line 4 col 17", true},
+
+		// Values are named by their type, not printed as they are held.
+		{`let picked = 1 ? 2 : 3;`, "interpreter error: let picked = 1 ? 2 : 3;\n                   ~~~~~~~~~~~~~^~~~~~~~~~~\nternary expressions should evaluate to a boolean value, got int on line 1 col 13", true},
+
+		// Errors inside a function point at the body, not the call.
+		{`fn halve(n) {
+			return n / 0;
+		}
+		let halved = halve(4);`, "interpreter error: return n / 0;\n                   ~~~~~~~^~~~~~~\ndivision by zero on line 2 col 7", true},
+
+		// Errors inside a module point at the module's source.
+		{`using "./lib/broken.gob";`, "interpreter error: export let ratio = 10 / 0;\n                   ~~~~~~~~~~~~~~~~~~~^~~~~~~~\nin module ./lib/broken.gob: division by zero on line 2 col 19", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err == nil {
+					t.Fatalf("expected `%v`, received no error", tt.want)
+				}
+
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestStackTraces(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	overflow := strings.Repeat("    recurse, called on line 2 col 7: return recurse(n + 1);\n", 20)
+
+	var tests = []struct {
+		source string
+		want   string
+		trace  string
+	}{
+		{`fn inner(x) {
+			return x / 0;
+		}
+		fn outer(y) {
+			return inner(y) + 1;
+		}
+		let traced = outer(3);`, "interpreter error: return x / 0;\n                   ~~~~~~~^~~~~~~\ndivision by zero on line 2 col 7", "stack trace:\n    inner, called on line 5 col 7: return inner(y) + 1;\n    outer, called on line 7 col 13: let traced = outer(3);\n"},
+
+		// Calls made by natives have no call site of their own.
+		{`using "iter";
+		fn twice(v) {
+			return v / 0;
+		}
+		let twiced = iter.collect(iter.map([1], twice));`, "interpreter error: return v / 0;\n                   ~~~~~~~^~~~~~~\ndivision by zero on line 3 col 7", "stack trace:\n    twice, called by a native function\n"},
+
+		// Errors outside of any function have no trace.
+		{`let untraced = 1 / 0;`, "interpreter error: let untraced = 1 / 0;\n                   ~~~~~~~~~~~~~~~^~~~~~~\ndivision by zero on line 1 col 15", ""},
+
+		// Runaway recursion is stopped before the Go stack runs out.
+		{`fn recurse(n) {
+			return recurse(n + 1);
+		}
+		recurse(0);`, "interpreter error: return recurse(n + 1);\n                   ~~~~~~~^~~~~~~~~~~~~~~~\nstack overflow: more than 10000 nested calls on line 2 col 7", "stack trace:\n" + overflow + "    ... 9980 more\n"},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+			if err == nil {
+				t.Fatalf("expected `%v`, received no error", tt.want)
+			}
+
+			if err.Error() != tt.want {
+				t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+			}
+
+			trace := runtime.FormatStackTrace(runtime.StackTrace(err))
+			if trace != tt.trace {
+				t.Errorf("expected trace `%v`, received `%v`", tt.trace, trace)
+			}
+
+			FlushBuffer()
+		})
+	}
+}
@@ -33,7 +33,69 @@ func TestFunctions(t *testing.T) {
 			let x = a + b;
 			io.println(x);
 		}
-		Adder(1, 3);`, "interpreter error: incorrect number of params specified for fn Adder, got 2 want 3", true},
+		Adder(1, 3);`, "interpreter error: Adder(1, 3);\n                   ^~~~~~~~~~~~~\nmissing param 'c' for fn Adder, got 2 want 3 on line 6 col 0", true},
+		{`using "io";
+		fn Pair(a, b){
+			io.println(a + b);
+		}
+		Pair(1, 2, 3);`, "interpreter error: Pair(1, 2, 3);\n                   ^~~~~~~~~~~~~~~\nincorrect number of params specified for fn Pair, got 3 want 2 on line 5 col 0", true},
+		{`using "io";
+		fn greet(name, greeting = "hi"){
+			io.println(io.sprintf("%v %v", greeting, name));
+		}
+		greet("bob");
+		greet("bob", "hello");`, "hi bob\nhello bob\n", false},
+		{`using "io";
+		fn offset(a, b = a + 1){
+			io.println(b);
+		}
+		offset(4);`, "5\n", false},
+		{`using "io";
+		fn sum(...nums){
+			let total = 0;
+			let i = 0;
+			let count = data.size(nums);
+			while (i < count) {
+				total += nums[i];
+				i++;
+			}
+			return total;
+		}
+		using "data";
+		io.println(sum());
+		io.println(sum(1, 2, 3));`, "0\n6\n", false},
+		{`using "io";
+		fn tail(head, ...others){
+			io.println(others);
+		}
+		tail(1, 2, 3);
+		tail(1);`, "[2, 3]\n[]\n", false},
+		{`using "io";
+		fn triple(a, b, c){
+			io.println(a + b + c);
+		}
+		let spreadArgs = [1, 2, 3];
+		triple(...spreadArgs);
+		let partial = [2, 3];
+		triple(1, ...partial);`, "6\n6\n", false},
+		{`using "io";
+		let fmtArgs = ["a", 1];
+		io.printf("%v-%v", ...fmtArgs);`, "a-1", false},
+		{`using "io";
+		fn needsTwo(a, b){
+			io.println(a);
+		}
+		let short = [1];
+		needsTwo(...short);`, "interpreter error: needsTwo(...short);\n                   ^~~~~~~~~~~~~~~~~~~~\nmissing param 'b' for fn needsTwo, got 1 want 2 on line 6 col 0", true},
+		{`using "io";
+		fn spreadNonArray(a){
+			io.println(a);
+		}
+		spreadNonArray(...10);`, "interpreter error: spreadNonArray(...10);\n                   ^~~~~~~~~~~~~~~~~~~~~~~\nspread argument must be an array, got int on line 5 col 0", true},
+		{`fn badRest(...rest, a){
+		}`, "parse error: fn badRest(...rest, a){\n             ~~~~~~~~~~~~~~~~~~^~~~~~\nrest param 'rest' must be the last param on line 1 col 18", true},
+		{`fn badDefault(a = 1, b){
+		}`, "parse error: fn badDefault(a = 1, b){\n             ~~~~~~~~~~~~~~~~~~~~~~^~~\nparam 'b' without a default value cannot follow one with a default on line 1 col 22", true},
 	}
 
 	for _, tt := range tests {
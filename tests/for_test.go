@@ -53,7 +53,7 @@ func TestForLoop(t *testing.T) {
 		let smallArray = [1, 2];
 		for (let i = 2; i < 3; i++;){
 			io.println(smallArray[i]);
-		}`, "interpreter error: index out of bounds for index 2", true},
+		}`, "interpreter error: io.println(smallArray[i]);\n                   ~~~~~~~~~~~^~~~~~~~~~~~~~~~\nindex out of bounds for index 2 on line 4 col 11", true},
 	}
 
 	for _, tt := range tests {
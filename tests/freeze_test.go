@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"goblin.org/main/program"
+)
+
+func TestFreeze(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "data";
+		const nums = [1, 2];
+		data.push(nums, 3);`, "interpreter error: data.push(nums, 3);\n                   ^~~~~~~~~~~~~~~~~~~~\ndata.push cannot modify a frozen array on line 3 col 0", true},
+		{`using "data";
+		const table = {
+			"a": 1,
+		};
+		data.put(table, "b", 2);`, "interpreter error: data.put(table, b, 2);\n                   ^~~~~~~~~~~~~~~~~~~~~~~\ndata.put cannot modify a frozen map on line 5 col 0", true},
+		{`const fixed = [1, 2];
+		fixed[0] = 5;`, "interpreter error: fixed[0] = 5;\n                   ^~~~~~~~~~~~~~\ncannot assign to index of 'fixed', array is frozen on line 2 col 0", true},
+		{`const section = [1, 2, 3];
+		section[0:1] = [9];`, "interpreter error: section[0:1] = [9];\n                   ^~~~~~~~~~~~~~~~~~~~\ncannot assign to slice of 'section', array is frozen on line 2 col 0", true},
+		{`using "data";
+		const labels = data.set();
+		data.add(labels, "x");`, "interpreter error: data.add(labels, x);\n                   ^~~~~~~~~~~~~~~~~~~~~\ndata.add cannot modify a frozen set on line 3 col 0", true},
+		{`using "data";
+		let inner = [1];
+		let outer = [0];
+		data.push(outer, inner);
+		freeze(outer);
+		data.push(inner, 2);`, "interpreter error: data.push(inner, 2);\n                   ^~~~~~~~~~~~~~~~~~~~~\ndata.push cannot modify a frozen array on line 6 col 0", true},
+		{`using "data";
+		let shared = [1];
+		let alias = shared;
+		freeze(alias);
+		data.pop(shared);`, "interpreter error: data.pop(shared);\n                   ^~~~~~~~~~~~~~~~~~\ndata.pop cannot modify a frozen array on line 5 col 0", true},
+		{`using "io";
+		io.println(freeze(5));`, "5\n", false},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestCopy(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		using "data";
+		const base = [1, 2];
+		let mine = copy(base);
+		data.push(mine, 3);
+		io.println(mine);
+		io.println(base);`, "[1, 2, 3]\n[1, 2]\n", false},
+		{`using "io";
+		using "data";
+		let deep = [0];
+		let sub = [1];
+		data.push(deep, sub);
+		let flat = copy(deep);
+		let full = deepcopy(deep);
+		data.push(sub, 2);
+		io.println(flat);
+		io.println(full);`, "[0, [1, 2]]\n[0, [1]]\n", false},
+		{`using "io";
+		using "data";
+		let sorted = data.sortedmap();
+		let copied = copy(sorted);
+		data.put(copied, "b", 1);
+		data.put(copied, "a", 2);
+		io.println(copied);`, "{a : 2, b : 1}\n", false},
+		{`using "io";
+		using "data";
+		let cycle = [];
+		data.push(cycle, cycle);
+		let cloned = deepcopy(cycle);
+		freeze(cycle);
+		data.pop(cloned);
+		io.println(data.size(cloned));`, "0\n", false},
+		{`copy();`, "interpreter error: copy();\n                   ^~~~~~~~\nunexpected number of args for copy, expected 1 got 0 on line 1 col 0", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
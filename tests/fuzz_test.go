@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"os"
+	"testing"
+
+	"goblin.org/main/frontend/lexer"
+	"goblin.org/main/frontend/parser"
+)
+
+// Programs the fuzzers start from. Neither the lexer nor the parser should panic on any
+// input, only return an error, run with i.e. go test ./tests -fuzz FuzzParse
+var fuzzSeeds = []string{
+	``,
+	`+`,
+	`=`,
+	`!`,
+	`@`,
+	`"unterminated`,
+	`b"\x00`,
+	`let x = 1 + 2 * 3;`,
+	`let y = x ** 2 ~/ 3 << 1;`,
+	`const m = {"a": 1, "b": [1, 2, 3],};`,
+	`fn add(a, b = 2, ...rest) { return a + b; } add(1, ...[2]);`,
+	`fn count(n) { let i = 0; while (i < n) { yield i; i++; } }`,
+	`for (let i = 0; i < 10; i++;) { if (i > 5) { io.println(i); } else { io.println(0); } }`,
+	`for (v in [1, 2]) { defer io.println(v); }`,
+	`using "io", "strings" as s; using { split } from "strings";`,
+	`export fn square(x) { return x * x; }`,
+	`let [a, ...rest] = [1, 2, 3]; let {k: v} = m;`,
+	`let array<map<string, int>> typed = [];`,
+	`let s = arr[1:-1]; let t = x ? 1 : 2;`,
+	`spawn worker(jobs); let b = b"\xff";`,
+}
+
+func addFuzzSeeds(f *testing.F) {
+
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	if source, err := os.ReadFile("../source/source.gob"); err == nil {
+		f.Add(string(source))
+	}
+}
+
+func FuzzTokenize(f *testing.F) {
+
+	addFuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, source string) {
+
+		tokens, _, err := lexer.Tokenize(source)
+		if err == nil && (len(tokens) == 0 || tokens[len(tokens)-1].Type != lexer.EOF) {
+			t.Errorf("expected tokens of `%v` to end with EOF", source)
+		}
+	})
+}
+
+func FuzzParse(f *testing.F) {
+
+	addFuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, source string) {
+
+		tokens, audit, err := lexer.Tokenize(source)
+		if err != nil {
+			return
+		}
+
+		parser.ProduceAST(tokens, audit)
+	})
+}
@@ -48,9 +48,9 @@ func TestPrint(t *testing.T) {
 			io.print(map[keys[i]]);
 		}`, "123", false},
 		{`using "io";
-		io.print();`, "interpreter error: unexpected number of args for io.print, expected 1 got 0", true},
+		io.print();`, "interpreter error: io.print();\n                   ^~~~~~~~~~~~\nunexpected number of args for io.print, expected 1 got 0 on line 2 col 0", true},
 		{`using "io";
-		io.print("hello", "world");`, "interpreter error: unexpected number of args for io.print, expected 1 got 2", true},
+		io.print("hello", "world");`, "interpreter error: io.print(hello, world);\n                   ^~~~~~~~~~~~~~~~~~~~~~~~\nunexpected number of args for io.print, expected 1 got 2 on line 2 col 0", true},
 	}
 
 	for _, tt := range tests {
@@ -123,9 +123,9 @@ func TestPrintln(t *testing.T) {
 			io.println(map[keys[i]]);
 		}`, "1\n2\n3\n", false},
 		{`using "io";
-		io.println();`, "interpreter error: unexpected number of args for io.println, expected 1 got 0", true},
+		io.println();`, "interpreter error: io.println();\n                   ^~~~~~~~~~~~~~\nunexpected number of args for io.println, expected 1 got 0 on line 2 col 0", true},
 		{`using "io";
-		io.println("hello", "world");`, "interpreter error: unexpected number of args for io.println, expected 1 got 2", true},
+		io.println("hello", "world");`, "interpreter error: io.println(hello, world);\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~~\nunexpected number of args for io.println, expected 1 got 2 on line 2 col 0", true},
 	}
 
 	for _, tt := range tests {
@@ -174,7 +174,7 @@ func TestPrintf(t *testing.T) {
 		let arr = [1, 2, 3];
 		io.printf("One: %v", arr[0]);`, "One: 1", false},
 		{`using "io";
-		io.printf();`, "interpreter error: unexpected number of args for io.printf, expected min 1 got 0", true},
+		io.printf();`, "interpreter error: io.printf();\n                   ^~~~~~~~~~~~~\nunexpected number of args for io.printf, expected min 1 got 0 on line 2 col 0", true},
 	}
 
 	for _, tt := range tests {
@@ -221,7 +221,7 @@ func TestSPrintf(t *testing.T) {
 		let i = io.sprintf("Hello, %v", "World");
 		io.print(i);`, "Hello, World", false},
 		{`using "io";
-		let j = io.sprintf();`, "interpreter error: unexpected number of args for io.sprintf, expected min 1 got 0", true},
+		let j = io.sprintf();`, "interpreter error: let j = io.sprintf();\n                   ~~~~~~~~^~~~~~~~~~~~~~\nunexpected number of args for io.sprintf, expected min 1 got 0 on line 2 col 8", true},
 	}
 
 	for _, tt := range tests {
@@ -270,10 +270,10 @@ func TestInput(t *testing.T) {
 		io.print(i);`, "Test message\n", "Message: Test message", false},
 		{`using "io";
 		let j = io.input();
-		io.print(j);`, "\n", "interpreter error: unexpected number of args for io.input, expected 1 got 0", true},
+		io.print(j);`, "\n", "interpreter error: let j = io.input();\n                   ~~~~~~~~^~~~~~~~~~~~\nunexpected number of args for io.input, expected 1 got 0 on line 2 col 8", true},
 		{`using "io";
 		let k = io.input("Message: ", "Another message");
-		io.print(k);`, "\n", "interpreter error: unexpected number of args for io.input, expected 1 got 2", true},
+		io.print(k);`, "\n", "interpreter error: let k = io.input(Message: , Another message);\n                   ~~~~~~~~^~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\nunexpected number of args for io.input, expected 1 got 2 on line 2 col 8", true},
 	}
 
 	for _, tt := range tests {
@@ -291,10 +291,10 @@ func TestInput(t *testing.T) {
 			env.Stdin = reader
 
 			// Write the test input to the writer end of the pipe
-			go func() {
-				writer.Write([]byte(tt.stdin))
+			go func(stdin string) {
+				writer.Write([]byte(stdin))
 				writer.Close()
-			}()
+			}(tt.stdin)
 
 			// Run the program.
 			_, err = program.Run(string(tt.source), env)
@@ -337,7 +337,7 @@ func TestOpen(t *testing.T) {
 		io.print(f);`, "../source/test.txt", false},
 		{`using "io";
 		let f = io.open("doesNotExist.txt", "r");
-		io.print(f);`, "interpreter error: open ../source/doesNotExist.txt: no such file or directory", true},
+		io.print(f);`, "interpreter error: let f = io.open(doesNotExist.txt, r);\n                   ~~~~~~~~^~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\nopen ../source/doesNotExist.txt: no such file or directory on line 2 col 8", true},
 	}
 
 	for _, tt := range tests {
@@ -387,11 +387,13 @@ func TestReadLine(t *testing.T) {
 		{`using "io";
 		let fr = io.open("test.txt", "r");
 		let liner = io.readline(fr, 3);
-		io.print(liner);`, "interpreter error: line number 3 not found in ../source/test.txt", true},
+		io.print(liner);`, "interpreter error: let liner = io.readline(fr, 3);\n                   ~~~~~~~~~~~~^~~~~~~~~~~~~~~~~~~~\nline number 3 not found in ../source/test.txt on line 3 col 12", true},
+
+		// Appended to rather than written, which would empty the file the other tests read.
 		{`using "io";
-		let fw = io.open("test.txt", "w");
+		let fw = io.open("test.txt", "a");
 		let linew = io.readline(fw, 1);
-		io.print(linew);`, "interpreter error: file: ../source/test.txt not opened in a valid read-mode", true},
+		io.print(linew);`, "interpreter error: let linew = io.readline(fw, 1);\n                   ~~~~~~~~~~~~^~~~~~~~~~~~~~~~~~~~\nfile: ../source/test.txt not opened in a valid read-mode on line 3 col 12", true},
 	}
 
 	for _, tt := range tests {
@@ -482,7 +484,7 @@ func TestClose(t *testing.T) {
 		io.println(line);
 		io.close(f);
 
-		let anotherLine = io.readline(f, 1);`, "interpreter error: read ../source/test.txt: file already closed", true},
+		let anotherLine = io.readline(f, 1);`, "interpreter error: let anotherLine = io.readline(f, 1);\n                   ~~~~~~~~~~~~~~~~~~^~~~~~~~~~~~~~~~~~~\nread ../source/test.txt: file already closed on line 8 col 18", true},
 		{`using "io";
 		let file = io.open("test.txt", "r");
 
@@ -0,0 +1,319 @@
+package tests
+
+import (
+	"fmt"
+	goruntime "runtime"
+	"testing"
+	"time"
+
+	"goblin.org/main/program"
+)
+
+func TestGenerators(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		fn count(int start) {
+			let i = start;
+			while (i < start + 3) {
+				yield i;
+				i += 1;
+			}
+		}
+		for (n in count(5)) {
+			io.println(n);
+		}`, "5\n6\n7\n", false},
+		{`using "io";
+		fn early() {
+			defer io.println("cleanup");
+			yield 1;
+			yield 2;
+		}
+		fn first() {
+			for (v in early()) {
+				return v;
+			}
+		}
+		io.println(first());`, "cleanup\n1\n", false},
+		{`using "io";
+		fn once() {
+			yield "only";
+		}
+		let single = once();
+		io.println(single);
+		for (a in single) {
+			io.println(a);
+		}
+		for (b in single) {
+			io.println(b);
+		}`, "iterator\nonly\n", false},
+		{`using "io";
+		fn failing() {
+			yield 1;
+			let arr = [1];
+			let missing = arr[5];
+		}
+		for (v in failing()) {
+			io.println(v);
+		}`, "interpreter error: let missing = arr[5];\n                   ~~~~~~~~~~~~~~^~~~~~~~\nindex out of bounds for index 5 on line 5 col 14", true},
+		{`let g = 0;
+		yield g;`, "parse error: yield g;\n             ~~~~~^~~~\nyield can only be used inside a function on line 2 col 5", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err == nil || err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err)
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+// Generators left part way through are stopped, rather than leaving their goroutine waiting
+// to be resumed forever.
+func TestGeneratorsStopped(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`using "io";
+		fn endless() {
+			defer io.println("stopped");
+			let i = 0;
+			while (true) {
+				yield i;
+				i += 1;
+			}
+		}
+		fn firstOf() {
+			for (v in endless()) {
+				return v;
+			}
+		}
+		io.println(firstOf());`, "stopped\n0\n"},
+		{`using "io";
+		using "iter";
+		fn upwards() {
+			let u = 0;
+			while (true) {
+				yield u;
+				u += 1;
+			}
+		}
+		io.println(iter.collect(iter.take(upwards(), 2)));`, "[0, 1]\n"},
+		{`using "io";
+		fn failsIn() {
+			defer io.println("stopped");
+			yield 1;
+			yield 2;
+		}
+		for (f in failsIn()) {
+			let short = [1];
+			io.println(short[5]);
+		}`, "stopped\n"},
+		{`using "io";
+		using "sync";
+		let ran = sync.waitgroup();
+		fn forever() {
+			let k = 0;
+			while (true) {
+				yield k;
+				k += 1;
+			}
+		}
+		fn firstInTask() {
+			for (k in forever()) {
+				io.println(k);
+				sync.done(ran);
+				return;
+			}
+		}
+		sync.add(ran, 1);
+		spawn firstInTask();
+		sync.wait(ran);`, "0\n"},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			before := goruntime.NumGoroutine()
+
+			// Run the program, errors are expected of some.
+			program.Run(string(tt.source), env)
+
+			// Tasks and generators finish in the background, so give them a moment.
+			for deadline := time.Now().Add(5 * time.Second); goruntime.NumGoroutine() > before; {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected %v goroutines, %v left running", before, goruntime.NumGoroutine())
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			if output.String() != tt.want {
+				t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestIterAdapters(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		using "iter";
+		fn naturals() {
+			let i = 0;
+			while (true) {
+				yield i;
+				i += 1;
+			}
+		}
+		fn double(x) {
+			return x * 2;
+		}
+		fn even(x) {
+			return x % 2 == 0;
+		}
+		io.println(iter.collect(iter.take(iter.map(naturals(), double), 4)));
+		io.println(iter.collect(iter.take(iter.filter(naturals(), even), 3)));
+		io.println(iter.collect(iter.zip(["a", "b", "c"], naturals())));`, "[0, 2, 4, 6]\n[0, 2, 4]\n[[a, 0], [b, 1], [c, 2]]\n", false},
+		{`using "io";
+		using "iter";
+		for ([i, w] in iter.enumerate("xy")) {
+			io.printf("%v=%v ", i, w);
+		}`, "0=x 1=y ", false},
+		{`using "iter";
+		fn notBool(x) {
+			return x;
+		}
+		iter.collect(iter.filter([1], notBool));`, "interpreter error: iter.collect(iter.filter([1], notBool));\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\niter.filter expects fn to return bool, int given on line 5 col 0", true},
+		{`using "iter";
+		iter.map([1], 5);`, "interpreter error: iter.map([1], 5);\n                   ^~~~~~~~~~~~~~~~~~\niter.map expectes arg2 to be of type fn, int given on line 2 col 0", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err == nil || err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err)
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestFileLines(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		let lines = io.open("test.txt", "r");
+		for (line in lines) {
+			io.println(line);
+		}
+		io.close(lines);`, "Hello, World!\nThis is a test\n", false},
+		{`using "io";
+		using "iter";
+		let numbered = io.open("test.txt", "r");
+		for ([i, line] in iter.enumerate(numbered)) {
+			io.printf("%v: %v ", i, line);
+		}
+		io.close(numbered);`, "0: Hello, World! 1: This is a test ", false},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err == nil || err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err)
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
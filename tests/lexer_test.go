@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"goblin.org/main/frontend/lexer"
+	"goblin.org/main/program"
+)
+
+func TestLexErrors(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`let at = 1 @ 2;`, "parse error: let at = 1 @\n             ~~~~~~~~~~~^~\nunrecognised character '@' on line 1 col 11", true},
+		{`let bang = !true;`, "parse error: let bang = !\n             ~~~~~~~~~~~^~\nunrecognised character '!' on line 1 col 11", true},
+		{`let plus = 1 +`, "parse error: let plus = 1 +\n             ~~~~~~~~~~~~~~^\nunexpected token found during parsing 'EOF' on line 1 col 14", true},
+		{`let equals =`, "parse error: let equals =\n             ~~~~~~~~~~~~^\nunexpected token found during parsing 'EOF' on line 1 col 12", true},
+		{`let open = "never closed;`, "parse error: let open = \"never closed;\n             ~~~~~~~~~~~^~~~~~~~~~~~~~~\nunterminated string on line 1 col 11", true},
+		{`let raw = b"never closed;`, "parse error: let raw = b\"never closed;\n             ~~~~~~~~~~^~~~~~~~~~~~~~~~\nunterminated bytes literal on line 1 col 10", true},
+		{`using "io";
+		let dollar = 1;
+		io.println(dollar $ 2);`, "parse error: io.println(dollar $\n             ~~~~~~~~~~~~~~~~~~^~\nunrecognised character '$' on line 3 col 18", true},
+		{`let größe = 1 @ 2;`, "parse error: let größe = 1 @\n             ~~~~~~~~~~~~~~^~\nunrecognised character '@' on line 1 col 14", true},
+		{`let 名前 = 1 $ 2;`, "parse error: let 名前 = 1 $\n             ~~~~~~~~~~~^~\nunrecognised character '$' on line 1 col 11", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err == nil || err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err)
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		let user_id = 1;
+		let x2 = 2;
+		let _private = 3;
+		io.println(user_id + x2 + _private);`, "6\n", false},
+		{`using "io";
+		let café = "crème";
+		let 名前 = "goblin";
+		io.println(café);
+		io.println(名前);`, "crème\ngoblin\n", false},
+		{`using "io";
+		fn área(ancho, alto) { return ancho * alto; }
+		io.println(área(2, 3));`, "6\n", false},
+		{`using "io";
+		let letters3 = [1, 2, 3];
+		io.println(letters3[2]);`, "3\n", false},
+		{`let 2fast = 1;`, "parse error: let 2fast = 1;\n             ~~~^~~~~~~~~~~~\nexpecting token `Identifier` on line 1 col 3", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err == nil || err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err)
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+// A program of n copies of the same few lines, to check lexing time grows linearly.
+func benchmarkSource(n int) string {
+
+	lines := `let user_id = 1;
+	fn área(w, h) { return w * h ** 2 ~/ 3; }
+	let map<string, int> totals = {"a": 1, "b": 2,};
+	for (let i = 0; i < 10; i++;) { io.println(área(i, user_id)); }
+	`
+
+	return strings.Repeat(lines, n)
+}
+
+func benchmarkTokenize(b *testing.B, n int) {
+
+	source := benchmarkSource(n)
+
+	b.SetBytes(int64(len(source)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, _, err := lexer.Tokenize(source); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// The time per byte should stay about the same as the source grows.
+func BenchmarkTokenize100(b *testing.B)    { benchmarkTokenize(b, 100) }
+func BenchmarkTokenize1000(b *testing.B)   { benchmarkTokenize(b, 1000) }
+func BenchmarkTokenize10000(b *testing.B)  { benchmarkTokenize(b, 10000) }
+func BenchmarkTokenize100000(b *testing.B) { benchmarkTokenize(b, 100000) }
@@ -28,7 +28,281 @@ func TestSimpleMap(t *testing.T) {
 			"foo": 10,
 			"bar": 20,
 		};
-		io.println(mapp["baz"]);`, "interpreter error: key `{String baz}` does not exist for map: mapp", true},
+		io.println(mapp["baz"]);`, "interpreter error: io.println(mapp[baz]);\n                   ~~~~~~~~~~~^~~~~~~~~~~~\nkey `{String baz}` does not exist for map: mapp on line 6 col 11", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestMapKeysByValue(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		using "strings";
+		let lookup = {
+			"host": 1,
+		};
+		let parts = strings.split("host:port", ":");
+		io.println(lookup[parts[0]]);`, "1\n", false},
+		{`using "io";
+		using "data";
+		let grid = {};
+		let here = [1, 2];
+		let there = [1, 2];
+		data.put(grid, here, "x");
+		io.println(grid[there]);`, "x\n", false},
+		{`using "io";
+		using "data";
+		let small = {};
+		data.put(small, 1, "one");
+		let shrunk = 9223372036854775807 + 1 - 9223372036854775807;
+		io.println(small[shrunk]);`, "one\n", false},
+		{`using "data";
+		fn keyfn() {}
+		let fns = {};
+		data.put(fns, keyfn, 1);`, "interpreter error: data.put(fns, keyfn, 1);\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~\nunhashable type fn on line 4 col 0", true},
+		{`fn other() {}
+		let fm = {};
+		fm[other] = 1;`, "interpreter error: fm[other] = 1;\n                   ^~~~~~~~~~~~~~~\nunhashable type fn on line 3 col 0", true},
+		{`using "data";
+		let looped = [1];
+		data.push(looped, looped);
+		let lm = {};
+		lm[looped] = 1;`, "interpreter error: lm[looped] = 1;\n                   ^~~~~~~~~~~~~~~~\nunhashable cyclic value on line 5 col 0", true},
+
+		// Keys are copied when stored, so changing the value they came from leaves them be.
+		{`using "io";
+		using "data";
+		let k = [1];
+		let km = {};
+		km[k] = 1;
+		data.push(k, 2);
+		io.println(km);
+		io.println(km[[1]]);`, "{[1] : 1}\n1\n", false},
+		{`using "io";
+		using "data";
+		let sk = [1];
+		let ks = data.set([sk]);
+		data.add(ks, sk);
+		data.push(sk, 2);
+		io.println(ks);`, "set{[1]}\n", false},
+		{`using "data";
+		let stored = {};
+		stored[[1]] = 1;
+		for (key in stored) {
+			data.push(key, 2);
+		}`, "interpreter error: data.push(key, 2);\n                   ^~~~~~~~~~~~~~~~~~~\ndata.push cannot modify a frozen array on line 5 col 0", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestValueEquality(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		let la = [1, 2];
+		let lb = [1, 2];
+		let lc = [2, 1];
+		io.println(la == lb);
+		io.println(la != lc);`, "true\ntrue\n", false},
+		{`using "io";
+		let ma = {
+			"a": 1,
+			"b": 2,
+		};
+		let mb = {
+			"b": 2,
+			"a": 1,
+		};
+		if (ma == mb) {
+			io.println("same");
+		}`, "same\n", false},
+		{`using "io";
+		fn fnull() {}
+		io.println(fnull != null);`, "true\n", false},
+		{`using "io";
+		fn fa() {}
+		io.println(fa == fa);`, "interpreter error: io.println(fa == fa);\n                   ~~~~~~~~~~~^~~~~~~~~~~\ncannot compare fn values on line 3 col 11", true},
+
+		// Values holding shared state are only equal to themselves.
+		{`using "io";
+		let ca = chan();
+		let cb = chan();
+		io.println(ca == ca);
+		io.println(ca == cb);
+		io.println([ca] == [ca]);`, "true\nfalse\ntrue\n", false},
+
+		// A value holding itself has no end to compare, one holding another twice is fine.
+		{`using "io";
+		using "data";
+		let selfish = [1];
+		data.push(selfish, selfish);
+		io.println(selfish == selfish);`, "interpreter error: io.println(selfish == selfish);\n                   ~~~~~~~~~~~^~~~~~~~~~~~~~~~~~~~~\ncannot compare cyclic value on line 5 col 11", true},
+		{`using "io";
+		let twice = [1];
+		io.println([twice, twice] == [[1], [1]]);`, "true\n", false},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestMapOrder(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		using "data";
+		let order = {
+			"zeta": 1,
+			"alpha": 2,
+			"mid": 3,
+		};
+		data.put(order, "beta", 4);
+		data.put(order, "zeta", 5);
+		io.println(order);
+		io.println(data.keys(order));
+		io.println(data.values(order));`, "{zeta : 5, alpha : 2, mid : 3, beta : 4}\n[zeta, alpha, mid, beta]\n[5, 2, 3, 4]\n", false},
+		{`using "io";
+		let walk = {
+			"c": 1,
+			"a": 2,
+			"b": 3,
+		};
+		for (key in walk) {
+			io.print(key);
+		}`, "cab", false},
+		{`using "io";
+		using "data";
+		let shrink = {
+			"x": 1,
+			"y": 2,
+		};
+		io.println(data.delete(shrink, "x"));
+		io.println(data.delete(shrink, "x"));
+		data.put(shrink, "x", 3);
+		io.println(shrink);`, "true\nfalse\n{y : 2, x : 3}\n", false},
+		{`using "io";
+		using "data";
+		let unsorted = {
+			"pear": 1,
+			"apple": 2,
+		};
+		let sorted = data.sortedmap(unsorted);
+		data.put(sorted, "fig", 3);
+		io.println(sorted);
+		io.println(data.keys(sorted));`, "{apple : 2, fig : 3, pear : 1}\n[apple, fig, pear]\n", false},
+		{`using "io";
+		let settings = {
+			"name": "goblin",
+			"debug": true,
+			"level": 2,
+		};
+		let {name, ...others} = settings;
+		io.println(others);`, "{debug : true, level : 2}\n", false},
+		{`using "data";
+		data.keys(5);`, "interpreter error: data.keys(5);\n                   ^~~~~~~~~~~~~~\ndata.keys must be used on map type, int type given on line 2 col 0", true},
 	}
 
 	for _, tt := range tests {
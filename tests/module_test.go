@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"goblin.org/main/program"
+)
+
+// Modules live in source/lib. Loaded modules are cached for the length of a program, each
+// program loads them afresh.
+func TestModules(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		using "./lib/math.gob";
+		using "./lib/shapes.gob";
+		io.println(math.square(4));
+		io.println(math.pi);
+		io.println(shapes.circle(2));`, "16\n3\n12\n", false},
+		{`using "io";
+		using "./lib/once.gob";
+		using "./lib/once.gob" as twice;
+		io.println(once.loaded == twice.loaded);`, "loading once\ntrue\n", false},
+		{`using "io";
+		using "./lib/once.gob";
+		io.println(once.loaded);`, "loading once\ntrue\n", false},
+
+		// A task using a module another task is still loading waits for it, rather than
+		// seeing an import cycle.
+		{`using "io";
+		using "sync";
+		let loaded = sync.waitgroup();
+		fn load() {
+			using "./lib/slow.gob";
+			io.println(slow.total);
+			sync.done(loaded);
+		}
+		sync.add(loaded, 2);
+		spawn load();
+		spawn load();
+		sync.wait(loaded);`, "loading slow\n5000\n5000\n", false},
+		{`using "io";
+		using "./lib/math.gob" as geometry;
+		let area = geometry.area;
+		io.println(area(1));`, "3\n", false},
+		{`using "io";
+		using "./lib/math.gob";
+		io.println(math.helper());`, "interpreter error: io.println(math.helper());\n                   ~~~~~~~~~~~^~~~~~~~~~~~~~~~\nundefined export: helper for module: math on line 3 col 11", true},
+		{`using "./lib/cyclea.gob";`, "interpreter error: using ./lib/cyclea.gob;\n                   ^~~~~~~~~~~~~~~~~~~~~~~~\nimport cycle: ./lib/cyclea.gob -> ./cycleb.gob -> ./cyclea.gob on line 1 col 0", true},
+		{`using "./lib/missing.gob";`, "interpreter error: using ./lib/missing.gob;\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~\nunable to load module: ./lib/missing.gob on line 1 col 0", true},
+		{`using "io";
+		export const answer = 42;
+		io.println(answer);`, "42\n", false},
+		{`fn exporting() { export let inner = 1; }`, "parse error: fn exporting() { export let inner = 1; }\n             ~~~~~~~~~~~~~~~~~~~~~~~^~~~~~~~~~~~~~~~~~\nexport can only be used at the top level of a module on line 1 col 23", true},
+		{`export io.println(1);`, "parse error: export io.println(1);\n             ~~~~~~~~~~~~~~~~~~~~~^\nexport requires a let, const or fn decleration on line 1 col 21", true},
+		{`using "io";
+		using "strings" as text;
+		io.println(text.split("a-b", "-"));`, "[a, b]\n", false},
+		{`using "strings" as words;
+		words.shout("hi");`, "interpreter error: words.shout(hi);\n                   ^~~~~~~~~~~~~~~~~\nundefined fucntion: shout for namespace: strings on line 2 col 0", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err == nil || err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err)
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestImports(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io", "strings" as str, "./lib/math.gob" as calc;
+		io.println(str.split("a b", " "));
+		io.println(calc.square(3));`, "[a, b]\n9\n", false},
+		{`using "io";
+		using { split } from "strings";
+		using { square, pi } from "./lib/math.gob";
+		io.println(split("c d", " "));
+		io.println(square(pi));`, "[c, d]\n9\n", false},
+		{`using "io";
+		fn scoped() {
+			using { encode } from "strings";
+			return encode("a", "ascii");
+		}
+		io.println(scoped());
+		io.println(encode);`, "resolve error: io.println(encode);\n               ~~~~~~~~~~~^~~~~~~~~\nreference to undefined variable 'encode' on line 7 col 11", true},
+		{`using "io";
+		fn usesData() {
+			using "data";
+			return data.size([1, 2]);
+		}
+		io.println(usesData());
+		io.println(data.size([1]));`, "2\n1\n", false},
+		{`using "data";
+		using "./lib/leaky.gob";
+		leaky.count([1]);`, "in module ./lib/leaky.gob:\nresolve error:     return data.size(arr);\n               ~~~~~~~~~~~^~~~~~~~~~~~~~~~\nreference to undefined variable 'data' on line 2 col 11", true},
+		{`let decode = 1;
+		using { decode } from "strings";`, "resolve error: using { decode } from strings;\n               ^~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\nimport of 'decode' from strings conflicts with existing variable 'decode' on line 2 col 0", true},
+		{`let text = 1;
+		using "strings" as text;`, "resolve error: using strings as text;\n               ^~~~~~~~~~~~~~~~~~~~~~~\nnamespace 'text' conflicts with existing variable 'text' on line 2 col 0", true},
+		{`using "io" as out;
+		using "data" as out;`, "resolve error: using data as out;\n               ^~~~~~~~~~~~~~~~~~~\nnamespace 'out' is already used for io on line 2 col 0", true},
+		{`using "sync";
+		let sync = 1;`, "resolve error: let sync = 1;\n               ^~~~~~~~~~~~~~\n'sync' already defined as a namespace on line 2 col 0", true},
+		{`using "io";
+		using { square } from "./lib/math.gob";`, "interpreter error: using { square } from ./lib/math.gob;\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\nimport of 'square' from math conflicts with existing variable 'square' on line 2 col 0", true},
+		{`using { missing } from "strings";`, "interpreter error: using { missing } from strings;\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\nundefined fucntion: missing for namespace: strings on line 1 col 0", true},
+		{`using { split } "strings";`, "parse error: using { split } strings;\n             ~~~~~~~~~~~~~~~^~~~~~~~~~\nexpecting 'from' after the names to import on line 1 col 15", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err == nil || err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err)
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
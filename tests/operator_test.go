@@ -64,3 +64,112 @@ func TestOperators(t *testing.T) {
 		})
 	}
 }
+
+func TestExtendedOperators(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`using "io";
+		io.println(2 ** 10);
+		io.println(2 ** 3 ** 2);
+		io.println(-2 ** 2);`, "1024\n512\n-4\n"},
+		{`using "io";
+		io.println(6 & 3);
+		io.println(6 | 3);
+		io.println(6 ^ 3);
+		io.println(~5);`, "2\n7\n5\n-6\n"},
+		{`using "io";
+		io.println(1 << 4);
+		io.println(256 >> 2);`, "16\n64\n"},
+		{`using "io";
+		io.println(7 ~/ 2);
+		io.println(-7 ~/ 2);
+		io.println(-7 / 2);`, "3\n-4\n-3\n"},
+		{`using "io";
+		io.println(1 + 2 * 3);
+		io.println(4 > 1 + 2);
+		io.println(1 + 2 << 1);
+		io.println(3 <= 3);
+		io.println(2 >= 3);`, "7\ntrue\n6\ntrue\nfalse\n"},
+		{`using "io";
+		let flags = 3;
+		flags **= 2;
+		flags &= 5;
+		flags |= 8;
+		flags ^= 1;
+		flags <<= 2;
+		flags >>= 1;
+		flags ~/= 4;
+		io.println(flags);`, "4\n"},
+		{`using "io";
+		let sum = 0;
+		for (let s = 0; s < 3; s++;) {
+			sum += s;
+		}
+		io.println(sum);`, "3\n"},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+			if err != nil {
+				t.Errorf(err.Error())
+			}
+
+			if output.String() != tt.want {
+				t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestOperatorErrors(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`using "io";
+		io.println(1 / 0);`, "interpreter error: io.println(1 / 0);\n                   ~~~~~~~~~~~^~~~~~~~\ndivision by zero on line 2 col 11"},
+		{`using "io";
+		io.println(1 ~/ 0);`, "interpreter error: io.println(1 ~/ 0);\n                   ~~~~~~~~~~~^~~~~~~~~\ndivision by zero on line 2 col 11"},
+		{`using "io";
+		io.println(1 % 0);`, "interpreter error: io.println(1 % 0);\n                   ~~~~~~~~~~~^~~~~~~~\nmodulo by zero on line 2 col 11"},
+		{`let divisor = 1;
+		divisor /= 0;`, "interpreter error: divisor /= 0;\n                   ^~~~~~~~~~~~~~\ndivision by zero on line 2 col 0"},
+		{`using "io";
+		io.println(2 ** -1);`, "interpreter error: io.println(2 ** -1);\n                   ~~~~~~~~~~~^~~~~~~~~~\nnegative exponent -1 for int on line 2 col 11"},
+		{`using "io";
+		io.println(1 << -1);`, "interpreter error: io.println(1 << -1);\n                   ~~~~~~~~~~~^~~~~~~~~~\nnegative shift count -1 on line 2 col 11"},
+		{`const fixed = 1;
+		fixed++;`, "resolve error: fixed++;\n               ^~~~~~~~~\ncannot reassign const value 'fixed' on line 2 col 0"},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("expected `%v`, received `%v`", tt.want, err)
+			}
+
+			FlushBuffer()
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"goblin.org/main/frontend/ast"
+	"goblin.org/main/frontend/lexer"
+	"goblin.org/main/frontend/parser"
+	"goblin.org/main/runtime"
+)
+
+func TestOptimise(t *testing.T) {
+
+	// Each program must optimise to the same AST as the one written out by hand.
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`const day = 60 * 60 * 24;`, `const day = 86400;`},
+		{`let diff = 2 - 5 + 10;`, `let diff = 7;`},
+		{`let huge = 9223372036854775807 + 1;`, `let huge = 9223372036854775808;`},
+		{`let same = "a" == "a";`, `let same = true;`},
+		{`let partial = 1;
+		let rest = partial + 2 * 3;`, `let partial = 1;
+		let rest = partial + 6;`},
+		{`let chosen = 1 < 2 ? "yes" : "no";`, `let chosen = "yes";`},
+		{`let limit = 0;
+		while (limit < 2 * 5) {
+			limit++;
+		}`, `let limit = 0;
+		while (limit < 10) {
+			limit++;
+		}`},
+
+		// Errors are left for the program to raise when it runs.
+		{`let zero = 10 / (5 - 5);`, `let zero = 10 / 0;`},
+		{`let notFolded = "a" + "b";`, `let notFolded = "a" + "b";`},
+
+		// Dead branches are dropped, the branch taken takes the place of the if statement.
+		{`if (2 > 1) {
+			let taken = 1;
+		} else {
+			let taken = 2;
+		}
+		let after = 1;`, `let taken = 1;
+		let after = 1;`},
+		{`if (false) {
+			let skipped = 1;
+		}
+		let after = 1;`, `let after = 1;`},
+
+		// Unless it is the last statement, whose value a function returns.
+		{`fn lastIf() {
+			if (false) {
+				return 1;
+			} else {
+				return 2;
+			}
+		}`, `fn lastIf() {
+			if (true) {
+				return 2;
+			}
+		}`},
+		{`fn early() {
+			return 1;
+			let unreachable = 2;
+		}`, `fn early() {
+			return 1;
+		}`},
+		{`fn inlined() {
+			if (true) {
+				return 1;
+			}
+			return 2;
+		}`, `fn inlined() {
+			return 1;
+		}`},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			got := ast.Dump(runtime.Optimise(parse(t, tt.source)))
+			want := ast.Dump(parse(t, tt.want))
+
+			if got != want {
+				t.Errorf("expected\n%v\nreceived\n%v", want, got)
+			}
+		})
+	}
+}
+
+func parse(t *testing.T, source string) ast.Program {
+
+	tokens, audit, err := lexer.Tokenize(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prog, err := parser.ProduceAST(tokens, audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return prog
+}
@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"goblin.org/main/frontend/ast"
+	"goblin.org/main/frontend/lexer"
+	"goblin.org/main/frontend/parser"
+)
+
+// Parses the same programs one at a time, then all at once, expecting the same trees and
+// errors. Run with -race to check no parse can see the state of another.
+func TestParallelParse(t *testing.T) {
+
+	var sources = []string{
+		`let x = 1 + 2 * 3;`,
+		`fn add(a, b) { return a + b; } add(1, 2);`,
+		`fn count(n) { let i = 0; while (i < n) { yield i; i++; } }`,
+		`const m = {"a": 1, "b": [1, 2, 3]};`,
+		`for (let i = 0; i < 10; i++;) { if (i > 5) { io.println(i); } else { io.println(0); } }`,
+		`using "io", "strings" as s; using { split } from "strings";`,
+		`export fn square(x) { return x * x; }`,
+		`let [a, ...rest] = [1, 2, 3];`,
+		`let broken = ;`,
+		`fn inner() { export let y = 1; }`,
+		`yield 1;`,
+		`let map<string, array<int>> nested = {"a": [1],};`,
+		`let bigNumber = 123456789012345678901234567890;`,
+	}
+
+	type result struct {
+		tree ast.Program
+		err  string
+	}
+
+	parse := func(source string) result {
+
+		tokens, audit, err := lexer.Tokenize(source)
+		if err != nil {
+			return result{err: err.Error()}
+		}
+
+		program, err := parser.NewParser(tokens, audit).ProduceAST()
+		if err != nil {
+			return result{err: err.Error()}
+		}
+
+		return result{tree: program}
+	}
+
+	want := make([]result, len(sources))
+	for i, source := range sources {
+		want[i] = parse(source)
+	}
+
+	const rounds = 50
+
+	var wg sync.WaitGroup
+	got := make([]result, len(sources)*rounds)
+
+	for i := range got {
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i] = parse(sources[i%len(sources)])
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i, r := range got {
+
+		expected := want[i%len(sources)]
+		if !reflect.DeepEqual(r, expected) {
+			t.Errorf("parsing `%v` in parallel gave `%#v`, expected `%#v`", sources[i%len(sources)], r, expected)
+		}
+	}
+}
+
+// Parses programs holding syntax errors, expecting every one of them to be reported.
+func TestDiagnostics(t *testing.T) {
+
+	var tests = []struct {
+		source string
+		want   []parser.Diagnostic
+	}{
+		{`let fine = 1;`, nil},
+		{`let a = ;
+		let b = 2;
+		let c = ;`, []parser.Diagnostic{
+			{Severity: parser.SeverityError, Line: 1, Col: 7, Source: "let a = ;", Message: "unexpected token found during parsing ';'"},
+			{Severity: parser.SeverityError, Line: 3, Col: 7, Source: "let c = ;", Message: "unexpected token found during parsing ';'"},
+		}},
+
+		// Errors inside a block are skipped up to the next statement, the block still ends at its brace.
+		{`fn f() {
+			let y = 1 +;
+			return y;
+		}
+		let z = ;`, []parser.Diagnostic{
+			{Severity: parser.SeverityError, Line: 2, Col: 11, Source: "let y = 1 +;", Message: "unexpected token found during parsing ';'"},
+			{Severity: parser.SeverityError, Line: 5, Col: 7, Source: "let z = ;", Message: "unexpected token found during parsing ';'"},
+		}},
+
+		// Errors that used to be dropped.
+		{`total += ;`, []parser.Diagnostic{
+			{Severity: parser.SeverityError, Line: 1, Col: 8, Source: "total += ;", Message: "unexpected token found during parsing ';'"},
+		}},
+		{`let v = w[;`, []parser.Diagnostic{
+			{Severity: parser.SeverityError, Line: 1, Col: 10, Source: "let v = w[;", Message: "unexpected token found during parsing ';'"},
+		}},
+
+		// Errors inside a map or array literal skip to the literal's closer, not stop at it.
+		{`let m = {"b": 1, "a": 2};
+		let after = ;`, []parser.Diagnostic{
+			{Severity: parser.SeverityError, Line: 1, Col: 23, Source: "let m = {b: 1, a: 2};", Message: "expecting token `,`"},
+			{Severity: parser.SeverityError, Line: 2, Col: 11, Source: "let after = ;", Message: "unexpected token found during parsing ';'"},
+		}},
+		{`let keyed = {[1]: 2,};`, []parser.Diagnostic{
+			{Severity: parser.SeverityError, Line: 1, Col: 16, Source: "let keyed = {[1]: 2,};", Message: "invalid type provided for map key: array literal"},
+		}},
+		{`let arr = [1, 2 3];
+		let after = ;`, []parser.Diagnostic{
+			{Severity: parser.SeverityError, Line: 1, Col: 15, Source: "let arr = [1, 2 3];", Message: "expecting token `,`"},
+			{Severity: parser.SeverityError, Line: 2, Col: 11, Source: "let after = ;", Message: "unexpected token found during parsing ';'"},
+		}},
+
+		// A bracket or paren that is never closed stops at the end of its statement, rather
+		// than taking the rest of the program with it.
+		{`let d = [1, 2;
+		let e = ;
+		let f = (1 + 2;
+		let g = ;
+		let h = ;`, []parser.Diagnostic{
+			{Severity: parser.SeverityError, Line: 1, Col: 13, Source: "let d = [1, 2;", Message: "expecting token `,`"},
+			{Severity: parser.SeverityError, Line: 2, Col: 7, Source: "let e = ;", Message: "unexpected token found during parsing ';'"},
+			{Severity: parser.SeverityError, Line: 3, Col: 14, Source: "let f = (1 + 2;", Message: "expecting token `)`"},
+			{Severity: parser.SeverityError, Line: 4, Col: 7, Source: "let g = ;", Message: "unexpected token found during parsing ';'"},
+			{Severity: parser.SeverityError, Line: 5, Col: 7, Source: "let h = ;", Message: "unexpected token found during parsing ';'"},
+		}},
+		{`fn f( {
+		let g = ;`, []parser.Diagnostic{
+			{Severity: parser.SeverityError, Line: 1, Col: 7, Source: "fn f( {", Message: "expecting token `Identifier`"},
+			{Severity: parser.SeverityError, Line: 2, Col: 7, Source: "let g = ;", Message: "unexpected token found during parsing ';'"},
+		}},
+
+		// The ';'s of a for header don't end the statement.
+		{`for (let i = ; i < 3; i++;) {
+			io.println(i);
+		}
+		let after = ;`, []parser.Diagnostic{
+			{Severity: parser.SeverityError, Line: 1, Col: 12, Source: "for (let i = ; i < 3; i++;) {", Message: "unexpected token found during parsing ';'"},
+			{Severity: parser.SeverityError, Line: 4, Col: 11, Source: "let after = ;", Message: "unexpected token found during parsing ';'"},
+		}},
+
+		// A stray brace is skipped over.
+		{`} let after = ;`, []parser.Diagnostic{
+			{Severity: parser.SeverityError, Line: 1, Col: 1, Source: "} let after = ;", Message: "unexpected token found during parsing '}'"},
+			{Severity: parser.SeverityError, Line: 1, Col: 13, Source: "} let after = ;", Message: "unexpected token found during parsing ';'"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+
+			tokens, audit, err := lexer.Tokenize(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []parser.Diagnostic
+
+			_, err = parser.ProduceAST(tokens, audit)
+			if err != nil {
+
+				diagnostics, isDiagnostics := err.(parser.Diagnostics)
+				if !isDiagnostics {
+					t.Fatalf("expected diagnostics, received `%v`", err)
+				}
+
+				got = diagnostics
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected `%#v`, received `%#v`", tt.want, got)
+			}
+		})
+	}
+}
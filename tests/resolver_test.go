@@ -0,0 +1,213 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"goblin.org/main/frontend/lexer"
+	"goblin.org/main/frontend/parser"
+	"goblin.org/main/frontend/resolver"
+	"goblin.org/main/program"
+	"goblin.org/main/runtime"
+)
+
+func TestResolveErrors(t *testing.T) {
+
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`let outerVar = 1;
+		fn useOuter() {
+			return outerVar + laterVar;
+		}
+		let laterVar = 2;`, ""},
+
+		// Branches that don't both run can declare the same name.
+		{`if (true) {
+			let branch = 1;
+		} else {
+			let branch = 2;
+		}`, ""},
+		{`for (let i = 0; i < 2; i++;) {
+			let perIteration = i;
+		}
+		for (let i = 0; i < 2; i++;) {
+			let perIteration = i;
+		}`, ""},
+
+		// Reported even when the code would never run.
+		{`fn neverCalled() {
+			return missing;
+		}`, "resolve error: return missing;\n               ~~~~~~~^~~~~~~~~\nreference to undefined variable 'missing' on line 2 col 7"},
+		{`fn tooEarly() {
+			let early = late;
+			let late = 1;
+		}`, "resolve error: let early = late;\n               ~~~~~~~~~~~~^~~~~~\nreference to undefined variable 'late' on line 2 col 12"},
+		{`fn scoped() {
+			let inner = 1;
+		}
+		let outer = inner;`, "resolve error: let outer = inner;\n               ~~~~~~~~~~~~^~~~~~~\nreference to undefined variable 'inner' on line 4 col 12"},
+		{`let twice = 1;
+		let twice = 2;`, "resolve error: let twice = 2;\n               ^~~~~~~~~~~~~~~\n'twice' already defined on line 2 col 0"},
+		{`fn shadowParam(a) {
+			let a = 1;
+		}`, "resolve error: let a = 1;\n               ^~~~~~~~~~~\n'a' already defined on line 2 col 0"},
+		{`const fixed = 1;
+		fn change() {
+			fixed = 2;
+		}`, "resolve error: fixed = 2;\n               ^~~~~~~~~~~\ncannot reassign const value 'fixed' on line 3 col 0"},
+		{`fn named() {}
+		named += 1;`, "resolve error: named += 1;\n               ^~~~~~~~~~~~\ncannot reassign const value 'named' on line 2 col 0"},
+		{`using "io" as shown;
+		using "strings" as shown;`, "resolve error: using strings as shown;\n               ^~~~~~~~~~~~~~~~~~~~~~~~\nnamespace 'shown' is already used for io on line 2 col 0"},
+		{`using "io" as again;
+		using "io" as again;`, ""},
+		{`using "./lib/math.gob" as m;
+		using "lib/math.gob" as m;`, ""},
+
+		// Every resolve error is reported, not just the first.
+		{`let first = a;
+		let second = b;`, "resolve error: let first = a;\n               ~~~~~~~~~~~~^~~\nreference to undefined variable 'a' on line 1 col 12\n" +
+			"resolve error: let second = b;\n               ~~~~~~~~~~~~~^~~\nreference to undefined variable 'b' on line 2 col 13"},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Check the program, without running it.
+			err := program.Check(tt.source)
+
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+
+			if got != tt.want {
+				t.Errorf("expected `%v`, received `%v`", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBindings(t *testing.T) {
+
+	var tests = []struct {
+		source string
+		name   string
+		line   int // Of the use to look up.
+		want   resolver.Binding
+	}{
+		{`let global = 1;
+		let copy = global;`, "global", 2, resolver.Binding{Global: true}},
+		{`fn local(a, b) {
+			return b;
+		}`, "b", 2, resolver.Binding{Depth: 0, Slot: 1}},
+		{`fn captures() {
+			let first = 1;
+			let captured = 2;
+			fn inner() {
+				fn innermost() {
+					return captured;
+				}
+			}
+		}`, "captured", 6, resolver.Binding{Depth: 2, Slot: 1}},
+		{`for (let i = 0; i < 1; i++;) {
+			let body = i;
+			let copy = body;
+		}`, "body", 3, resolver.Binding{Depth: 0, Slot: 1}},
+
+		// Before its decleration a name means whatever it did outside.
+		{`let shadowed = 1;
+		fn shadows() {
+			let copy = shadowed;
+			let shadowed = 2;
+		}`, "shadowed", 3, resolver.Binding{Global: true}},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.name)
+		t.Run(testname, func(t *testing.T) {
+
+			tokens, audit, err := lexer.Tokenize(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prog, err := parser.ProduceAST(tokens, audit)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, errs := resolver.Resolve(prog, audit, resolver.Globals{})
+			if len(errs) != 0 {
+				t.Fatalf("expected no resolve errors, received %v", errs)
+			}
+
+			for ref, b := range res.Bindings {
+				if ref.Name == tt.name && ref.Span.Start.Line == tt.line {
+
+					if b != tt.want {
+						t.Errorf("expected %+v, received %+v", tt.want, b)
+					}
+
+					return
+				}
+			}
+
+			t.Errorf("expected a binding for '%v' on line %v", tt.name, tt.line)
+		})
+	}
+}
+
+func TestReplGlobals(t *testing.T) {
+
+	var tests = []struct {
+		lines []string // Each run on its own, like lines typed into the REPL.
+		want  string
+	}{
+		{[]string{`let earlier = 1;`, `using "io"; io.println(earlier);`}, "1\n"},
+		{[]string{`let [first, second] = [1, 2];`, `using "io"; io.println(first + second);`}, "3\n"},
+		{[]string{`let reassigned = 1;`, `reassigned = 2;`, `using "io"; io.println(reassigned);`}, "2\n"},
+		{[]string{`const fixed = 1;`, `fixed = 2;`}, "error: resolve error: fixed = 2;\n" +
+			"               ^~~~~~~~~~~\n" +
+			"cannot reassign const value 'fixed' on line 1 col 0\n"},
+	}
+
+	for _, tt := range tests {
+		for _, engine := range []program.Engine{program.TreeWalker, program.Bytecode} {
+
+			testname := fmt.Sprintf("%v, %v", strings.Join(tt.lines, " "), engine)
+			t.Run(testname, func(t *testing.T) {
+
+				var out bytes.Buffer
+
+				env := runtime.Environment{
+					Stdout:        &out,
+					Stdin:         os.Stdin,
+					Variables:     map[string]runtime.RuntimeValue{},
+					Constants:     map[string]bool{},
+					Namespaces:    map[string]runtime.Namespace{},
+					EntryLocation: "../source",
+				}
+
+				env.Setup()
+
+				for _, line := range tt.lines {
+					if _, err := program.RunWith(line, env, program.Options{Engine: engine}); err != nil {
+						fmt.Fprintf(&out, "error: %v\n", err)
+						break
+					}
+				}
+
+				if got := out.String(); got != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, got)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"goblin.org/main/program"
+)
+
+func TestSets(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		using "data";
+		using "strings";
+		let words = strings.split("b a c a b", " ");
+		let uniq = data.set(words);
+		io.println(uniq);
+		io.println(data.size(uniq));`, "set{a, b, c}\n3\n", false},
+		{`using "io";
+		using "data";
+		let grown = data.set();
+		data.add(grown, 2);
+		data.add(grown, 1);
+		data.add(grown, 2);
+		io.println(grown);
+		io.println(data.contains(grown, 1));
+		io.println(data.remove(grown, 1));
+		io.println(data.remove(grown, 1));
+		io.println(grown);`, "set{1, 2}\ntrue\ntrue\nfalse\nset{2}\n", false},
+		{`using "io";
+		using "data";
+		let mixed = data.set([3, "x", 1, true, 10]);
+		io.println(mixed);`, "set{true, 1, 3, 10, x}\n", false},
+		{`using "io";
+		using "data";
+		let odds = data.set([1, 3, 5]);
+		let primes = data.set([2, 3, 5]);
+		io.println(data.union(odds, primes));
+		io.println(data.intersection(odds, primes));
+		io.println(data.difference(odds, primes));
+		io.println(data.subset(data.set([3, 5]), primes));
+		io.println(data.subset(odds, primes));`, "set{1, 2, 3, 5}\nset{3, 5}\nset{1}\ntrue\nfalse\n", false},
+		{`using "io";
+		using "data";
+		let ordered = data.set([10, 2, 4]);
+		for (n in ordered) {
+			io.print(n);
+		}`, "2410", false},
+		{`using "io";
+		using "data";
+		let bigs = data.set([2 ** 64, 18446744073709551616]);
+		io.println(data.size(bigs));`, "1\n", false},
+		{`using "io";
+		using "data";
+		let holder = data.set();
+		let inner = [1];
+		let same = [1];
+		data.add(holder, inner);
+		data.add(holder, same);
+		io.println(data.size(holder));`, "1\n", false},
+		{`using "data";
+		fn hook() {}
+		let hooks = data.set();
+		data.add(hooks, hook);`, "interpreter error: data.add(hooks, hook);\n                   ^~~~~~~~~~~~~~~~~~~~~~~\nunhashable type fn on line 4 col 0", true},
+		{`using "data";
+		let circular = [1];
+		data.push(circular, circular);
+		let members = data.set();
+		data.add(members, circular);`, "interpreter error: data.add(members, circular);\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~~~~~\nunhashable cyclic value on line 5 col 0", true},
+		{`using "data";
+		let circle = [1];
+		data.push(circle, circle);
+		data.contains(data.set([1]), circle);`, "interpreter error: data.contains(data.set([1]), circle);\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\nunhashable cyclic value on line 4 col 0", true},
+		{`using "data";
+		data.set(5);`, "interpreter error: data.set(5);\n                   ^~~~~~~~~~~~~\ndata.set cannot be made from int on line 2 col 0", true},
+		{`using "data";
+		let notSet = [1];
+		data.union(notSet, notSet);`, "interpreter error: data.union(notSet, notSet);\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~~~~\ndata.union must be used on set type, array type given on line 3 col 0", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
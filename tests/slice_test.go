@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"goblin.org/main/program"
+)
+
+func TestSlicing(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		let nums = [1, 2, 3, 4, 5];
+		io.println(nums[1:3]);
+		io.println(nums[:2]);
+		io.println(nums[3:]);
+		io.println(nums[:]);`, "[2, 3]\n[1, 2]\n[4, 5]\n[1, 2, 3, 4, 5]\n", false},
+		{`using "io";
+		let negs = [1, 2, 3, 4, 5];
+		io.println(negs[-1]);
+		io.println(negs[-2:]);
+		io.println(negs[:-1]);`, "5\n[4, 5]\n[1, 2, 3, 4]\n", false},
+		{`using "io";
+		let word = "héllo wörld";
+		io.println(word[1]);
+		io.println(word[0:5]);
+		io.println(word[-5:]);`, "é\nhéllo\nwörld\n", false},
+		{`using "io";
+		let orig = [1, 2, 3];
+		let dup = orig[:];
+		dup[0] = 100;
+		io.println(orig);
+		io.println(dup);`, "[1, 2, 3]\n[100, 2, 3]\n", false},
+		{`using "io";
+		let lo = 1;
+		let span = [1, 2, 3];
+		io.println(span[lo:lo + 1]);`, "[2]\n", false},
+		{`using "io";
+		let bad = [1, 2, 3];
+		io.println(bad[2:1]);`, "interpreter error: io.println(bad[2:1]);\n                   ~~~~~~~~~~~^~~~~~~~~~~\nslice bounds out of range [2:1] with length 3 on line 3 col 11", true},
+		{`using "io";
+		let short = [1, 2, 3];
+		io.println(short[-4]);`, "interpreter error: io.println(short[-4]);\n                   ~~~~~~~~~~~^~~~~~~~~~~~\nindex out of bounds for index -4 on line 3 col 11", true},
+		{`using "io";
+		let notSliceable = 5;
+		io.println(notSliceable[1:]);`, "interpreter error: io.println(notSliceable[1:]);\n                   ~~~~~~~~~~~^~~~~~~~~~~~~~~~~~~\ncannot slice int on line 3 col 11", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestSliceAssignment(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		let shrink = [1, 2, 3, 4, 5];
+		shrink[1:3] = [9];
+		io.println(shrink);`, "[1, 9, 4, 5]\n", false},
+		{`using "io";
+		let grow = [1, 2];
+		grow[1:1] = ["a", "b"];
+		io.println(grow);`, "[1, a, b, 2]\n", false},
+		{`using "io";
+		let elems = [1, 2, 3];
+		elems[0] = 7;
+		elems[-1] = 8;
+		io.println(elems);`, "[7, 2, 8]\n", false},
+		{`using "io";
+		let entries = {
+			"a": 1,
+		};
+		entries["b"] = 2;
+		io.println(entries["b"]);`, "2\n", false},
+		{`using "io";
+		let text = "abc";
+		text[0:1] = ["x"];`, "interpreter error: text[0:1] = [x];\n                   ^~~~~~~~~~~~~~~~~\ncannot assign to slice of 'text', strings are immutable on line 3 col 0", true},
+		{`using "io";
+		let target = [1, 2, 3];
+		target[0:1] = 5;`, "interpreter error: target[0:1] = 5;\n                   ^~~~~~~~~~~~~~~~~\ncannot assign int to slice of 'target', expected array on line 3 col 0", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
@@ -46,12 +46,12 @@ func TestSplit(t *testing.T) {
 		using "strings";
 
 		let words = strings.split("Hello world");
-		io.print(words[0]);`, "interpreter error: unexpected number of args for strings.split, expected 2 got 1", true},
+		io.print(words[0]);`, "interpreter error: let words = strings.split(Hello world);\n                   ~~~~~~~~~~~~^~~~~~~~~~~~~~~~~~~~~~~~~~~~\nunexpected number of args for strings.split, expected 2 got 1 on line 4 col 12", true},
 		{`using "io";
 		using "strings";
 
 		let words = strings.split("Hello world", ",", "");
-		io.print(words[0]);`, "interpreter error: unexpected number of args for strings.split, expected 2 got 3", true},
+		io.print(words[0]);`, "interpreter error: let words = strings.split(Hello world, ,, );\n                   ~~~~~~~~~~~~^~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\nunexpected number of args for strings.split, expected 2 got 3 on line 4 col 12", true},
 	}
 
 	for _, tt := range tests {
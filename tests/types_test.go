@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"goblin.org/main/program"
+)
+
+func TestTypeAnnotations(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source      string
+		want        string
+		throwsError bool
+	}{
+		{`using "io";
+		let int typedInt = 10;
+		const string typedStr = "hello";
+		let array<int> typedArr = [1, 2, 3];
+		let map<string, int> typedMap = {
+			"one": 1,
+		};
+		io.println(typedInt);
+		io.println(typedStr);
+		io.println(typedArr);`, "10\nhello\n[1, 2, 3]\n", false},
+		{`using "io";
+		fn int typedAdd(int a, int b) {
+			return a + b;
+		}
+		io.println(typedAdd(1, 2));`, "3\n", false},
+		{`using "io";
+		fn float typedHalf(float f) {
+			return f / 2;
+		}
+		io.println(typedHalf(10));`, "5\n", false},
+		{`using "io";
+		fn typedTotal(...int nums) {
+			io.println(nums);
+		}
+		typedTotal(1, 2, 3);`, "[1, 2, 3]\n", false},
+		{`using "io";
+		let map<string, array<int>> nestedTypes = {
+			"evens": [2, 4],
+		};
+		io.println(nestedTypes["evens"]);`, "[2, 4]\n", false},
+		{`using "io";
+		fn typedAnything(any a, b) {
+			io.println(a);
+		}
+		typedAnything("x", 1);`, "x\n", false},
+		{`let int badInt = "a";`, "type error: let int badInt = a;\n            ~~~~^~~~~~~~~~~~~~~~\ncannot use string as int in decleration of 'badInt' on line 1 col 4", true},
+		{`let int reassigned = 1;
+		reassigned = true;`, "type error: reassigned = true;\n            ^~~~~~~~~~~~~~~~~~~\ncannot assign bool to 'reassigned' of type int on line 2 col 0", true},
+		{`fn string badReturn() {
+			return 1;
+		}`, "type error: return 1;\n            ^~~~~~~~~~\nfn badReturn returns string, got int on line 2 col 0", true},
+		{`fn typedParam(int a) {
+		}
+		typedParam("a");`, "type error: typedParam(a);\n            ^~~~~~~~~~~~~~~\nparam 'a' of fn typedParam expects int, got string on line 3 col 0", true},
+		{`let array<string> badArr = ["a", 2];`, "type error: let array<string> badArr = [a, 2];\n            ~~~~^~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\ncannot use int as string in element 1 of 'badArr' on line 1 col 4", true},
+		{`let Point p = 1;`, "parse error: let Point p = 1;\n             ~~~~~~~~~^~~~~~~~\nunknown type 'Point' on line 1 col 9", true},
+		{`let array x = [];`, "parse error: let array x = [];\n             ~~~~~~~~~^~~~~~~~~\ntype 'array' expects 1 type params on line 1 col 9", true},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if !tt.throwsError {
+
+				// When tests aren't supposed to throw an error.
+				if err != nil {
+					t.Errorf(err.Error())
+				}
+
+				if output.String() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, output.String())
+				}
+			} else {
+
+				// When tests are supposed to throw an error.
+				if err.Error() != tt.want {
+					t.Errorf("expected `%v`, received `%v`", tt.want, err.Error())
+				}
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestRuntimeParamTypes(t *testing.T) {
+
+	// Setup the program env.
+	HarnessSetup()
+
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`using "strings";
+		fn int double(int n) {
+			return n * 2;
+		}
+		let parts = strings.split("a b", " ");
+		double(parts[0]);`, "interpreter error: double(parts[0]);\n                   ^~~~~~~~~~~~~~~~~~\nparam 'n' of fn double expects int, got string on line 6 col 0"},
+		{`using "strings";
+		fn countWords(array<int> words) {
+		}
+		let words = strings.split("a b", " ");
+		countWords(words);`, "interpreter error: countWords(words);\n                   ^~~~~~~~~~~~~~~~~~~\nparam 'words' of fn countWords expects array<int>, got array on line 5 col 0"},
+		{`using "strings";
+		fn typedRest(...int nums) {
+		}
+		let letters = strings.split("a b", " ");
+		typedRest(1, ...letters);`, "interpreter error: typedRest(1, ...letters);\n                   ^~~~~~~~~~~~~~~~~~~~~~~~~~\nparam 'nums' of fn typedRest expects int, got string on line 5 col 0"},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Run the program.
+			_, err := program.Run(string(tt.source), env)
+
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("expected `%v`, received `%v`", tt.want, err)
+			}
+
+			FlushBuffer()
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+
+	var tests = []struct {
+		source string
+		want   string
+	}{
+		{`fn int checkedAdd(int a, int b) {
+			return a + b;
+		}
+		let int checked = checkedAdd(1, 2);`, ""},
+		{`let string checkedStr = 1;
+		let bool checkedBool = "a";`, "type error: let string checkedStr = 1;\n            ~~~~^~~~~~~~~~~~~~~~~~~~~~~\ncannot use int as string in decleration of 'checkedStr' on line 1 col 4\n" +
+			"type error: let bool checkedBool = a;\n            ~~~~^~~~~~~~~~~~~~~~~~~~~~\ncannot use string as bool in decleration of 'checkedBool' on line 2 col 4"},
+
+		// Every syntax error is reported, not just the first.
+		{`let checkA = ;
+		let checkB = ;`, "parse error: let checkA = ;\n             ~~~~~~~~~~~~^~~\nunexpected token found during parsing ';' on line 1 col 12\n" +
+			"parse error: let checkB = ;\n             ~~~~~~~~~~~~^~~\nunexpected token found during parsing ';' on line 2 col 12"},
+	}
+
+	for _, tt := range tests {
+		testname := fmt.Sprintf("%v, %v", tt.source, tt.want)
+		t.Run(testname, func(t *testing.T) {
+
+			// Check the program, without running it.
+			err := program.Check(tt.source)
+
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+
+			if got != tt.want {
+				t.Errorf("expected `%v`, received `%v`", tt.want, got)
+			}
+		})
+	}
+}
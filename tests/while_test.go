@@ -35,7 +35,7 @@ func TestWhile(t *testing.T) {
 		while (k < 1) {
 			io.println(arr[k]);
 			k++;
-		}`, "interpreter error: index out of bounds for index 0", true},
+		}`, "interpreter error: io.println(arr[k]);\n                   ~~~~~~~~~~~^~~~~~~~~\nindex out of bounds for index 0 on line 5 col 11", true},
 	}
 
 	for _, tt := range tests {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"unicode/utf8"
 )
 
 // Removes the first element from an array and returns that removed element.
@@ -76,23 +77,32 @@ expecting 'EOL' on line 1 col 10
 */
 func GenerateParserError(auditLine string, specificToken string, line int, col int, message string) string {
 
-	origin := "parse error: "
+	return GenerateError("parse error: ", auditLine, specificToken, line, col, message)
+}
+
+// Formats an error string the same way as GenerateParserError, with the underline aligned
+// to whichever origin (e.g. 'type error: ') the message will be prefixed with.
+func GenerateError(origin string, auditLine string, specificToken string, line int, col int, message string) string {
+
 	underlines := ""
 
 	for i := 0; i < len(origin); i++ {
 		underlines += " "
 	}
 
-	for i := 0; i < len(auditLine)+1; i++ {
+	// Columns count characters rather than bytes.
+	col += utf8.RuneCountInString(specificToken)
+
+	for i := 0; i < utf8.RuneCountInString(auditLine)+1; i++ {
 
-		if i == (col + len(specificToken)) {
+		if i == col {
 			underlines += "^"
 		} else {
 			underlines += "~"
 		}
 	}
 
-	msg := fmt.Sprintf("%v\n%v\n%v on line %v col %v", auditLine, underlines, message, line, col+len(specificToken))
+	msg := fmt.Sprintf("%v\n%v\n%v on line %v col %v", auditLine, underlines, message, line, col)
 
 	return msg
 }